@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//Value type of typed attribute
+const (
+	ATTRIBUTE_TYPE_STRING  = "string"
+	ATTRIBUTE_TYPE_INTEGER = "integer"
+	ATTRIBUTE_TYPE_BOOLEAN = "boolean"
+	ATTRIBUTE_TYPE_JSON    = "json"
+	ATTRIBUTE_TYPE_BINARY  = "binary"
+)
+
+//ERR_ATTRIBUTE_TYPE_MISMATCH is returned when value type of attribute is not the requested type
+var ERR_ATTRIBUTE_TYPE_MISMATCH = errors.New("attribute type mismatch")
+
+//NewStringAttribute return attribute of utf-8 string
+func NewStringAttribute(key, value string) *DDOAttribute {
+	return newTypedAttribute(key, ATTRIBUTE_TYPE_STRING, []byte(value))
+}
+
+//NewIntegerAttribute return attribute of integer, which is encoded as decimal string
+func NewIntegerAttribute(key string, value int64) *DDOAttribute {
+	return newTypedAttribute(key, ATTRIBUTE_TYPE_INTEGER, []byte(strconv.FormatInt(value, 10)))
+}
+
+//NewBooleanAttribute return attribute of boolean, which is encoded as true or false
+func NewBooleanAttribute(key string, value bool) *DDOAttribute {
+	return newTypedAttribute(key, ATTRIBUTE_TYPE_BOOLEAN, []byte(strconv.FormatBool(value)))
+}
+
+//NewJSONAttribute return attribute of value encoded as JSON
+func NewJSONAttribute(key string, value interface{}) (*DDOAttribute, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("attribute:%s json marshal error:%s", key, err)
+	}
+	return newTypedAttribute(key, ATTRIBUTE_TYPE_JSON, data), nil
+}
+
+//NewBinaryAttribute return attribute of raw bytes
+func NewBinaryAttribute(key string, value []byte) *DDOAttribute {
+	return newTypedAttribute(key, ATTRIBUTE_TYPE_BINARY, value)
+}
+
+//NewServiceAttribute return attribute of service endpoint, which is service <did>#<name> of DID document.
+//Its key is DID_SERVICE_ATTRIBUTE_PREFIX + name, and its value type is the type of service.
+func NewServiceAttribute(name, serviceType, endpoint string) *DDOAttribute {
+	return newTypedAttribute(DID_SERVICE_ATTRIBUTE_PREFIX+name, serviceType, []byte(endpoint))
+}
+
+func newTypedAttribute(key, valueType string, value []byte) *DDOAttribute {
+	return &DDOAttribute{
+		Key:       []byte(key),
+		ValueType: []byte(valueType),
+		Value:     value,
+	}
+}
+
+func (this *DDOAttribute) GetKey() string {
+	return string(this.Key)
+}
+
+func (this *DDOAttribute) GetValueType() string {
+	return string(this.ValueType)
+}
+
+//IsService return whether attribute is a service endpoint
+func (this *DDOAttribute) IsService() bool {
+	return strings.HasPrefix(this.GetKey(), DID_SERVICE_ATTRIBUTE_PREFIX)
+}
+
+func (this *DDOAttribute) checkType(valueType string) error {
+	if this.IsService() || this.GetValueType() != valueType {
+		return fmt.Errorf("%w, attribute:%s type:%s expect:%s", ERR_ATTRIBUTE_TYPE_MISMATCH, this.GetKey(), this.GetValueType(), valueType)
+	}
+	return nil
+}
+
+func (this *DDOAttribute) AsString() (string, error) {
+	err := this.checkType(ATTRIBUTE_TYPE_STRING)
+	if err != nil {
+		return "", err
+	}
+	return string(this.Value), nil
+}
+
+func (this *DDOAttribute) AsInteger() (int64, error) {
+	err := this.checkType(ATTRIBUTE_TYPE_INTEGER)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseInt(string(this.Value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("attribute:%s parse integer error:%s", this.GetKey(), err)
+	}
+	return value, nil
+}
+
+func (this *DDOAttribute) AsBoolean() (bool, error) {
+	err := this.checkType(ATTRIBUTE_TYPE_BOOLEAN)
+	if err != nil {
+		return false, err
+	}
+	value, err := strconv.ParseBool(string(this.Value))
+	if err != nil {
+		return false, fmt.Errorf("attribute:%s parse boolean error:%s", this.GetKey(), err)
+	}
+	return value, nil
+}
+
+//DecodeJSON unmarshal value of JSON attribute into v
+func (this *DDOAttribute) DecodeJSON(v interface{}) error {
+	err := this.checkType(ATTRIBUTE_TYPE_JSON)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(this.Value, v)
+	if err != nil {
+		return fmt.Errorf("attribute:%s json unmarshal error:%s", this.GetKey(), err)
+	}
+	return nil
+}
+
+func (this *DDOAttribute) AsBinary() ([]byte, error) {
+	err := this.checkType(ATTRIBUTE_TYPE_BINARY)
+	if err != nil {
+		return nil, err
+	}
+	return this.Value, nil
+}
+
+//AsService return service endpoint of attribute in DID document of ontId
+func (this *DDOAttribute) AsService(ontId string) (*DIDService, error) {
+	if !this.IsService() {
+		return nil, fmt.Errorf("%w, attribute:%s is not a service", ERR_ATTRIBUTE_TYPE_MISMATCH, this.GetKey())
+	}
+	return &DIDService{
+		Id:              ontId + "#" + strings.TrimPrefix(this.GetKey(), DID_SERVICE_ATTRIBUTE_PREFIX),
+		Type:            this.GetValueType(),
+		ServiceEndpoint: string(this.Value),
+	}, nil
+}
+
+//Decode return value of attribute by its value type, which is string, int64, bool, []byte,
+//decoded JSON value, or *DIDService with id of #<name>. Value of unknown type is returned as []byte.
+func (this *DDOAttribute) Decode() (interface{}, error) {
+	if this.IsService() {
+		return this.AsService("")
+	}
+	switch this.GetValueType() {
+	case ATTRIBUTE_TYPE_STRING:
+		return this.AsString()
+	case ATTRIBUTE_TYPE_INTEGER:
+		return this.AsInteger()
+	case ATTRIBUTE_TYPE_BOOLEAN:
+		return this.AsBoolean()
+	case ATTRIBUTE_TYPE_JSON:
+		var value interface{}
+		err := this.DecodeJSON(&value)
+		if err != nil {
+			return nil, err
+		}
+		return value, nil
+	default:
+		return this.Value, nil
+	}
+}
+
+//jsonValue return value of attribute as decoded JSON value for schema validation.
+//Binary value is hex encoded string, and service is object of id, type and serviceEndpoint.
+func (this *DDOAttribute) jsonValue() (interface{}, error) {
+	value, err := this.Decode()
+	if err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case []byte:
+		return hex.EncodeToString(v), nil
+	case int64:
+		return float64(v), nil
+	case *DIDService:
+		return map[string]interface{}{"id": v.Id, "type": v.Type, "serviceEndpoint": v.ServiceEndpoint}, nil
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,269 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+//ERR_ATTRIBUTE_SCHEMA is returned when attribute value doesn't match its registered schema
+var ERR_ATTRIBUTE_SCHEMA = errors.New("attribute doesn't match schema")
+
+//JSONSchema is a subset of JSON Schema, which supports type, enum, properties, required,
+//additionalProperties, items, minimum, maximum, minLength, maxLength and pattern.
+type JSONSchema struct {
+	Type                 interface{}            `json:"type,omitempty"` //Name of type, or list of names
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	pattern              *regexp.Regexp
+}
+
+//ParseJSONSchema parse JSON schema, and compile its patterns.
+//Schema with unsupported keyword is rejected, instead of ignoring the constraint silently.
+func ParseJSONSchema(data []byte) (*JSONSchema, error) {
+	schema := &JSONSchema{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(schema)
+	if err != nil {
+		return nil, fmt.Errorf("json unmarshal schema error:%s", err)
+	}
+	err = schema.compile()
+	if err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+func (this *JSONSchema) compile() error {
+	switch t := this.Type.(type) {
+	case nil, string:
+	case []interface{}:
+		for _, name := range t {
+			if _, ok := name.(string); !ok {
+				return fmt.Errorf("invalid type:%v of schema", name)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid type:%v of schema", t)
+	}
+	if this.Pattern != "" {
+		pattern, err := regexp.Compile(this.Pattern)
+		if err != nil {
+			return fmt.Errorf("compile pattern:%s error:%s", this.Pattern, err)
+		}
+		this.pattern = pattern
+	}
+	for _, property := range this.Properties {
+		err := property.compile()
+		if err != nil {
+			return err
+		}
+	}
+	if this.Items != nil {
+		return this.Items.compile()
+	}
+	return nil
+}
+
+//Validate check decoded JSON value against schema
+func (this *JSONSchema) Validate(value interface{}) error {
+	return this.validate(value, "$")
+}
+
+func (this *JSONSchema) validate(value interface{}, path string) error {
+	if !this.matchType(value) {
+		return fmt.Errorf("%w, %s type:%s expect:%v", ERR_ATTRIBUTE_SCHEMA, path, jsonTypeName(value), this.Type)
+	}
+	if len(this.Enum) > 0 {
+		found := false
+		for _, item := range this.Enum {
+			if reflect.DeepEqual(item, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w, %s is not one of enum", ERR_ATTRIBUTE_SCHEMA, path)
+		}
+	}
+	switch v := value.(type) {
+	case float64:
+		if this.Minimum != nil && v < *this.Minimum {
+			return fmt.Errorf("%w, %s:%v less than minimum:%v", ERR_ATTRIBUTE_SCHEMA, path, v, *this.Minimum)
+		}
+		if this.Maximum != nil && v > *this.Maximum {
+			return fmt.Errorf("%w, %s:%v greater than maximum:%v", ERR_ATTRIBUTE_SCHEMA, path, v, *this.Maximum)
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if this.MinLength != nil && length < *this.MinLength {
+			return fmt.Errorf("%w, %s length:%d less than minLength:%d", ERR_ATTRIBUTE_SCHEMA, path, length, *this.MinLength)
+		}
+		if this.MaxLength != nil && length > *this.MaxLength {
+			return fmt.Errorf("%w, %s length:%d greater than maxLength:%d", ERR_ATTRIBUTE_SCHEMA, path, length, *this.MaxLength)
+		}
+		if this.pattern != nil && !this.pattern.MatchString(v) {
+			return fmt.Errorf("%w, %s doesn't match pattern:%s", ERR_ATTRIBUTE_SCHEMA, path, this.Pattern)
+		}
+	case []interface{}:
+		if this.Items == nil {
+			return nil
+		}
+		for i, item := range v {
+			err := this.Items.validate(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range this.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%w, %s missing required property:%s", ERR_ATTRIBUTE_SCHEMA, path, name)
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := this.Properties[name]
+			if !ok {
+				if this.AdditionalProperties != nil && !*this.AdditionalProperties {
+					return fmt.Errorf("%w, %s additional property:%s", ERR_ATTRIBUTE_SCHEMA, path, name)
+				}
+				continue
+			}
+			err := property.validate(v[name], path+"."+name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (this *JSONSchema) matchType(value interface{}) bool {
+	switch t := this.Type.(type) {
+	case string:
+		return matchJSONType(t, value)
+	case []interface{}:
+		for _, name := range t {
+			if matchJSONType(name.(string), value) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func matchJSONType(name string, value interface{}) bool {
+	if name == "integer" {
+		v, ok := value.(float64)
+		return ok && v == math.Trunc(v)
+	}
+	return jsonTypeName(value) == name
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+//AttributeSchemaRegistry is the JSON schemas of attribute values by attribute key
+type AttributeSchemaRegistry struct {
+	schemas map[string]*JSONSchema
+	lock    sync.RWMutex
+}
+
+func NewAttributeSchemaRegistry() *AttributeSchemaRegistry {
+	return &AttributeSchemaRegistry{schemas: make(map[string]*JSONSchema)}
+}
+
+//Register parse schema and register it for attribute of key
+func (this *AttributeSchemaRegistry) Register(key string, schema []byte) error {
+	jsonSchema, err := ParseJSONSchema(schema)
+	if err != nil {
+		return fmt.Errorf("schema of attribute:%s error:%s", key, err)
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.schemas[key] = jsonSchema
+	return nil
+}
+
+func (this *AttributeSchemaRegistry) Unregister(key string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	delete(this.schemas, key)
+}
+
+//Validate check value of attributes against registered schemas, attribute without schema is not checked.
+//Value of integer attribute is number, binary value is hex string, and service is object of id, type and serviceEndpoint.
+func (this *AttributeSchemaRegistry) Validate(attributes []*DDOAttribute) error {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	for _, attr := range attributes {
+		schema, ok := this.schemas[attr.GetKey()]
+		if !ok {
+			continue
+		}
+		value, err := attr.jsonValue()
+		if err != nil {
+			return err
+		}
+		err = schema.Validate(value)
+		if err != nil {
+			return fmt.Errorf("attribute:%s error:%w", attr.GetKey(), err)
+		}
+	}
+	return nil
+}
+
+//SetAttributeSchemas set schemas to validate attributes before building RegIDWithAttributes and AddAttributes transaction.
+//Attributes are not validated if registry is nil.
+func (this *OntId) SetAttributeSchemas(registry *AttributeSchemaRegistry) {
+	this.attrSchemas = registry
+}
+
+func (this *OntId) validateAttributes(attributes []*DDOAttribute) error {
+	if this.attrSchemas == nil {
+		return nil
+	}
+	return this.attrSchemas.Validate(attributes)
+}
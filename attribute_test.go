@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypedAttribute(t *testing.T) {
+	age := NewIntegerAttribute("age", -18)
+	value, err := age.AsInteger()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-18), value)
+	_, err = age.AsString()
+	assert.True(t, errors.Is(err, ERR_ATTRIBUTE_TYPE_MISMATCH))
+
+	verified, err := NewBooleanAttribute("verified", true).AsBoolean()
+	assert.Nil(t, err)
+	assert.True(t, verified)
+
+	profile, err := NewJSONAttribute("profile", map[string]interface{}{"name": "alice"})
+	assert.Nil(t, err)
+	decoded, err := profile.Decode()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "alice"}, decoded)
+
+	decoded, err = NewBinaryAttribute("photo", []byte{1, 2}).Decode()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{1, 2}, decoded)
+
+	hub := NewServiceAttribute("hub", "IdentityHub", "https://hub.example.com")
+	assert.True(t, hub.IsService())
+	service, err := hub.AsService("did:ont:alice")
+	assert.Nil(t, err)
+	assert.Equal(t, &DIDService{Id: "did:ont:alice#hub", Type: "IdentityHub", ServiceEndpoint: "https://hub.example.com"}, service)
+	_, err = NewStringAttribute("name", "alice").AsService("did:ont:alice")
+	assert.True(t, errors.Is(err, ERR_ATTRIBUTE_TYPE_MISMATCH))
+}
+
+func TestAttributeSchemaRegistry(t *testing.T) {
+	registry := NewAttributeSchemaRegistry()
+	assert.Nil(t, registry.Register("age", []byte(`{"type":"integer","minimum":0,"maximum":150}`)))
+	assert.Nil(t, registry.Register("profile", []byte(`{"type":"object","required":["name"],"additionalProperties":false,
+		"properties":{"name":{"type":"string","minLength":1},"tags":{"type":"array","items":{"enum":["a","b"]}}}}`)))
+	assert.Nil(t, registry.Register("email", []byte(`{"type":["string","null"],"pattern":"^[^@]+@[^@]+$"}`)))
+	assert.NotNil(t, registry.Register("bad", []byte(`{"pattern":"("}`)))
+	assert.NotNil(t, registry.Register("bad", []byte(`{"type":"array","minItems":1}`)))
+	assert.NotNil(t, registry.Register("bad", []byte(`{"type":"object","properties":{"name":{"type":"string","format":"email"}}}`)))
+
+	valid, err := NewJSONAttribute("profile", map[string]interface{}{"name": "alice", "tags": []string{"a"}})
+	assert.Nil(t, err)
+	assert.Nil(t, registry.Validate([]*DDOAttribute{NewIntegerAttribute("age", 18), valid,
+		NewStringAttribute("email", "alice@example.com"), NewStringAttribute("other", "")}))
+
+	invalids := []*DDOAttribute{
+		NewIntegerAttribute("age", 200),
+		NewStringAttribute("age", "18"),
+		NewStringAttribute("email", "alice"),
+	}
+	for _, values := range []map[string]interface{}{{}, {"name": ""}, {"name": "a", "x": 1}, {"name": "a", "tags": []string{"c"}}} {
+		attr, err := NewJSONAttribute("profile", values)
+		assert.Nil(t, err)
+		invalids = append(invalids, attr)
+	}
+	for _, attr := range invalids {
+		assert.True(t, errors.Is(registry.Validate([]*DDOAttribute{attr}), ERR_ATTRIBUTE_SCHEMA), attr.GetKey())
+	}
+}
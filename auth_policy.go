@@ -0,0 +1,426 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DNAProject/DNA-go-sdk/client"
+	"github.com/DNAProject/DNA/common"
+	"github.com/DNAProject/DNA/core/types"
+)
+
+//Method of auth contract used by change of AuthPlan
+const (
+	AUTH_CHANGE_ASSIGN_FUNCS  = "assignFuncsToRole"
+	AUTH_CHANGE_ASSIGN_ONTIDS = "assignOntIDsToRole"
+	AUTH_CHANGE_DELEGATE      = "delegate"
+	AUTH_CHANGE_WITHDRAW      = "withdraw"
+)
+
+//ERR_INVALID_AUTH_POLICY is returned when auth policy is malformed
+var ERR_INVALID_AUTH_POLICY = errors.New("invalid auth policy")
+
+//ERR_AUTH_SIGNER_NOT_FOUND is returned when admin isn't the admin of contract, or signer of delegation is not given
+var ERR_AUTH_SIGNER_NOT_FOUND = errors.New("auth signer not found")
+
+//AuthPolicy is declarative permissions of contract, which is applied by AuthManager
+type AuthPolicy struct {
+	Roles       map[string][]string     `json:"roles"`                 //Functions by role
+	Members     map[string][]string     `json:"members,omitempty"`     //Roles by identity
+	Delegations []*AuthPolicyDelegation `json:"delegations,omitempty"` //Roles delegated between identities
+}
+
+//AuthPolicyDelegation is a role delegated by From to To
+type AuthPolicyDelegation struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Role   string `json:"role"`
+	Period int    `json:"period"` //Seconds of delegation
+	Level  int    `json:"level"`
+}
+
+func ParseAuthPolicy(data []byte) (*AuthPolicy, error) {
+	policy := &AuthPolicy{}
+	err := json.Unmarshal(data, policy)
+	if err != nil {
+		return nil, fmt.Errorf("json unmarshal auth policy error:%s", err)
+	}
+	err = policy.Check()
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+//Check return ERR_INVALID_AUTH_POLICY if roles of members or delegations are not declared, or delegation is invalid
+func (this *AuthPolicy) Check() error {
+	for ontId, roles := range this.Members {
+		for _, role := range roles {
+			if _, ok := this.Roles[role]; !ok {
+				return fmt.Errorf("%w, role:%s of member:%s is not declared", ERR_INVALID_AUTH_POLICY, role, ontId)
+			}
+		}
+	}
+	delegations := make(map[string]bool, len(this.Delegations))
+	for _, delegation := range this.Delegations {
+		if _, ok := this.Roles[delegation.Role]; !ok {
+			return fmt.Errorf("%w, role:%s of delegation is not declared", ERR_INVALID_AUTH_POLICY, delegation.Role)
+		}
+		if delegation.From == "" || delegation.To == "" || delegation.From == delegation.To {
+			return fmt.Errorf("%w, delegation of role:%s from:%s to:%s", ERR_INVALID_AUTH_POLICY, delegation.Role, delegation.From, delegation.To)
+		}
+		if delegation.Period <= 0 || delegation.Level <= 0 {
+			return fmt.Errorf("%w, delegation of role:%s to:%s period:%d level:%d", ERR_INVALID_AUTH_POLICY,
+				delegation.Role, delegation.To, delegation.Period, delegation.Level)
+		}
+		key := delegation.From + "\n" + delegation.To + "\n" + delegation.Role
+		if delegations[key] {
+			return fmt.Errorf("%w, duplicate delegation of role:%s from:%s to:%s", ERR_INVALID_AUTH_POLICY,
+				delegation.Role, delegation.From, delegation.To)
+		}
+		delegations[key] = true
+	}
+	return nil
+}
+
+//AuthChange is an auth transaction to apply
+type AuthChange struct {
+	Method string
+	Role   string
+	Funcs  []string //Functions of assignFuncsToRole
+	OntIds []string //Identities of assignOntIDsToRole
+	From   string   //Delegator of delegate and withdraw
+	To     string   //Delegatee of delegate and withdraw
+	Period int
+	Level  int
+}
+
+func (this *AuthChange) String() string {
+	switch this.Method {
+	case AUTH_CHANGE_ASSIGN_FUNCS:
+		return fmt.Sprintf("%s role:%s funcs:%s", this.Method, this.Role, strings.Join(this.Funcs, ","))
+	case AUTH_CHANGE_ASSIGN_ONTIDS:
+		return fmt.Sprintf("%s role:%s ontids:%s", this.Method, this.Role, strings.Join(this.OntIds, ","))
+	case AUTH_CHANGE_DELEGATE:
+		return fmt.Sprintf("%s role:%s from:%s to:%s period:%ds level:%d", this.Method, this.Role, this.From, this.To, this.Period, this.Level)
+	default:
+		return fmt.Sprintf("%s role:%s from:%s to:%s", this.Method, this.Role, this.From, this.To)
+	}
+}
+
+//AuthPlan is the changes to apply auth policy to contract
+type AuthPlan struct {
+	Contract    common.Address
+	Admin       string
+	Changes     []*AuthChange
+	Unsupported []string //Differences cannot be applied by auth contract, such as removing function from role
+}
+
+func (this *AuthPlan) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "contract:%s admin:%s changes:%d\n", this.Contract.ToHexString(), this.Admin, len(this.Changes))
+	for i, change := range this.Changes {
+		fmt.Fprintf(&sb, "  %d. %s\n", i+1, change)
+	}
+	for _, diff := range this.Unsupported {
+		fmt.Fprintf(&sb, "  unsupported: %s\n", diff)
+	}
+	return sb.String()
+}
+
+//AuthSigner is identity which signs auth transaction by controller of key at KeyIndex
+type AuthSigner struct {
+	OntId      string
+	KeyIndex   int
+	Controller Signer
+}
+
+var (
+	DEFAULT_AUTH_MANAGER_GAS_PRICE = uint64(500)
+	DEFAULT_AUTH_MANAGER_GAS_LIMIT = uint64(20000)
+)
+
+//AuthManagerConfig config of AuthManager
+type AuthManagerConfig struct {
+	GasPrice     uint64
+	GasLimit     uint64
+	SubmitConfig *client.TxSubmitConfig //client.NewTxSubmitConfig() is used if nil
+	Output       io.Writer              //Planned changes are printed to Output in dry-run mode, os.Stdout is used if nil
+}
+
+//AuthManager diff auth policy against auth contract, and apply the changes.
+//Each transaction is confirmed before next one, since delegation depends on role assigned before.
+type AuthManager struct {
+	auth   *Auth
+	payer  *Account
+	config *AuthManagerConfig
+}
+
+func NewAuthManagerConfig() *AuthManagerConfig {
+	return &AuthManagerConfig{
+		GasPrice: DEFAULT_AUTH_MANAGER_GAS_PRICE,
+		GasLimit: DEFAULT_AUTH_MANAGER_GAS_LIMIT,
+	}
+}
+
+//NewAuthManager return AuthManager whose transactions are paid by payer, NewAuthManagerConfig() is used if config is nil
+func (this *Auth) NewAuthManager(payer *Account, config *AuthManagerConfig) *AuthManager {
+	if config == nil {
+		config = NewAuthManagerConfig()
+	}
+	return &AuthManager{
+		auth:   this,
+		payer:  payer,
+		config: config,
+	}
+}
+
+//Plan return changes to apply policy to contract administrated by admin.
+//Storage of auth contract is indexed by role and identity, so only roles and identities in policy are compared.
+func (this *AuthManager) Plan(contractAddress common.Address, policy *AuthPolicy, admin string) (*AuthPlan, error) {
+	err := policy.Check()
+	if err != nil {
+		return nil, err
+	}
+	onChainAdmin, err := this.auth.GetAdmin(contractAddress)
+	if err != nil {
+		return nil, fmt.Errorf("GetAdmin error:%s", err)
+	}
+	if onChainAdmin != admin {
+		return nil, fmt.Errorf("%w, admin of contract is:%s", ERR_AUTH_SIGNER_NOT_FOUND, onChainAdmin)
+	}
+	plan := &AuthPlan{
+		Contract:    contractAddress,
+		Admin:       admin,
+		Changes:     make([]*AuthChange, 0),
+		Unsupported: make([]string, 0),
+	}
+	err = this.planRoleFuncs(plan, policy)
+	if err != nil {
+		return nil, err
+	}
+	err = this.planMembers(plan, policy)
+	if err != nil {
+		return nil, err
+	}
+	err = this.planDelegations(plan, policy)
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (this *AuthManager) planRoleFuncs(plan *AuthPlan, policy *AuthPolicy) error {
+	for _, role := range sortedKeys(policy.Roles) {
+		funcs, err := this.auth.GetRoleFuncs(plan.Contract, []byte(role))
+		if err != nil {
+			return fmt.Errorf("GetRoleFuncs of role:%s error:%s", role, err)
+		}
+		missing := diffStrings(policy.Roles[role], funcs)
+		if len(missing) > 0 {
+			plan.Changes = append(plan.Changes, &AuthChange{Method: AUTH_CHANGE_ASSIGN_FUNCS, Role: role, Funcs: missing})
+		}
+		for _, funcName := range diffStrings(funcs, policy.Roles[role]) {
+			plan.Unsupported = append(plan.Unsupported, fmt.Sprintf("remove func:%s from role:%s", funcName, role))
+		}
+	}
+	return nil
+}
+
+func (this *AuthManager) planMembers(plan *AuthPlan, policy *AuthPolicy) error {
+	newMembers := make(map[string][]string)
+	for _, ontId := range sortedKeys(policy.Members) {
+		tokens, err := this.auth.GetRoleTokens(plan.Contract, []byte(ontId))
+		if err != nil {
+			return fmt.Errorf("GetRoleTokens of:%s error:%s", ontId, err)
+		}
+		//token assigned by admin never expire, others are delegated
+		assigned := make([]string, 0, len(tokens))
+		for _, token := range tokens {
+			if token.ExpireTime == math.MaxUint32 {
+				assigned = append(assigned, token.Role)
+			}
+		}
+		for _, role := range diffStrings(policy.Members[ontId], assigned) {
+			newMembers[role] = append(newMembers[role], ontId)
+		}
+		for _, role := range diffStrings(assigned, policy.Members[ontId]) {
+			plan.Unsupported = append(plan.Unsupported, fmt.Sprintf("remove role:%s from:%s", role, ontId))
+		}
+	}
+	for _, role := range sortedKeys(newMembers) {
+		plan.Changes = append(plan.Changes, &AuthChange{Method: AUTH_CHANGE_ASSIGN_ONTIDS, Role: role, OntIds: newMembers[role]})
+	}
+	return nil
+}
+
+//planDelegations withdraw active delegations to identities of policy which are not declared, and delegate missing ones.
+//Active delegation which is different from the declared one is withdrawn and delegated again.
+func (this *AuthManager) planDelegations(plan *AuthPlan, policy *AuthPolicy) error {
+	delegatees := make(map[string][]*AuthPolicyDelegation)
+	ontIds := sortedKeys(policy.Members)
+	for _, delegation := range policy.Delegations {
+		if _, ok := policy.Members[delegation.To]; !ok && delegatees[delegation.To] == nil {
+			ontIds = append(ontIds, delegation.To)
+		}
+		delegatees[delegation.To] = append(delegatees[delegation.To], delegation)
+	}
+	sort.Strings(ontIds)
+	now := time.Now()
+	withdraws := make([]*AuthChange, 0)
+	delegates := make([]*AuthChange, 0)
+	for _, ontId := range ontIds {
+		active, err := this.auth.GetDelegations(plan.Contract, []byte(ontId))
+		if err != nil {
+			return fmt.Errorf("GetDelegations of:%s error:%s", ontId, err)
+		}
+		for _, d := range delegatees[ontId] {
+			var exist *AuthDelegation
+			for _, delegation := range active {
+				if d.From == delegation.Root && d.Role == delegation.Role {
+					exist = delegation
+					break
+				}
+			}
+			if exist != nil && isSameDelegation(d, exist, now) {
+				continue
+			}
+			if exist != nil {
+				withdraws = append(withdraws, &AuthChange{Method: AUTH_CHANGE_WITHDRAW, Role: d.Role, From: d.From, To: ontId})
+			}
+			delegates = append(delegates, &AuthChange{Method: AUTH_CHANGE_DELEGATE, Role: d.Role, From: d.From, To: d.To, Period: d.Period, Level: d.Level})
+		}
+		for _, delegation := range active {
+			declared := false
+			for _, d := range delegatees[ontId] {
+				if d.From == delegation.Root && d.Role == delegation.Role {
+					declared = true
+					break
+				}
+			}
+			if !declared {
+				withdraws = append(withdraws, &AuthChange{Method: AUTH_CHANGE_WITHDRAW, Role: delegation.Role, From: delegation.Root, To: ontId})
+			}
+		}
+	}
+	plan.Changes = append(plan.Changes, withdraws...)
+	plan.Changes = append(plan.Changes, delegates...)
+	return nil
+}
+
+//isSameDelegation return whether active delegation has the declared level and period.
+//Period is not saved by auth contract, so delegation expiring later than the declared period from now is different,
+//and delegation expiring earlier is regarded as the same one which has been delegated for a while.
+func isSameDelegation(declared *AuthPolicyDelegation, active *AuthDelegation, now time.Time) bool {
+	if int(active.Level) != declared.Level {
+		return false
+	}
+	return int64(active.ExpireTime) <= now.Unix()+int64(declared.Period)
+}
+
+//ApplyPolicy apply policy to contract, and return the plan. In dry-run mode, planned changes are printed but not sent.
+//Changes of roles are signed by admin, and delegations are signed by their delegators in signers.
+//If error is returned, ApplyPolicy can be called again, and changes already applied are not sent again.
+func (this *AuthManager) ApplyPolicy(contractAddress common.Address, policy *AuthPolicy, admin *AuthSigner, signers []*AuthSigner, dryRun bool) (*AuthPlan, error) {
+	plan, err := this.Plan(contractAddress, policy, admin.OntId)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		output := this.config.Output
+		if output == nil {
+			output = os.Stdout
+		}
+		_, err = fmt.Fprint(output, plan.String())
+		return plan, err
+	}
+	return plan, this.Apply(plan, admin, signers)
+}
+
+//Apply send changes of plan one by one, and wait for each of them confirmed
+func (this *AuthManager) Apply(plan *AuthPlan, admin *AuthSigner, signers []*AuthSigner) error {
+	signerMap := make(map[string]*AuthSigner)
+	for _, signer := range signers {
+		signerMap[signer.OntId] = signer
+	}
+	signerMap[admin.OntId] = admin
+	//check signers of all changes before sending any of them
+	for _, change := range plan.Changes {
+		if change.Method != AUTH_CHANGE_DELEGATE && change.Method != AUTH_CHANGE_WITHDRAW {
+			continue
+		}
+		if _, ok := signerMap[change.From]; !ok {
+			return fmt.Errorf("%w, %s", ERR_AUTH_SIGNER_NOT_FOUND, change)
+		}
+	}
+	for _, change := range plan.Changes {
+		signer := admin
+		if change.Method == AUTH_CHANGE_DELEGATE || change.Method == AUTH_CHANGE_WITHDRAW {
+			signer = signerMap[change.From]
+		}
+		tx, err := this.newChangeTransaction(plan.Contract, change, signer)
+		if err != nil {
+			return fmt.Errorf("%s error:%s", change, err)
+		}
+		err = this.auth.dnaSkd.signAndSubmit(tx, this.payer, signer.Controller, this.config.SubmitConfig)
+		if err != nil {
+			return fmt.Errorf("%s error:%s", change, err)
+		}
+	}
+	return nil
+}
+
+func (this *AuthManager) newChangeTransaction(contractAddress common.Address, change *AuthChange, signer *AuthSigner) (*types.MutableTransaction, error) {
+	gasPrice, gasLimit := this.config.GasPrice, this.config.GasLimit
+	switch change.Method {
+	case AUTH_CHANGE_ASSIGN_FUNCS:
+		return this.auth.NewAssignFuncsToRoleTransaction(gasPrice, gasLimit, contractAddress, []byte(signer.OntId), []byte(change.Role), change.Funcs, signer.KeyIndex)
+	case AUTH_CHANGE_ASSIGN_ONTIDS:
+		persons := make([][]byte, 0, len(change.OntIds))
+		for _, ontId := range change.OntIds {
+			persons = append(persons, []byte(ontId))
+		}
+		return this.auth.NewAssignOntIDsToRoleTransaction(gasPrice, gasLimit, contractAddress, []byte(signer.OntId), []byte(change.Role), persons, signer.KeyIndex)
+	case AUTH_CHANGE_DELEGATE:
+		return this.auth.NewDelegateTransaction(gasPrice, gasLimit, contractAddress, []byte(change.From), []byte(change.To), []byte(change.Role), change.Period, change.Level, signer.KeyIndex)
+	case AUTH_CHANGE_WITHDRAW:
+		return this.auth.NewWithdrawTransaction(gasPrice, gasLimit, contractAddress, []byte(change.From), []byte(change.To), []byte(change.Role), signer.KeyIndex)
+	default:
+		return nil, fmt.Errorf("unknown method:%s", change.Method)
+	}
+}
+
+//diffStrings return items of a which are not in b, in order of a
+func diffStrings(a, b []string) []string {
+	set := make(map[string]bool, len(b))
+	for _, item := range b {
+		set[item] = true
+	}
+	diff := make([]string, 0)
+	for _, item := range a {
+		if !set[item] {
+			diff = append(diff, item)
+			set[item] = true
+		}
+	}
+	return diff
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
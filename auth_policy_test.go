@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DNAProject/DNA-go-sdk/client"
+	"github.com/DNAProject/DNA/common"
+	"github.com/DNAProject/DNA/common/serialization"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthPolicy(t *testing.T) {
+	policy, err := ParseAuthPolicy([]byte(`{
+		"roles": {"admin": ["setConfig", "pause"], "user": ["transfer"]},
+		"members": {"did:ont:alice": ["admin"]},
+		"delegations": [{"from": "did:ont:alice", "to": "did:ont:bob", "role": "admin", "period": 3600, "level": 1}]
+	}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"admin", "user"}, sortedKeys(policy.Roles))
+
+	_, err = ParseAuthPolicy([]byte(`{"roles": {}, "members": {"did:ont:alice": ["admin"]}}`))
+	assert.True(t, errors.Is(err, ERR_INVALID_AUTH_POLICY))
+	policy.Delegations = append(policy.Delegations, &AuthPolicyDelegation{From: "did:ont:alice", To: "did:ont:bob", Role: "admin", Period: 60, Level: 1})
+	assert.True(t, errors.Is(policy.Check(), ERR_INVALID_AUTH_POLICY))
+	policy.Delegations = policy.Delegations[:1]
+	assert.Nil(t, policy.Check())
+	policy.Delegations[0].Period = 0
+	assert.True(t, errors.Is(policy.Check(), ERR_INVALID_AUTH_POLICY))
+
+	assert.Equal(t, []string{"pause"}, diffStrings([]string{"setConfig", "pause", "pause"}, []string{"setConfig"}))
+	assert.Equal(t, []string{}, diffStrings(nil, []string{"setConfig"}))
+
+	plan := &AuthPlan{
+		Admin: "did:ont:admin",
+		Changes: []*AuthChange{
+			{Method: AUTH_CHANGE_ASSIGN_FUNCS, Role: "admin", Funcs: []string{"setConfig", "pause"}},
+			{Method: AUTH_CHANGE_DELEGATE, Role: "admin", From: "did:ont:alice", To: "did:ont:bob", Period: 3600, Level: 1},
+		},
+		Unsupported: []string{"remove func:foo from role:admin"},
+	}
+	str := plan.String()
+	assert.Contains(t, str, "1. assignFuncsToRole role:admin funcs:setConfig,pause")
+	assert.Contains(t, str, "2. delegate role:admin from:did:ont:alice to:did:ont:bob period:3600s level:1")
+	assert.Contains(t, str, "unsupported: remove func:foo from role:admin")
+
+	//signer of delegation is checked before sending any transaction
+	manager := NewDNASdk().Native.Auth.NewAuthManager(NewAccount(), &AuthManagerConfig{})
+	err = manager.Apply(plan, &AuthSigner{OntId: "did:ont:admin", KeyIndex: 1}, nil)
+	assert.True(t, errors.Is(err, ERR_AUTH_SIGNER_NOT_FOUND))
+}
+
+//newAuthStorageServer serve storage of auth contract by rpc, storage is value of keys without contract address
+func newAuthStorageServer(contractAddress common.Address, storage map[string][]byte) *httptest.Server {
+	items := make(map[string]string)
+	for key, value := range storage {
+		items[hex.EncodeToString(append(contractAddress[:], key...))] = hex.EncodeToString(newAuthStorageItem(value))
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &client.JsonRpcRequest{}
+		json.NewDecoder(r.Body).Decode(req)
+		rsp := &client.JsonRpcResponse{Id: req.Id, Result: json.RawMessage(`null`)}
+		if req.Method == client.RPC_GET_STORAGE && len(req.Params) == 2 {
+			if item, ok := items[req.Params[1].(string)]; ok {
+				rsp.Result, _ = json.Marshal(item)
+			}
+		}
+		json.NewEncoder(w).Encode(rsp)
+	}))
+}
+
+func TestAuthManagerPlan(t *testing.T) {
+	contractAddress := common.Address{1, 2, 3}
+	funcs := new(bytes.Buffer)
+	serialization.WriteUint32(funcs, 2)
+	serialization.WriteString(funcs, "setConfig")
+	serialization.WriteString(funcs, "old")
+	tokens := new(bytes.Buffer)
+	serialization.WriteUint32(tokens, 1)
+	writeAuthToken(tokens, "admin", math.MaxUint32, 1)
+	newDelegations := func(role string, expireTime uint32, level uint8) []byte {
+		buf := new(bytes.Buffer)
+		serialization.WriteUint32(buf, 1)
+		serialization.WriteVarBytes(buf, []byte("did:ont:alice"))
+		writeAuthToken(buf, role, expireTime, level)
+		return buf.Bytes()
+	}
+	now := uint32(time.Now().Unix())
+	storage := map[string][]byte{
+		string(AUTH_PREFIX_ADMIN):                        []byte("did:ont:admin"),
+		string(AUTH_PREFIX_ROLE_FUNC) + "admin":          funcs.Bytes(),
+		string(AUTH_PREFIX_ROLE_TOKEN) + "did:ont:alice": tokens.Bytes(),
+	}
+	//level is different from policy
+	storage[string(AUTH_PREFIX_DELEGATE_STATUS)+"did:ont:bob"] = newDelegations("admin", now+1000, 2)
+	//not declared by policy
+	storage[string(AUTH_PREFIX_DELEGATE_STATUS)+"did:ont:carol"] = newDelegations("admin", now+1000, 1)
+	//the same as policy
+	storage[string(AUTH_PREFIX_DELEGATE_STATUS)+"did:ont:dave"] = newDelegations("user", now+1000, 1)
+	//expire later than period of policy
+	storage[string(AUTH_PREFIX_DELEGATE_STATUS)+"did:ont:eve"] = newDelegations("user", now+100000, 1)
+	server := newAuthStorageServer(contractAddress, storage)
+	defer server.Close()
+
+	sdk := NewDNASdk()
+	sdk.NewRpcClient().SetAddress(server.URL)
+	manager := sdk.Native.Auth.NewAuthManager(NewAccount(), &AuthManagerConfig{})
+	policy, err := ParseAuthPolicy([]byte(`{
+		"roles": {"admin": ["setConfig", "pause"], "user": ["transfer"]},
+		"members": {"did:ont:alice": ["admin", "user"], "did:ont:carol": []},
+		"delegations": [
+			{"from": "did:ont:alice", "to": "did:ont:bob", "role": "admin", "period": 3600, "level": 1},
+			{"from": "did:ont:alice", "to": "did:ont:dave", "role": "user", "period": 3600, "level": 1},
+			{"from": "did:ont:alice", "to": "did:ont:eve", "role": "user", "period": 3600, "level": 1}
+		]
+	}`))
+	assert.Nil(t, err)
+
+	_, err = manager.Plan(contractAddress, policy, "did:ont:other")
+	assert.True(t, errors.Is(err, ERR_AUTH_SIGNER_NOT_FOUND))
+	plan, err := manager.Plan(contractAddress, policy, "did:ont:admin")
+	assert.Nil(t, err)
+	assert.Equal(t, []*AuthChange{
+		{Method: AUTH_CHANGE_ASSIGN_FUNCS, Role: "admin", Funcs: []string{"pause"}},
+		{Method: AUTH_CHANGE_ASSIGN_FUNCS, Role: "user", Funcs: []string{"transfer"}},
+		{Method: AUTH_CHANGE_ASSIGN_ONTIDS, Role: "user", OntIds: []string{"did:ont:alice"}},
+		{Method: AUTH_CHANGE_WITHDRAW, Role: "admin", From: "did:ont:alice", To: "did:ont:bob"},
+		{Method: AUTH_CHANGE_WITHDRAW, Role: "admin", From: "did:ont:alice", To: "did:ont:carol"},
+		{Method: AUTH_CHANGE_WITHDRAW, Role: "user", From: "did:ont:alice", To: "did:ont:eve"},
+		{Method: AUTH_CHANGE_DELEGATE, Role: "admin", From: "did:ont:alice", To: "did:ont:bob", Period: 3600, Level: 1},
+		{Method: AUTH_CHANGE_DELEGATE, Role: "user", From: "did:ont:alice", To: "did:ont:eve", Period: 3600, Level: 1},
+	}, plan.Changes)
+	assert.Equal(t, []string{"remove func:old from role:admin"}, plan.Unsupported)
+
+	//default config is used if nil, and dry run only prints plan
+	output := new(bytes.Buffer)
+	manager = sdk.Native.Auth.NewAuthManager(NewAccount(), nil)
+	manager.config.Output = output
+	dryRun, err := manager.ApplyPolicy(contractAddress, policy, &AuthSigner{OntId: "did:ont:admin"}, nil, true)
+	assert.Nil(t, err)
+	assert.Equal(t, plan.Changes, dryRun.Changes)
+	assert.Equal(t, plan.String(), output.String())
+}
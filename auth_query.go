@@ -0,0 +1,279 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/DNAProject/DNA/common"
+	"github.com/DNAProject/DNA/common/serialization"
+)
+
+//Prefix of storage key in auth contract, the key is <contract address><prefix><role or ontid>
+const (
+	AUTH_PREFIX_ADMIN           = byte(0x01)
+	AUTH_PREFIX_ROLE_FUNC       = byte(0x02)
+	AUTH_PREFIX_ROLE_TOKEN      = byte(0x03)
+	AUTH_PREFIX_DELEGATE_STATUS = byte(0x04)
+)
+
+//AuthToken is a role held by identity, which is assigned by admin or delegated by other identity
+type AuthToken struct {
+	Role       string
+	ExpireTime uint32 //Unix time, math.MaxUint32 if never expire
+	Level      uint8  //Level of delegation, the role can be delegated again if level > 1
+}
+
+func (this *AuthToken) IsExpired(now time.Time) bool {
+	return this.ExpireTime != math.MaxUint32 && int64(this.ExpireTime) < now.Unix()
+}
+
+//AuthDelegation is a role delegated to identity by Root
+type AuthDelegation struct {
+	Root string //Identity which delegated the role
+	AuthToken
+}
+
+//AuthRole is functions of role, and identities which hold the role
+type AuthRole struct {
+	Role    string
+	Funcs   []string
+	Members []string
+}
+
+//AuthInfo is the auth settings of contract
+type AuthInfo struct {
+	Admin       string
+	Roles       []*AuthRole
+	Delegations map[string][]*AuthDelegation //Active delegations by identity
+}
+
+//GetAdmin return admin identity of contract in auth contract, or empty string if not initialized
+func (this *Auth) GetAdmin(contractAddress common.Address) (string, error) {
+	value, err := this.getStorage(contractAddress, AUTH_PREFIX_ADMIN, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+//GetRoleFuncs return functions assigned to role of contract
+func (this *Auth) GetRoleFuncs(contractAddress common.Address, role []byte) ([]string, error) {
+	value, err := this.getStorage(contractAddress, AUTH_PREFIX_ROLE_FUNC, role)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAuthRoleFuncs(value)
+}
+
+//GetRoleTokens return roles held by identity in contract, including expired tokens
+func (this *Auth) GetRoleTokens(contractAddress common.Address, ontId []byte) ([]*AuthToken, error) {
+	value, err := this.getStorage(contractAddress, AUTH_PREFIX_ROLE_TOKEN, ontId)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAuthTokens(value)
+}
+
+//GetDelegations return roles delegated to identity in contract, which are not expired
+func (this *Auth) GetDelegations(contractAddress common.Address, ontId []byte) ([]*AuthDelegation, error) {
+	value, err := this.getStorage(contractAddress, AUTH_PREFIX_DELEGATE_STATUS, ontId)
+	if err != nil {
+		return nil, err
+	}
+	delegations, err := decodeAuthDelegations(value)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	activeDelegations := make([]*AuthDelegation, 0, len(delegations))
+	for _, delegation := range delegations {
+		if !delegation.IsExpired(now) {
+			activeDelegations = append(activeDelegations, delegation)
+		}
+	}
+	return activeDelegations, nil
+}
+
+//GetAuthInfo return admin, functions and members of roles, and active delegations of contract.
+//Storage of auth contract is indexed by role and identity, so roles and identities to query should be given.
+func (this *Auth) GetAuthInfo(contractAddress common.Address, roles, ontIds [][]byte) (*AuthInfo, error) {
+	admin, err := this.GetAdmin(contractAddress)
+	if err != nil {
+		return nil, fmt.Errorf("GetAdmin error:%s", err)
+	}
+	info := &AuthInfo{
+		Admin:       admin,
+		Roles:       make([]*AuthRole, 0, len(roles)),
+		Delegations: make(map[string][]*AuthDelegation),
+	}
+	roleMap := make(map[string]*AuthRole)
+	for _, role := range roles {
+		funcs, err := this.GetRoleFuncs(contractAddress, role)
+		if err != nil {
+			return nil, fmt.Errorf("GetRoleFuncs of role:%s error:%s", role, err)
+		}
+		authRole := &AuthRole{Role: string(role), Funcs: funcs, Members: make([]string, 0)}
+		info.Roles = append(info.Roles, authRole)
+		roleMap[authRole.Role] = authRole
+	}
+	now := time.Now()
+	for _, ontId := range ontIds {
+		tokens, err := this.GetRoleTokens(contractAddress, ontId)
+		if err != nil {
+			return nil, fmt.Errorf("GetRoleTokens of:%s error:%s", ontId, err)
+		}
+		for _, token := range tokens {
+			authRole, ok := roleMap[token.Role]
+			if ok && !token.IsExpired(now) {
+				authRole.Members = append(authRole.Members, string(ontId))
+			}
+		}
+		delegations, err := this.GetDelegations(contractAddress, ontId)
+		if err != nil {
+			return nil, fmt.Errorf("GetDelegations of:%s error:%s", ontId, err)
+		}
+		if len(delegations) > 0 {
+			info.Delegations[string(ontId)] = delegations
+		}
+	}
+	return info, nil
+}
+
+//PreExecVerifyToken check whether caller can invoke function of contract by pre-executing verifyToken,
+//which doesn't cost gas. Transaction is signed by controller of caller at keyIndex but not sent.
+func (this *Auth) PreExecVerifyToken(contractAddress common.Address, caller []byte, funcName string, keyIndex int, controller Signer) (bool, error) {
+	tx, err := this.NewVerifyTokenTransaction(0, 0, contractAddress, caller, funcName, keyIndex)
+	if err != nil {
+		return false, err
+	}
+	err = this.dnaSkd.SignToTransaction(tx, controller)
+	if err != nil {
+		return false, err
+	}
+	preResult, err := this.dnaSkd.PreExecTransaction(tx)
+	if err != nil {
+		return false, err
+	}
+	if preResult.State == 0 {
+		return false, fmt.Errorf("pre-execute verifyToken failed")
+	}
+	return preResult.Result.ToBool()
+}
+
+func (this *Auth) getStorage(contractAddress common.Address, prefix byte, suffix []byte) ([]byte, error) {
+	key := make([]byte, 0, common.ADDR_LEN+1+len(suffix))
+	key = append(key, contractAddress[:]...)
+	key = append(key, prefix)
+	key = append(key, suffix...)
+	data, err := this.dnaSkd.GetStorage(AUTH_CONTRACT_ADDRESS.ToHexString(), key)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAuthStorageItem(data)
+}
+
+//decodeAuthStorageItem return value of storage item, which is state version followed by var bytes value
+func decodeAuthStorageItem(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	buf := bytes.NewBuffer(data)
+	_, err := serialization.ReadUint8(buf)
+	if err != nil {
+		return nil, fmt.Errorf("storage item version ReadUint8 error:%s", err)
+	}
+	value, err := serialization.ReadVarBytes(buf)
+	if err != nil {
+		return nil, fmt.Errorf("storage item value ReadVarBytes error:%s", err)
+	}
+	return value, nil
+}
+
+func decodeAuthRoleFuncs(data []byte) ([]string, error) {
+	funcs := make([]string, 0)
+	if len(data) == 0 {
+		return funcs, nil
+	}
+	buf := bytes.NewBuffer(data)
+	size, err := serialization.ReadUint32(buf)
+	if err != nil {
+		return nil, fmt.Errorf("funcs size ReadUint32 error:%s", err)
+	}
+	for i := uint32(0); i < size; i++ {
+		funcName, err := serialization.ReadString(buf)
+		if err != nil {
+			return nil, fmt.Errorf("func:%d ReadString error:%s", i, err)
+		}
+		funcs = append(funcs, funcName)
+	}
+	return funcs, nil
+}
+
+func decodeAuthTokens(data []byte) ([]*AuthToken, error) {
+	tokens := make([]*AuthToken, 0)
+	if len(data) == 0 {
+		return tokens, nil
+	}
+	buf := bytes.NewBuffer(data)
+	size, err := serialization.ReadUint32(buf)
+	if err != nil {
+		return nil, fmt.Errorf("tokens size ReadUint32 error:%s", err)
+	}
+	for i := uint32(0); i < size; i++ {
+		token, err := readAuthToken(buf)
+		if err != nil {
+			return nil, fmt.Errorf("token:%d error:%s", i, err)
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+func decodeAuthDelegations(data []byte) ([]*AuthDelegation, error) {
+	delegations := make([]*AuthDelegation, 0)
+	if len(data) == 0 {
+		return delegations, nil
+	}
+	buf := bytes.NewBuffer(data)
+	size, err := serialization.ReadUint32(buf)
+	if err != nil {
+		return nil, fmt.Errorf("delegations size ReadUint32 error:%s", err)
+	}
+	for i := uint32(0); i < size; i++ {
+		root, err := serialization.ReadVarBytes(buf)
+		if err != nil {
+			return nil, fmt.Errorf("delegation:%d root ReadVarBytes error:%s", i, err)
+		}
+		token, err := readAuthToken(buf)
+		if err != nil {
+			return nil, fmt.Errorf("delegation:%d error:%s", i, err)
+		}
+		delegations = append(delegations, &AuthDelegation{Root: string(root), AuthToken: *token})
+	}
+	return delegations, nil
+}
+
+func readAuthToken(buf *bytes.Buffer) (*AuthToken, error) {
+	role, err := serialization.ReadVarBytes(buf)
+	if err != nil {
+		return nil, fmt.Errorf("role ReadVarBytes error:%s", err)
+	}
+	expireTime, err := serialization.ReadUint32(buf)
+	if err != nil {
+		return nil, fmt.Errorf("expire time ReadUint32 error:%s", err)
+	}
+	level, err := serialization.ReadUint8(buf)
+	if err != nil {
+		return nil, fmt.Errorf("level ReadUint8 error:%s", err)
+	}
+	return &AuthToken{
+		Role:       string(role),
+		ExpireTime: expireTime,
+		Level:      level,
+	}, nil
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/DNAProject/DNA/common/serialization"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAuthStorageItem(value []byte) []byte {
+	buf := new(bytes.Buffer)
+	serialization.WriteUint8(buf, 0)
+	serialization.WriteVarBytes(buf, value)
+	return buf.Bytes()
+}
+
+func writeAuthToken(buf *bytes.Buffer, role string, expireTime uint32, level uint8) {
+	serialization.WriteVarBytes(buf, []byte(role))
+	serialization.WriteUint32(buf, expireTime)
+	serialization.WriteUint8(buf, level)
+}
+
+func TestDecodeAuthStorage(t *testing.T) {
+	value, err := decodeAuthStorageItem(newAuthStorageItem([]byte("did:ont:admin")))
+	assert.Nil(t, err)
+	assert.Equal(t, "did:ont:admin", string(value))
+	value, err = decodeAuthStorageItem(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+
+	buf := new(bytes.Buffer)
+	serialization.WriteUint32(buf, 2)
+	serialization.WriteString(buf, "foo")
+	serialization.WriteString(buf, "bar")
+	funcs, err := decodeAuthRoleFuncs(buf.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"foo", "bar"}, funcs)
+	_, err = decodeAuthRoleFuncs(buf.Bytes()[:6])
+	assert.NotNil(t, err)
+
+	buf = new(bytes.Buffer)
+	serialization.WriteUint32(buf, 2)
+	writeAuthToken(buf, "admin", math.MaxUint32, 2)
+	writeAuthToken(buf, "user", 100, 1)
+	tokens, err := decodeAuthTokens(buf.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, []*AuthToken{{Role: "admin", ExpireTime: math.MaxUint32, Level: 2}, {Role: "user", ExpireTime: 100, Level: 1}}, tokens)
+	assert.False(t, tokens[0].IsExpired(time.Now()))
+	assert.True(t, tokens[1].IsExpired(time.Now()))
+
+	buf = new(bytes.Buffer)
+	serialization.WriteUint32(buf, 1)
+	serialization.WriteVarBytes(buf, []byte("did:ont:root"))
+	writeAuthToken(buf, "user", 200, 1)
+	delegations, err := decodeAuthDelegations(buf.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(delegations))
+	assert.Equal(t, "did:ont:root", delegations[0].Root)
+	assert.Equal(t, "user", delegations[0].Role)
+	assert.Equal(t, uint32(200), delegations[0].ExpireTime)
+}
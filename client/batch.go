@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	sdkcom "github.com/DNAProject/DNA-go-sdk/common"
+	"github.com/DNAProject/DNA-go-sdk/utils"
+)
+
+//RpcBatch collect heterogeneous rpc calls, and send them to dna in JSON-RPC 2.0 batch request.
+//Result of each call is decoded into the same type as corresponding method of ClientMgr:
+//  GetBlockByHeight, GetBlockByHash    -> *types.Block
+//  GetBlockHash                        -> common.Uint256
+//  GetTransaction                      -> *types.Transaction
+//  GetStorage                          -> []byte
+//  GetSmartContractEvent               -> *sdkcom.SmartContactEvent
+//  GetSmartContractEventByBlock        -> []*sdkcom.SmartContactEvent
+//  GetBlockHeightByTxHash              -> uint32
+type RpcBatch struct {
+	mgr   *ClientMgr
+	calls []*rpcBatchCall
+}
+
+type rpcBatchCall struct {
+	req    *JsonRpcRequest
+	decode func(data []byte) (interface{}, error)
+}
+
+//RpcBatchResult is the result of one call in RpcBatch
+type RpcBatchResult struct {
+	Method string
+	Result interface{}
+	Error  error
+}
+
+//NewRpcBatch return a empty RpcBatch
+func (this *ClientMgr) NewRpcBatch() *RpcBatch {
+	return &RpcBatch{mgr: this}
+}
+
+//Len return the count of calls in batch
+func (this *RpcBatch) Len() int {
+	return len(this.calls)
+}
+
+func (this *RpcBatch) add(method string, params []interface{}, decode func(data []byte) (interface{}, error)) *RpcBatch {
+	this.calls = append(this.calls, &rpcBatchCall{
+		req: &JsonRpcRequest{
+			Version: JSON_RPC_VERSION,
+			Id:      this.mgr.getNextQid(),
+			Method:  method,
+			Params:  params,
+		},
+		decode: decode,
+	})
+	return this
+}
+
+func (this *RpcBatch) GetBlockByHeight(height uint32) *RpcBatch {
+	return this.add(RPC_GET_BLOCK, []interface{}{height}, func(data []byte) (interface{}, error) {
+		return utils.GetBlock(data)
+	})
+}
+
+func (this *RpcBatch) GetBlockByHash(blockHash string) *RpcBatch {
+	return this.add(RPC_GET_BLOCK, []interface{}{blockHash}, func(data []byte) (interface{}, error) {
+		return utils.GetBlock(data)
+	})
+}
+
+func (this *RpcBatch) GetBlockHash(height uint32) *RpcBatch {
+	return this.add(RPC_GET_BLOCK_HASH, []interface{}{height}, func(data []byte) (interface{}, error) {
+		return utils.GetUint256(data)
+	})
+}
+
+func (this *RpcBatch) GetTransaction(txHash string) *RpcBatch {
+	return this.add(RPC_GET_TRANSACTION, []interface{}{txHash}, func(data []byte) (interface{}, error) {
+		return utils.GetTransaction(data)
+	})
+}
+
+func (this *RpcBatch) GetBlockHeightByTxHash(txHash string) *RpcBatch {
+	return this.add(RPC_GET_BLOCK_HEIGHT_BY_TX_HASH, []interface{}{txHash}, func(data []byte) (interface{}, error) {
+		return utils.GetUint32(data)
+	})
+}
+
+func (this *RpcBatch) GetStorage(contractAddress string, key []byte) *RpcBatch {
+	return this.add(RPC_GET_STORAGE, []interface{}{contractAddress, hex.EncodeToString(key)}, func(data []byte) (interface{}, error) {
+		return utils.GetStorage(data)
+	})
+}
+
+func (this *RpcBatch) GetSmartContractEvent(txHash string) *RpcBatch {
+	return this.add(RPC_GET_SMART_CONTRACT_EVENT, []interface{}{txHash}, func(data []byte) (interface{}, error) {
+		return utils.GetSmartContractEvent(data)
+	})
+}
+
+func (this *RpcBatch) GetSmartContractEventByBlock(height uint32) *RpcBatch {
+	return this.add(RPC_GET_SMART_CONTRACT_EVENT, []interface{}{height}, func(data []byte) (interface{}, error) {
+		return utils.GetSmartContactEvents(data)
+	})
+}
+
+//Send send all of calls in batch, and return per-call results in the order of calls.
+//Calls are split into several batch requests if more than DEFAULT_RPC_BATCH_SIZE.
+//Error is returned only when batch request cannot be sent, error of each call is in RpcBatchResult.
+func (this *RpcBatch) Send() ([]*RpcBatchResult, error) {
+	rpc := this.mgr.GetRpcClient()
+	if rpc == nil {
+		return nil, fmt.Errorf("batch request need rpc client of dna")
+	}
+	results := make([]*RpcBatchResult, 0, len(this.calls))
+	batchSize := DEFAULT_RPC_BATCH_SIZE
+	if batchSize <= 0 {
+		batchSize = len(this.calls)
+	}
+	for start := 0; start < len(this.calls); start += batchSize {
+		end := start + batchSize
+		if end > len(this.calls) {
+			end = len(this.calls)
+		}
+		calls := this.calls[start:end]
+		reqs := make([]*JsonRpcRequest, 0, len(calls))
+		for _, call := range calls {
+			reqs = append(reqs, call.req)
+		}
+		rsps, err := rpc.sendRpcBatchRequest(reqs)
+		if err != nil {
+			return nil, err
+		}
+		for i, call := range calls {
+			result := &RpcBatchResult{Method: call.req.Method}
+			rsp := rsps[i]
+			if rsp.Err != nil {
+				result.Error = rsp.Err
+			} else {
+				result.Result, result.Error = call.decode(rsp.Result)
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+//GetSmartContractEventsByBlocks return smart contract events of blocks in one batch request
+func (this *ClientMgr) GetSmartContractEventsByBlocks(heights []uint32) ([][]*sdkcom.SmartContactEvent, error) {
+	batch := this.NewRpcBatch()
+	for _, height := range heights {
+		batch.GetSmartContractEventByBlock(height)
+	}
+	results, err := batch.Send()
+	if err != nil {
+		return nil, err
+	}
+	events := make([][]*sdkcom.SmartContactEvent, 0, len(results))
+	for i, result := range results {
+		if result.Error != nil {
+			return nil, fmt.Errorf("get event of block:%d error:%s", heights[i], result.Error)
+		}
+		events = append(events, result.Result.([]*sdkcom.SmartContactEvent))
+	}
+	return events, nil
+}
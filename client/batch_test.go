@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/DNAProject/DNA/common"
+	"github.com/stretchr/testify/assert"
+)
+
+const TEST_BLOCK_HASH = "0000000000000000000000000000000000000000000000000000000000000001"
+
+func newBatchTestResponse(req *JsonRpcRequest) *JsonRpcResponse {
+	rsp := &JsonRpcResponse{Id: req.Id}
+	switch req.Method {
+	case RPC_GET_BLOCK_HASH:
+		rsp.Result = json.RawMessage(`"` + TEST_BLOCK_HASH + `"`)
+	case RPC_GET_STORAGE:
+		rsp.Result = json.RawMessage(`"0102"`)
+	default:
+		rsp.Error, rsp.Desc = ERR_CODE_INVALID_PARAMS, "INVALID PARAMS"
+	}
+	return rsp
+}
+
+//newBatchTestServer serve batch request in reversed order, or reject it if batch is not supported
+func newBatchTestServer(supportBatch bool, batches, singles *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if !bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+			atomic.AddInt32(singles, 1)
+			req := &JsonRpcRequest{}
+			json.Unmarshal(body, req)
+			json.NewEncoder(w).Encode(newBatchTestResponse(req))
+			return
+		}
+		atomic.AddInt32(batches, 1)
+		if !supportBatch {
+			json.NewEncoder(w).Encode(&JsonRpcResponse{Error: ERR_CODE_INVALID_PARAMS, Desc: "INVALID PARAMS"})
+			return
+		}
+		reqs := make([]*JsonRpcRequest, 0)
+		json.Unmarshal(body, &reqs)
+		rsps := make([]*JsonRpcResponse, 0, len(reqs))
+		for i := len(reqs) - 1; i >= 0; i-- {
+			rsps = append(rsps, newBatchTestResponse(reqs[i]))
+		}
+		json.NewEncoder(w).Encode(rsps)
+	}))
+}
+
+func checkBatchTestResults(t *testing.T, results []*RpcBatchResult) {
+	assert.Equal(t, 3, len(results))
+	assert.Equal(t, RPC_GET_BLOCK_HASH, results[0].Method)
+	assert.Nil(t, results[0].Error)
+	blockHash, _ := common.Uint256FromHexString(TEST_BLOCK_HASH)
+	assert.Equal(t, blockHash, results[0].Result)
+
+	assert.Equal(t, RPC_GET_BLOCK_HEIGHT_BY_TX_HASH, results[1].Method)
+	nodeErr := &NodeError{}
+	assert.True(t, errors.As(results[1].Error, &nodeErr))
+	assert.Equal(t, int64(ERR_CODE_INVALID_PARAMS), nodeErr.Code)
+	assert.Equal(t, RPC_GET_BLOCK_HEIGHT_BY_TX_HASH, nodeErr.Method)
+
+	assert.Equal(t, RPC_GET_STORAGE, results[2].Method)
+	assert.Nil(t, results[2].Error)
+	assert.Equal(t, []byte{1, 2}, results[2].Result)
+}
+
+func TestRpcBatch(t *testing.T) {
+	var batches, singles int32
+	server := newBatchTestServer(true, &batches, &singles)
+	defer server.Close()
+
+	mgr := &ClientMgr{}
+	mgr.NewRpcClient().SetAddress(server.URL)
+	batchSize := DEFAULT_RPC_BATCH_SIZE
+	DEFAULT_RPC_BATCH_SIZE = 2
+	defer func() { DEFAULT_RPC_BATCH_SIZE = batchSize }()
+
+	//results are in order of calls, although responses are not
+	results, err := mgr.NewRpcBatch().GetBlockHash(1).GetBlockHeightByTxHash(strings.Repeat("0", 64)).GetStorage("0100000000000000000000000000000000000000", []byte("key")).Send()
+	assert.Nil(t, err)
+	checkBatchTestResults(t, results)
+	assert.Equal(t, int32(2), batches)
+	assert.Equal(t, int32(0), singles)
+}
+
+func TestRpcBatch_Fallback(t *testing.T) {
+	var batches, singles int32
+	server := newBatchTestServer(false, &batches, &singles)
+	defer server.Close()
+
+	mgr := &ClientMgr{}
+	mgr.NewRpcClient().SetAddress(server.URL)
+	//calls are sent one by one if node cannot unmarshal batch request
+	results, err := mgr.NewRpcBatch().GetBlockHash(1).GetBlockHeightByTxHash(strings.Repeat("0", 64)).GetStorage("0100000000000000000000000000000000000000", []byte("key")).Send()
+	assert.Nil(t, err)
+	checkBatchTestResults(t, results)
+	assert.Equal(t, int32(1), batches)
+	assert.Equal(t, int32(3), singles)
+}
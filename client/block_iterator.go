@@ -0,0 +1,289 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	sdkcom "github.com/DNAProject/DNA-go-sdk/common"
+	"github.com/DNAProject/DNA-go-sdk/utils"
+	"github.com/DNAProject/DNA/core/types"
+)
+
+//BlockWithEvents is a block with all of smart contract events of block
+type BlockWithEvents struct {
+	Height uint32
+	Block  *types.Block
+	Events []*sdkcom.SmartContactEvent
+	//Notifies matched by BlockIteratorConfig.Filter, nil if filter is not set
+	Notifies []*FilteredNotify
+}
+
+var (
+	DEFAULT_BLOCK_ITERATOR_CONCURRENCY    = 8
+	DEFAULT_BLOCK_ITERATOR_BUFFER_SIZE    = 64
+	DEFAULT_BLOCK_ITERATOR_MAX_RETRY      = 3
+	DEFAULT_BLOCK_ITERATOR_RETRY_INTERVAL = time.Second
+)
+
+//BlockIteratorConfig config of BlockIterator
+type BlockIteratorConfig struct {
+	Concurrency   int           //Max count of blocks fetching at the same time
+	BufferSize    int           //Max count of fetched blocks waiting for delivery
+	MaxRetry      int           //Max retry times of fetching one block, each retry using next client
+	RetryInterval time.Duration //Interval between retries
+	WithoutEvents bool          //Don't fetch smart contract events of block
+	Filter        *EventFilter  //Filter notifies of events into BlockWithEvents.Notifies
+}
+
+func NewBlockIteratorConfig() *BlockIteratorConfig {
+	return &BlockIteratorConfig{
+		Concurrency:   DEFAULT_BLOCK_ITERATOR_CONCURRENCY,
+		BufferSize:    DEFAULT_BLOCK_ITERATOR_BUFFER_SIZE,
+		MaxRetry:      DEFAULT_BLOCK_ITERATOR_MAX_RETRY,
+		RetryInterval: DEFAULT_BLOCK_ITERATOR_RETRY_INTERVAL,
+	}
+}
+
+//BlockIterator fetch blocks of height range [start, end] concurrently, and deliver them in height order.
+//To resume from a checkpoint, create a new BlockIterator start from Checkpoint() of the old one.
+type BlockIterator struct {
+	mgr        *ClientMgr
+	clients    []DNAClient
+	config     *BlockIteratorConfig
+	start      uint32
+	end        uint32
+	checkpoint uint32
+	blockCh    chan *BlockWithEvents
+	jobCh      chan uint32
+	resultCh   chan *BlockWithEvents
+	windowCh   chan struct{}
+	exitCh     chan interface{}
+	doneCh     chan interface{} //Closed when delivery stopped
+	exitOnce   sync.Once
+	startOnce  sync.Once
+	err        error
+	lock       sync.RWMutex
+}
+
+//NewBlockIterator return a BlockIterator of height range [start, end].
+//If clients is empty, use all of clients in ClientMgr.
+func (this *ClientMgr) NewBlockIterator(start, end uint32, config *BlockIteratorConfig, clients ...DNAClient) (*BlockIterator, error) {
+	if start > end {
+		return nil, fmt.Errorf("start height:%d is larger than end height:%d", start, end)
+	}
+	if config == nil {
+		config = NewBlockIteratorConfig()
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+	if config.BufferSize < 0 {
+		config.BufferSize = 0
+	}
+	if len(clients) == 0 {
+		clients = this.getClients()
+	}
+	if len(clients) == 0 {
+		return nil, fmt.Errorf("don't have available client of dna")
+	}
+	return &BlockIterator{
+		mgr:        this,
+		clients:    clients,
+		config:     config,
+		start:      start,
+		end:        end,
+		checkpoint: start,
+		blockCh:    make(chan *BlockWithEvents, config.BufferSize),
+		jobCh:      make(chan uint32),
+		resultCh:   make(chan *BlockWithEvents, config.Concurrency),
+		windowCh:   make(chan struct{}, config.Concurrency+config.BufferSize),
+		exitCh:     make(chan interface{}),
+		doneCh:     make(chan interface{}),
+	}, nil
+}
+
+//Start begin to fetch blocks. Blocks are delivered to the channel returned by Blocks()
+func (this *BlockIterator) Start() {
+	this.startOnce.Do(func() {
+		go this.dispatch()
+		for i := 0; i < this.config.Concurrency; i++ {
+			go this.fetch(i)
+		}
+		go this.reorder()
+	})
+}
+
+//Blocks return the channel of blocks in height order.
+//The channel is closed after end height delivered, iterator closed, or error occurred, check Err() for the reason.
+func (this *BlockIterator) Blocks() <-chan *BlockWithEvents {
+	return this.blockCh
+}
+
+//Checkpoint return the next height to deliver, blocks before it have been sent to the channel returned by Blocks()
+func (this *BlockIterator) Checkpoint() uint32 {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.checkpoint
+}
+
+//Err return the error which stopped the iterator
+func (this *BlockIterator) Err() error {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.err
+}
+
+//Close stop fetching blocks, and wait for delivery stopped. Blocks which have not been delivered are dropped.
+//Blocks already delivered can still be read from Blocks() until it's closed, then Checkpoint() is the next height of them.
+func (this *BlockIterator) Close() {
+	this.stop()
+	//Iterator which is not started has nothing to wait
+	this.startOnce.Do(func() {
+		close(this.doneCh)
+	})
+	<-this.doneCh
+}
+
+func (this *BlockIterator) stop() {
+	this.exitOnce.Do(func() {
+		close(this.exitCh)
+	})
+}
+
+func (this *BlockIterator) setErr(err error) {
+	this.lock.Lock()
+	if this.err == nil {
+		this.err = err
+	}
+	this.lock.Unlock()
+	this.stop()
+}
+
+func (this *BlockIterator) dispatch() {
+	for height := uint64(this.start); height <= uint64(this.end); height++ {
+		select {
+		case this.windowCh <- struct{}{}:
+		case <-this.exitCh:
+			return
+		}
+		select {
+		case this.jobCh <- uint32(height):
+		case <-this.exitCh:
+			return
+		}
+	}
+}
+
+func (this *BlockIterator) fetch(index int) {
+	for {
+		select {
+		case height := <-this.jobCh:
+			block, err := this.fetchBlock(index, height)
+			if err != nil {
+				select {
+				case <-this.exitCh:
+				default:
+					this.setErr(err)
+				}
+				return
+			}
+			select {
+			case this.resultCh <- block:
+			case <-this.exitCh:
+				return
+			}
+		case <-this.exitCh:
+			return
+		}
+	}
+}
+
+func (this *BlockIterator) fetchBlock(index int, height uint32) (*BlockWithEvents, error) {
+	var err error
+	for i := 0; i <= this.config.MaxRetry; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(this.config.RetryInterval):
+			case <-this.exitCh:
+				return nil, fmt.Errorf("block iterator closed")
+			}
+		}
+		client := this.clients[(index+i)%len(this.clients)]
+		var block *BlockWithEvents
+		block, err = this.fetchBlockFrom(client, height)
+		if err == nil {
+			return block, nil
+		}
+	}
+	return nil, fmt.Errorf("fetch block:%d error:%s", height, err)
+}
+
+func (this *BlockIterator) fetchBlockFrom(client DNAClient, height uint32) (*BlockWithEvents, error) {
+	data, err := client.getBlockByHeight(this.mgr.getNextQid(), height)
+	if err != nil {
+		return nil, err
+	}
+	block, err := utils.GetBlock(data)
+	if err != nil {
+		return nil, err
+	}
+	result := &BlockWithEvents{
+		Height: height,
+		Block:  block,
+	}
+	if this.config.WithoutEvents {
+		return result, nil
+	}
+	data, err = client.getSmartContractEventByBlock(this.mgr.getNextQid(), height)
+	if err != nil {
+		return nil, err
+	}
+	result.Events, err = utils.GetSmartContactEvents(data)
+	if err != nil {
+		return nil, err
+	}
+	if this.config.Filter != nil {
+		result.Notifies = this.config.Filter.FilterEvents(result.Events, height)
+	}
+	return result, nil
+}
+
+func (this *BlockIterator) reorder() {
+	defer close(this.doneCh)
+	defer close(this.blockCh)
+	pending := make(map[uint32]*BlockWithEvents)
+	next := uint64(this.start)
+	for next <= uint64(this.end) {
+		select {
+		case block := <-this.resultCh:
+			pending[block.Height] = block
+		case <-this.exitCh:
+			return
+		}
+		for {
+			block, ok := pending[uint32(next)]
+			if !ok {
+				break
+			}
+			select {
+			case this.blockCh <- block:
+			case <-this.exitCh:
+				return
+			}
+			delete(pending, uint32(next))
+			next++
+			this.lock.Lock()
+			this.checkpoint = uint32(next)
+			this.lock.Unlock()
+			<-this.windowCh
+			if next > uint64(this.end) {
+				break
+			}
+		}
+	}
+	this.stop()
+}
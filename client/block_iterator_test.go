@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DNAProject/DNA/common"
+	"github.com/DNAProject/DNA/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+//newBlockTestServer serve blocks without transactions, block at height h is responded after delay(h)
+func newBlockTestServer(delay func(height uint32) time.Duration, onFetched func(height uint32)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &JsonRpcRequest{}
+		json.NewDecoder(r.Body).Decode(req)
+		rsp := &JsonRpcResponse{Id: req.Id}
+		height := uint32(req.Params[0].(float64))
+		switch req.Method {
+		case RPC_GET_BLOCK:
+			time.Sleep(delay(height))
+			block := &types.Block{Header: &types.Header{Height: height}}
+			sink := common.NewZeroCopySink(nil)
+			block.Serialization(sink)
+			rsp.Result, _ = json.Marshal(hex.EncodeToString(sink.Bytes()))
+			onFetched(height)
+		case RPC_GET_SMART_CONTRACT_EVENT:
+			rsp.Result = json.RawMessage(`[]`)
+		default:
+			rsp.Error, rsp.Desc = ERR_CODE_INVALID_METHOD, "INVALID METHOD"
+		}
+		json.NewEncoder(w).Encode(rsp)
+	}))
+}
+
+func TestBlockIterator_Order(t *testing.T) {
+	fetched := make([]uint32, 0)
+	lock := sync.Mutex{}
+	//later blocks of each window are fetched earlier
+	server := newBlockTestServer(func(height uint32) time.Duration {
+		return time.Duration(4-(height-1)%4) * 20 * time.Millisecond
+	}, func(height uint32) {
+		lock.Lock()
+		fetched = append(fetched, height)
+		lock.Unlock()
+	})
+	defer server.Close()
+
+	mgr := &ClientMgr{}
+	mgr.NewRpcClient().SetAddress(server.URL)
+	iterator, err := mgr.NewBlockIterator(1, 8, &BlockIteratorConfig{Concurrency: 4, BufferSize: 0})
+	assert.Nil(t, err)
+	iterator.Start()
+	delivered := make([]uint32, 0)
+	for block := range iterator.Blocks() {
+		assert.Equal(t, block.Height, block.Block.Header.Height)
+		delivered = append(delivered, block.Height)
+	}
+	assert.Nil(t, iterator.Err())
+	assert.Equal(t, []uint32{1, 2, 3, 4, 5, 6, 7, 8}, delivered)
+	assert.Equal(t, uint32(9), iterator.Checkpoint())
+	lock.Lock()
+	assert.Equal(t, 8, len(fetched))
+	assert.NotEqual(t, delivered, fetched)
+	lock.Unlock()
+}
+
+func TestBlockIterator_CheckpointAfterClose(t *testing.T) {
+	server := newBlockTestServer(func(height uint32) time.Duration { return 0 }, func(height uint32) {})
+	defer server.Close()
+
+	mgr := &ClientMgr{}
+	mgr.NewRpcClient().SetAddress(server.URL)
+	iterator, err := mgr.NewBlockIterator(1, 100, &BlockIteratorConfig{Concurrency: 2, BufferSize: 4})
+	assert.Nil(t, err)
+	iterator.Start()
+	for i := uint32(1); i <= 3; i++ {
+		block := <-iterator.Blocks()
+		assert.Equal(t, i, block.Height)
+	}
+	iterator.Close()
+	//blocks already delivered can be drained, and checkpoint is next to the last of them
+	last := uint32(3)
+	for block := range iterator.Blocks() {
+		assert.Equal(t, last+1, block.Height)
+		last = block.Height
+	}
+	assert.Equal(t, last+1, iterator.Checkpoint())
+	assert.Nil(t, iterator.Err())
+
+	//iterator not started can be closed
+	iterator, err = mgr.NewBlockIterator(1, 100, nil)
+	assert.Nil(t, err)
+	iterator.Close()
+	assert.Equal(t, uint32(1), iterator.Checkpoint())
+}
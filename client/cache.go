@@ -0,0 +1,350 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	DEFAULT_CACHE_MAX_ENTRIES   = 10000
+	DEFAULT_CACHE_CONFIRM_DEPTH = uint32(1)
+	DEFAULT_CACHE_HEIGHT_TTL    = time.Second
+)
+
+const DISK_CACHE_TMP_SUFFIX = ".tmp"
+
+//Kind of cached data
+const (
+	CACHE_KIND_BLOCK    = "block"
+	CACHE_KIND_TX       = "tx"
+	CACHE_KIND_EVENT    = "event"
+	CACHE_KIND_CONTRACT = "contract"
+)
+
+//CacheBackend is a size bounded storage of cache entries, evicting the least recently used entry when full
+type CacheBackend interface {
+	Get(key string) ([]byte, bool)
+	Put(key string, value []byte)
+	Len() int
+}
+
+//CacheConfig config of ResponseCache
+type CacheConfig struct {
+	//Data of block at height h is cached only if h+ConfirmDepth <= current block height.
+	//Contract code has no height, and is cached once it can be queried.
+	ConfirmDepth uint32
+	//Current block height is reused within HeightTTL, instead of querying node each time data is stored
+	HeightTTL time.Duration
+}
+
+func NewCacheConfig() *CacheConfig {
+	return &CacheConfig{
+		ConfirmDepth: DEFAULT_CACHE_CONFIRM_DEPTH,
+		HeightTTL:    DEFAULT_CACHE_HEIGHT_TTL,
+	}
+}
+
+//CacheStats is hit and miss statistics of cache
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Stores  uint64
+	Skipped uint64 //Not stored because data is not confirmed yet
+}
+
+type cacheCounter struct {
+	hits    uint64
+	misses  uint64
+	stores  uint64
+	skipped uint64
+}
+
+//ResponseCache is a read-through cache of immutable data queried by ClientMgr, which are
+//blocks by hash, transactions, events of transaction and contract code.
+type ResponseCache struct {
+	config     *CacheConfig
+	backend    CacheBackend
+	counters   map[string]*cacheCounter
+	height     uint32 //Current block height queried at heightTime
+	heightTime time.Time
+	heightLock sync.Mutex
+}
+
+func NewResponseCache(config *CacheConfig, backend CacheBackend) *ResponseCache {
+	if config == nil {
+		config = NewCacheConfig()
+	}
+	if backend == nil {
+		backend = NewMemLRUCache(DEFAULT_CACHE_MAX_ENTRIES)
+	}
+	counters := make(map[string]*cacheCounter)
+	for _, kind := range []string{CACHE_KIND_BLOCK, CACHE_KIND_TX, CACHE_KIND_EVENT, CACHE_KIND_CONTRACT} {
+		counters[kind] = &cacheCounter{}
+	}
+	return &ResponseCache{
+		config:   config,
+		backend:  backend,
+		counters: counters,
+	}
+}
+
+func cacheKey(kind, key string) string {
+	return kind + ":" + strings.ToLower(key)
+}
+
+func (this *ResponseCache) get(kind, key string) ([]byte, bool) {
+	if this == nil {
+		return nil, false
+	}
+	data, ok := this.backend.Get(cacheKey(kind, key))
+	if ok {
+		atomic.AddUint64(&this.counters[kind].hits, 1)
+	} else {
+		atomic.AddUint64(&this.counters[kind].misses, 1)
+	}
+	return data, ok
+}
+
+//put store data of block at height if it's confirmed.
+//getHeight and getCurrentHeight are only called when ConfirmDepth > 0, and current block height is reused within HeightTTL.
+func (this *ResponseCache) put(kind, key string, data []byte, getHeight, getCurrentHeight func() (uint32, error)) {
+	if this.config.ConfirmDepth > 0 {
+		height, err := getHeight()
+		var curHeight uint32
+		if err == nil {
+			curHeight, err = this.getCurrentHeight(getCurrentHeight)
+		}
+		if err != nil || uint64(height)+uint64(this.config.ConfirmDepth) > uint64(curHeight) {
+			atomic.AddUint64(&this.counters[kind].skipped, 1)
+			return
+		}
+	}
+	this.backend.Put(cacheKey(kind, key), data)
+	atomic.AddUint64(&this.counters[kind].stores, 1)
+}
+
+//getCurrentHeight return current block height queried within HeightTTL, or query it by getCurrentHeight
+func (this *ResponseCache) getCurrentHeight(getCurrentHeight func() (uint32, error)) (uint32, error) {
+	this.heightLock.Lock()
+	defer this.heightLock.Unlock()
+	if this.config.HeightTTL > 0 && time.Since(this.heightTime) < this.config.HeightTTL {
+		return this.height, nil
+	}
+	height, err := getCurrentHeight()
+	if err != nil {
+		return 0, err
+	}
+	this.height = height
+	this.heightTime = time.Now()
+	return height, nil
+}
+
+//putUnconfirmable store data which has no height
+func (this *ResponseCache) putUnconfirmable(kind, key string, data []byte) {
+	this.backend.Put(cacheKey(kind, key), data)
+	atomic.AddUint64(&this.counters[kind].stores, 1)
+}
+
+//GetStats return statistics of each kind of cached data
+func (this *ResponseCache) GetStats() map[string]*CacheStats {
+	stats := make(map[string]*CacheStats, len(this.counters))
+	for kind, counter := range this.counters {
+		stats[kind] = &CacheStats{
+			Hits:    atomic.LoadUint64(&counter.hits),
+			Misses:  atomic.LoadUint64(&counter.misses),
+			Stores:  atomic.LoadUint64(&counter.stores),
+			Skipped: atomic.LoadUint64(&counter.skipped),
+		}
+	}
+	return stats
+}
+
+//GetTotalStats return statistics of all kinds of cached data
+func (this *ResponseCache) GetTotalStats() *CacheStats {
+	total := &CacheStats{}
+	for _, stats := range this.GetStats() {
+		total.Hits += stats.Hits
+		total.Misses += stats.Misses
+		total.Stores += stats.Stores
+		total.Skipped += stats.Skipped
+	}
+	return total
+}
+
+//Len return count of cached entries
+func (this *ResponseCache) Len() int {
+	return this.backend.Len()
+}
+
+//EnableCache enable read-through cache of GetBlockByHash, GetTransaction, GetSmartContractEvent and GetSmartContract.
+//It should be called before the ClientMgr is used concurrently.
+func (this *ClientMgr) EnableCache(cache *ResponseCache) {
+	this.cache = cache
+}
+
+func (this *ClientMgr) DisableCache() {
+	this.cache = nil
+}
+
+func (this *ClientMgr) GetCache() *ResponseCache {
+	return this.cache
+}
+
+type memCacheEntry struct {
+	key   string
+	value []byte
+}
+
+//MemLRUCache is an in-memory CacheBackend bounded by count of entries
+type MemLRUCache struct {
+	maxEntries int
+	entries    *list.List
+	index      map[string]*list.Element
+	onEvict    func(key string)
+	lock       sync.Mutex
+}
+
+func NewMemLRUCache(maxEntries int) *MemLRUCache {
+	if maxEntries <= 0 {
+		maxEntries = DEFAULT_CACHE_MAX_ENTRIES
+	}
+	return &MemLRUCache{
+		maxEntries: maxEntries,
+		entries:    list.New(),
+		index:      make(map[string]*list.Element),
+	}
+}
+
+func (this *MemLRUCache) Get(key string) ([]byte, bool) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	elem, ok := this.index[key]
+	if !ok {
+		return nil, false
+	}
+	this.entries.MoveToFront(elem)
+	return elem.Value.(*memCacheEntry).value, true
+}
+
+func (this *MemLRUCache) Put(key string, value []byte) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	elem, ok := this.index[key]
+	if ok {
+		elem.Value.(*memCacheEntry).value = value
+		this.entries.MoveToFront(elem)
+		return
+	}
+	this.index[key] = this.entries.PushFront(&memCacheEntry{key: key, value: value})
+	for this.entries.Len() > this.maxEntries {
+		oldest := this.entries.Back()
+		this.entries.Remove(oldest)
+		key := oldest.Value.(*memCacheEntry).key
+		delete(this.index, key)
+		if this.onEvict != nil {
+			this.onEvict(key)
+		}
+	}
+}
+
+func (this *MemLRUCache) Len() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.entries.Len()
+}
+
+//DiskLRUCache is a CacheBackend of files in a directory bounded by count of entries.
+//Recency is kept in memory, and restored from modify time of files when opened.
+type DiskLRUCache struct {
+	dir   string
+	index *MemLRUCache //Key is file name, value is nil
+}
+
+func OpenDiskLRUCache(dir string, maxEntries int) (*DiskLRUCache, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("create cache dir:%s error:%s", dir, err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read cache dir:%s error:%s", dir, err)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	cache := &DiskLRUCache{dir: dir, index: NewMemLRUCache(maxEntries)}
+	cache.index.onEvict = func(name string) {
+		os.Remove(filepath.Join(dir, name))
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(file.Name(), DISK_CACHE_TMP_SUFFIX) {
+			os.Remove(filepath.Join(dir, file.Name()))
+			continue
+		}
+		cache.index.Put(file.Name(), nil)
+	}
+	return cache, nil
+}
+
+func (this *DiskLRUCache) fileName(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+func (this *DiskLRUCache) Get(key string) ([]byte, bool) {
+	name := this.fileName(key)
+	if _, ok := this.index.Get(name); !ok {
+		return nil, false
+	}
+	path := filepath.Join(this.dir, name)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	//Keep recency after reopen
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return data, true
+}
+
+//Put write value to a temp file and rename it, so that a file is either complete or absent
+func (this *DiskLRUCache) Put(key string, value []byte) {
+	name := this.fileName(key)
+	path := filepath.Join(this.dir, name)
+	tmpFile, err := ioutil.TempFile(this.dir, name+"-*"+DISK_CACHE_TMP_SUFFIX)
+	if err != nil {
+		return
+	}
+	_, err = tmpFile.Write(value)
+	closeErr := tmpFile.Close()
+	if err != nil || closeErr != nil {
+		os.Remove(tmpFile.Name())
+		return
+	}
+	err = os.Rename(tmpFile.Name(), path)
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return
+	}
+	this.index.Put(name, nil)
+}
+
+func (this *DiskLRUCache) Len() int {
+	return this.index.Len()
+}
@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemLRUCache(t *testing.T) {
+	cache := NewMemLRUCache(2)
+	cache.Put("a", []byte("1"))
+	cache.Put("b", []byte("2"))
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+	cache.Put("c", []byte("3"))
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+	data, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), data)
+	assert.Equal(t, 2, cache.Len())
+}
+
+func TestDiskLRUCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dna_cache")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	cache, err := OpenDiskLRUCache(dir, 2)
+	assert.Nil(t, err)
+	cache.Put("a", []byte("1"))
+	cache.Put("b", []byte("2"))
+	cache.Put("c", []byte("3"))
+	_, ok := cache.Get("a")
+	assert.False(t, ok)
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(files))
+
+	cache, err = OpenDiskLRUCache(dir, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, cache.Len())
+	data, ok := cache.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("3"), data)
+}
+
+func TestResponseCache(t *testing.T) {
+	var nilCache *ResponseCache
+	_, ok := nilCache.get(CACHE_KIND_BLOCK, "a")
+	assert.False(t, ok)
+
+	cache := NewResponseCache(&CacheConfig{ConfirmDepth: 2}, nil)
+	curHeight := func() (uint32, error) { return 10, nil }
+	unavailable := func() (uint32, error) { return 0, fmt.Errorf("unavailable") }
+	cache.put(CACHE_KIND_BLOCK, "a", []byte("1"), heightOf(9), curHeight)
+	cache.put(CACHE_KIND_BLOCK, "B", []byte("2"), heightOf(8), curHeight)
+	cache.put(CACHE_KIND_TX, "c", []byte("3"), heightOf(1), unavailable)
+	cache.putUnconfirmable(CACHE_KIND_CONTRACT, "d", []byte("4"))
+
+	_, ok = cache.get(CACHE_KIND_BLOCK, "a")
+	assert.False(t, ok)
+	data, ok := cache.get(CACHE_KIND_BLOCK, "b")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("2"), data)
+	_, ok = cache.get(CACHE_KIND_TX, "b")
+	assert.False(t, ok)
+	_, ok = cache.get(CACHE_KIND_CONTRACT, "d")
+	assert.True(t, ok)
+
+	stats := cache.GetStats()
+	assert.Equal(t, &CacheStats{Hits: 1, Misses: 1, Stores: 1, Skipped: 1}, stats[CACHE_KIND_BLOCK])
+	assert.Equal(t, &CacheStats{Misses: 1, Skipped: 1}, stats[CACHE_KIND_TX])
+	assert.Equal(t, &CacheStats{Hits: 2, Misses: 2, Stores: 2, Skipped: 2}, cache.GetTotalStats())
+	assert.Equal(t, 2, cache.Len())
+}
+
+func heightOf(height uint32) func() (uint32, error) {
+	return func() (uint32, error) { return height, nil }
+}
+
+func TestResponseCache_QueryHeight(t *testing.T) {
+	queries := 0
+	curHeight := func() (uint32, error) {
+		queries++
+		return 10, nil
+	}
+	noQuery := func() (uint32, error) {
+		t.Fatal("height should not be queried")
+		return 0, nil
+	}
+	cache := NewResponseCache(&CacheConfig{ConfirmDepth: 0}, nil)
+	cache.put(CACHE_KIND_TX, "a", []byte("1"), noQuery, noQuery)
+	_, ok := cache.get(CACHE_KIND_TX, "a")
+	assert.True(t, ok)
+
+	//current height is reused within ttl
+	cache = NewResponseCache(&CacheConfig{ConfirmDepth: 1, HeightTTL: time.Minute}, nil)
+	cache.put(CACHE_KIND_TX, "a", []byte("1"), heightOf(9), curHeight)
+	cache.put(CACHE_KIND_TX, "b", []byte("2"), heightOf(10), curHeight)
+	assert.Equal(t, 1, queries)
+	assert.Equal(t, &CacheStats{Stores: 1, Skipped: 1}, cache.GetStats()[CACHE_KIND_TX])
+
+	cache = NewResponseCache(&CacheConfig{ConfirmDepth: 1}, nil)
+	cache.put(CACHE_KIND_TX, "a", []byte("1"), heightOf(9), curHeight)
+	cache.put(CACHE_KIND_TX, "b", []byte("2"), heightOf(9), curHeight)
+	assert.Equal(t, 3, queries)
+}
@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"errors"
+
+	"github.com/DNAProject/DNA/common"
+)
+
+//EndpointCapability is whether an endpoint is exposed by node through a transport
+type EndpointCapability struct {
+	Transport string //TRANSPORT_RPC, TRANSPORT_REST or TRANSPORT_WS
+	Endpoint  string //Name of ClientMgr method
+	Supported bool
+	Probed    bool  //False if endpoint changes state of node, whose Supported is by transport only
+	Err       error //Probe failed by transport error, Supported is unknown
+}
+
+//NodeCapabilities is the result of probing endpoints of node
+type NodeCapabilities struct {
+	Endpoints []*EndpointCapability
+}
+
+//IsSupported report whether endpoint is exposed through transport
+func (this *NodeCapabilities) IsSupported(transport, endpoint string) bool {
+	for _, capability := range this.Endpoints {
+		if capability.Transport == transport && capability.Endpoint == endpoint {
+			return capability.Supported
+		}
+	}
+	return false
+}
+
+//GetSupported return supported endpoints of transport
+func (this *NodeCapabilities) GetSupported(transport string) []string {
+	endpoints := make([]string, 0)
+	for _, capability := range this.Endpoints {
+		if capability.Transport == transport && capability.Supported {
+			endpoints = append(endpoints, capability.Endpoint)
+		}
+	}
+	return endpoints
+}
+
+//endpointProbe call endpoint with harmless params, node rejecting params still means endpoint is exposed
+type endpointProbe struct {
+	endpoint string
+	probe    func(client DNAClient, qid string) error
+}
+
+var endpointProbes = []*endpointProbe{
+	{"GetVersion", func(client DNAClient, qid string) error {
+		_, err := client.getVersion(qid)
+		return err
+	}},
+	{"GetNetworkId", func(client DNAClient, qid string) error {
+		_, err := client.getNetworkId(qid)
+		return err
+	}},
+	{"GetCurrentBlockHeight", func(client DNAClient, qid string) error {
+		_, err := client.getCurrentBlockHeight(qid)
+		return err
+	}},
+	{"GetBlockByHeight", func(client DNAClient, qid string) error {
+		_, err := client.getBlockByHeight(qid, 0)
+		return err
+	}},
+	{"GetBlockInfoByHeight", func(client DNAClient, qid string) error {
+		_, err := client.getBlockInfoByHeight(qid, 0)
+		return err
+	}},
+	{"GetBlockHash", func(client DNAClient, qid string) error {
+		_, err := client.getBlockHash(qid, 0)
+		return err
+	}},
+	{"GetBlockTxHashesByHeight", func(client DNAClient, qid string) error {
+		_, err := client.getBlockTxHashesByHeight(qid, 0)
+		return err
+	}},
+	{"GetBlockHeightByTxHash", func(client DNAClient, qid string) error {
+		_, err := client.getBlockHeightByTxHash(qid, common.UINT256_EMPTY.ToHexString())
+		return err
+	}},
+	{"GetSmartContractEventByBlock", func(client DNAClient, qid string) error {
+		_, err := client.getSmartContractEventByBlock(qid, 0)
+		return err
+	}},
+	{"GetMerkleProof", func(client DNAClient, qid string) error {
+		_, err := client.getMerkleProof(qid, common.UINT256_EMPTY.ToHexString())
+		return err
+	}},
+	{"GetMemPoolTxCount", func(client DNAClient, qid string) error {
+		_, err := client.getMemPoolTxCount(qid)
+		return err
+	}},
+	{"GetBalance", func(client DNAClient, qid string) error {
+		_, err := client.getBalance(qid, common.ADDRESS_EMPTY.ToBase58())
+		return err
+	}},
+	{"GetGenerateBlockTime", func(client DNAClient, qid string) error {
+		_, err := client.getGenerateBlockTime(qid)
+		return err
+	}},
+	{"GetBlockRootWithNewTxRoot", func(client DNAClient, qid string) error {
+		_, err := client.getBlockRootWithNewTxRoot(qid, common.UINT256_EMPTY)
+		return err
+	}},
+}
+
+//writeEndpoints are endpoints which change state of node, with the transports exposing them.
+//They are never called by probe.
+var writeEndpoints = []*struct {
+	endpoint   string
+	transports []string
+}{
+	{"SendEmergencyGovReq", []string{TRANSPORT_RPC}},
+}
+
+//ProbeCapabilities report which endpoints are exposed by node through each of rpc, rest and websocket client.
+//Endpoint is unsupported if node responds invalid method, or the transport has no such endpoint.
+//Endpoints changing state of node are not called, and are reported by transport only.
+func (this *ClientMgr) ProbeCapabilities() *NodeCapabilities {
+	clients := make([]DNAClient, 0, 3)
+	transports := make([]string, 0, 3)
+	if this.rpc != nil {
+		clients = append(clients, this.rpc)
+		transports = append(transports, TRANSPORT_RPC)
+	}
+	if this.rest != nil {
+		clients = append(clients, this.rest)
+		transports = append(transports, TRANSPORT_REST)
+	}
+	if this.ws != nil {
+		clients = append(clients, this.ws)
+		transports = append(transports, TRANSPORT_WS)
+	}
+	capabilities := &NodeCapabilities{}
+	for i, client := range clients {
+		for _, probe := range endpointProbes {
+			capability := &EndpointCapability{
+				Transport: transports[i],
+				Endpoint:  probe.endpoint,
+				Probed:    true,
+			}
+			err := probe.probe(client, this.getNextQid())
+			switch {
+			case err == nil:
+				capability.Supported = true
+			case errors.Is(err, ERR_INVALID_METHOD) || errors.Is(err, ERR_METHOD_NOT_SUPPORTED):
+			case GetNodeError(err) != nil:
+				capability.Supported = true
+			default:
+				capability.Err = err
+			}
+			capabilities.Endpoints = append(capabilities.Endpoints, capability)
+		}
+		for _, write := range writeEndpoints {
+			capability := &EndpointCapability{
+				Transport: transports[i],
+				Endpoint:  write.endpoint,
+			}
+			for _, transport := range write.transports {
+				if transport == transports[i] {
+					capability.Supported = true
+				}
+			}
+			capabilities.Endpoints = append(capabilities.Endpoints, capability)
+		}
+	}
+	return capabilities
+}
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbeCapabilities(t *testing.T) {
+	var emergencyReqs int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &JsonRpcRequest{}
+		json.NewDecoder(r.Body).Decode(req)
+		rsp := &JsonRpcResponse{Id: req.Id}
+		switch req.Method {
+		case RPC_GET_ONT_BALANCE:
+			rsp.Result = json.RawMessage(`{"gas":"100","height":"12"}`)
+		case RPC_GET_GENERATE_BLOCK_TIME:
+			rsp.Result = json.RawMessage(`6`)
+		case SEND_EMERGENCY_GOV_REQ:
+			atomic.AddInt32(&emergencyReqs, 1)
+			rsp.Error, rsp.Desc = ERR_CODE_INVALID_PARAMS, "INVALID PARAMS"
+		default:
+			rsp.Error, rsp.Desc = ERR_CODE_INVALID_METHOD, "INVALID METHOD"
+		}
+		json.NewEncoder(w).Encode(rsp)
+	}))
+	defer server.Close()
+
+	mgr := &ClientMgr{}
+	mgr.NewRpcClient().SetAddress(server.URL)
+	balance, err := mgr.GetBalance("AFmseVrdL9f9oyCzZefL9tG6UbvhPbdYzM")
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(12), balance.Height)
+	assert.Equal(t, uint64(100), balance.Balances["gas"])
+	blockTime, err := mgr.GetGenerateBlockTime()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(6), blockTime)
+
+	capabilities := mgr.ProbeCapabilities()
+	assert.Equal(t, len(endpointProbes)+len(writeEndpoints), len(capabilities.Endpoints))
+	assert.True(t, capabilities.IsSupported(TRANSPORT_RPC, "GetBalance"))
+	//endpoint changing state of node is never sent
+	assert.True(t, capabilities.IsSupported(TRANSPORT_RPC, "SendEmergencyGovReq"))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&emergencyReqs))
+	assert.False(t, capabilities.IsSupported(TRANSPORT_RPC, "GetBlockRootWithNewTxRoot"))
+	assert.False(t, capabilities.IsSupported(TRANSPORT_REST, "GetBalance"))
+	assert.Equal(t, []string{"GetBalance", "GetGenerateBlockTime", "SendEmergencyGovReq"}, capabilities.GetSupported(TRANSPORT_RPC))
+}
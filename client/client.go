@@ -16,15 +16,18 @@ import (
 )
 
 type ClientMgr struct {
-	rpc       *RpcClient  //Rpc client used the rpc api of dna
-	rest      *RestClient //Rest client used the rest api of dna
-	ws        *WSClient   //Web socket client used the web socket api of dna
-	defClient DNAClient
-	qid       uint64
+	rpc          *RpcClient  //Rpc client used the rpc api of dna
+	rest         *RestClient //Rest client used the rest api of dna
+	ws           *WSClient   //Web socket client used the web socket api of dna
+	defClient    DNAClient
+	qid          uint64
+	interceptors *InterceptorChain
+	cache        *ResponseCache
 }
 
 func (this *ClientMgr) NewRpcClient() *RpcClient {
 	this.rpc = NewRpcClient()
+	this.setInterceptors(this.rpc)
 	return this.rpc
 }
 
@@ -34,6 +37,7 @@ func (this *ClientMgr) GetRpcClient() *RpcClient {
 
 func (this *ClientMgr) NewRestClient() *RestClient {
 	this.rest = NewRestClient()
+	this.setInterceptors(this.rest)
 	return this.rest
 }
 
@@ -43,6 +47,7 @@ func (this *ClientMgr) GetRestClient() *RestClient {
 
 func (this *ClientMgr) NewWebSocketClient() *WSClient {
 	wsClient := NewWSClient()
+	this.setInterceptors(wsClient)
 	this.ws = wsClient
 	return wsClient
 }
@@ -52,6 +57,7 @@ func (this *ClientMgr) GetWebSocketClient() *WSClient {
 }
 
 func (this *ClientMgr) SetDefaultClient(client DNAClient) {
+	this.setInterceptors(client)
 	this.defClient = client
 }
 
@@ -108,11 +114,23 @@ func (this *ClientMgr) GetBlockByHash(blockHash string) (*types.Block, error) {
 	if client == nil {
 		return nil, fmt.Errorf("don't have available client of dna")
 	}
+	data, cached := this.cache.get(CACHE_KIND_BLOCK, blockHash)
+	if cached {
+		return utils.GetBlock(data)
+	}
 	data, err := client.getBlockByHash(this.getNextQid(), blockHash)
 	if err != nil {
 		return nil, err
 	}
-	return utils.GetBlock(data)
+	block, err := utils.GetBlock(data)
+	if err != nil {
+		return nil, err
+	}
+	if this.cache != nil {
+		getHeight := func() (uint32, error) { return block.Header.Height, nil }
+		this.cache.put(CACHE_KIND_BLOCK, blockHash, data, getHeight, this.GetCurrentBlockHeight)
+	}
+	return block, nil
 }
 
 func (this *ClientMgr) GetTransaction(txHash string) (*types.Transaction, error) {
@@ -120,11 +138,20 @@ func (this *ClientMgr) GetTransaction(txHash string) (*types.Transaction, error)
 	if client == nil {
 		return nil, fmt.Errorf("don't have available client of dna")
 	}
+	data, cached := this.cache.get(CACHE_KIND_TX, txHash)
+	if cached {
+		return utils.GetTransaction(data)
+	}
 	data, err := client.getRawTransaction(this.getNextQid(), txHash)
 	if err != nil {
 		return nil, err
 	}
-	return utils.GetTransaction(data)
+	tx, err := utils.GetTransaction(data)
+	if err != nil {
+		return nil, err
+	}
+	this.cacheByTxHash(CACHE_KIND_TX, txHash, data)
+	return tx, nil
 }
 
 func (this *ClientMgr) GetBlockHash(height uint32) (common.Uint256, error) {
@@ -180,14 +207,24 @@ func (this *ClientMgr) GetSmartContract(contractAddress string) (*sdkcom.SmartCo
 	if client == nil {
 		return nil, fmt.Errorf("don't have available client of dna")
 	}
-	data, err := client.getSmartContract(this.getNextQid(), contractAddress)
-	if err != nil {
-		return nil, err
+	data, cached := this.cache.get(CACHE_KIND_CONTRACT, contractAddress)
+	if !cached {
+		var err error
+		data, err = client.getSmartContract(this.getNextQid(), contractAddress)
+		if err != nil {
+			return nil, err
+		}
 	}
 	deployCode, err := utils.GetSmartContract(data)
 	if err != nil {
 		return nil, err
 	}
+	if deployCode == nil {
+		return nil, fmt.Errorf("contract:%s not found", contractAddress)
+	}
+	if !cached && this.cache != nil {
+		this.cache.putUnconfirmable(CACHE_KIND_CONTRACT, contractAddress, data)
+	}
 	sm := sdkcom.SmartContract(*deployCode)
 	return &sm, nil
 }
@@ -197,11 +234,32 @@ func (this *ClientMgr) GetSmartContractEvent(txHash string) (*sdkcom.SmartContac
 	if client == nil {
 		return nil, fmt.Errorf("don't have available client of dna")
 	}
+	data, cached := this.cache.get(CACHE_KIND_EVENT, txHash)
+	if cached {
+		return utils.GetSmartContractEvent(data)
+	}
 	data, err := client.getSmartContractEvent(this.getNextQid(), txHash)
 	if err != nil {
 		return nil, err
 	}
-	return utils.GetSmartContractEvent(data)
+	event, err := utils.GetSmartContractEvent(data)
+	if err != nil {
+		return nil, err
+	}
+	if event != nil {
+		this.cacheByTxHash(CACHE_KIND_EVENT, txHash, data)
+	}
+	return event, nil
+}
+
+//cacheByTxHash store data of transaction into cache if the block of transaction is confirmed.
+//Height of transaction is only queried when cache needs to check confirmation.
+func (this *ClientMgr) cacheByTxHash(kind, txHash string, data []byte) {
+	if this.cache == nil {
+		return
+	}
+	getHeight := func() (uint32, error) { return this.GetBlockHeightByTxHash(txHash) }
+	this.cache.put(kind, txHash, data, getHeight, this.GetCurrentBlockHeight)
 }
 
 func (this *ClientMgr) GetSmartContractEventByBlock(height uint32) ([]*sdkcom.SmartContactEvent, error) {
@@ -276,6 +334,55 @@ func (this *ClientMgr) GetNetworkId() (uint32, error) {
 	return utils.GetUint32(data)
 }
 
+//GetBalance return balance of native assets of account, address is base58 string
+func (this *ClientMgr) GetBalance(address string) (*sdkcom.BalanceInfo, error) {
+	client := this.getClient()
+	if client == nil {
+		return nil, fmt.Errorf("don't have available client of dna")
+	}
+	data, err := client.getBalance(this.getNextQid(), address)
+	if err != nil {
+		return nil, err
+	}
+	return utils.GetBalanceInfo(data)
+}
+
+//GetGenerateBlockTime return the interval of generating block in second
+func (this *ClientMgr) GetGenerateBlockTime() (uint32, error) {
+	client := this.getClient()
+	if client == nil {
+		return 0, fmt.Errorf("don't have available client of dna")
+	}
+	data, err := client.getGenerateBlockTime(this.getNextQid())
+	if err != nil {
+		return 0, err
+	}
+	return utils.GetUint32(data)
+}
+
+//SendEmergencyGovReq send serialized emergency governance request to node, only supported by rpc
+func (this *ClientMgr) SendEmergencyGovReq(data []byte) error {
+	client := this.getRpcOnlyClient()
+	if client == nil {
+		return fmt.Errorf("don't have available client of dna")
+	}
+	_, err := client.sendEmergencyGovReq(this.getNextQid(), data)
+	return err
+}
+
+//GetBlockRootWithNewTxRoot return the block root after appending txRoot to the block root merkle tree, only supported by rpc
+func (this *ClientMgr) GetBlockRootWithNewTxRoot(txRoot common.Uint256) (common.Uint256, error) {
+	client := this.getRpcOnlyClient()
+	if client == nil {
+		return common.UINT256_EMPTY, fmt.Errorf("don't have available client of dna")
+	}
+	data, err := client.getBlockRootWithNewTxRoot(this.getNextQid(), txRoot)
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	return utils.GetUint256(data)
+}
+
 func (this *ClientMgr) SendTransaction(mutTx *types.MutableTransaction) (common.Uint256, error) {
 	client := this.getClient()
 	if client == nil {
@@ -313,6 +420,12 @@ func (this *ClientMgr) PreExecTransaction(mutTx *types.MutableTransaction) (*sdk
 	return preResult, nil
 }
 
+//SubmitTransaction send transaction with retry and rebroadcast, and wait for transaction packed into block.
+//Using default TxSubmitConfig, see TxSubmitter for more detail.
+func (this *ClientMgr) SubmitTransaction(mutTx *types.MutableTransaction) *TxSubmitResult {
+	return this.NewTxSubmitter(nil).Submit(mutTx)
+}
+
 //WaitForGenerateBlock Wait dna generate block. Default wait 2 blocks.
 //return timeout error when there is no block generate in some time.
 func (this *ClientMgr) WaitForGenerateBlock(timeout time.Duration, blockCount ...uint32) (bool, error) {
@@ -357,6 +470,31 @@ func (this *ClientMgr) getClient() DNAClient {
 	return nil
 }
 
+//getRpcOnlyClient return rpc client if it's set, for methods which are only supported by rpc
+func (this *ClientMgr) getRpcOnlyClient() DNAClient {
+	if this.rpc != nil {
+		return this.rpc
+	}
+	return this.getClient()
+}
+
+func (this *ClientMgr) getClients() []DNAClient {
+	clients := make([]DNAClient, 0, 4)
+	if this.defClient != nil {
+		clients = append(clients, this.defClient)
+	}
+	if this.rpc != nil && DNAClient(this.rpc) != this.defClient {
+		clients = append(clients, this.rpc)
+	}
+	if this.rest != nil && DNAClient(this.rest) != this.defClient {
+		clients = append(clients, this.rest)
+	}
+	if this.ws != nil && DNAClient(this.ws) != this.defClient {
+		clients = append(clients, this.ws)
+	}
+	return clients
+}
+
 func (this *ClientMgr) getNextQid() string {
 	return fmt.Sprintf("%d", atomic.AddUint64(&this.qid, 1))
 }
@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/DNAProject/DNA/common"
 	"github.com/DNAProject/DNA/core/types"
 )
 
@@ -31,6 +32,10 @@ type DNAClient interface {
 	getMemPoolTxState(qid, txHash string) ([]byte, error)
 	getMemPoolTxCount(qid string) ([]byte, error)
 	sendRawTransaction(qid string, tx *types.Transaction, isPreExec bool) ([]byte, error)
+	getBalance(qid, address string) ([]byte, error)
+	getGenerateBlockTime(qid string) ([]byte, error)
+	sendEmergencyGovReq(qid string, data []byte) ([]byte, error)
+	getBlockRootWithNewTxRoot(qid string, txRoot common.Uint256) ([]byte, error)
 }
 
 const (
@@ -54,6 +59,8 @@ const (
 	RPC_GET_BLOCK_HEIGHT_BY_TX_HASH = "getblockheightbytxhash"
 	SEND_EMERGENCY_GOV_REQ          = "sendemergencygovreq"
 	GET_BLOCK_ROOT_WITH_NEW_TX_ROOT = "getblockrootwithnewtxroot"
+
+	RPC_BATCH = "batch" //Not a rpc method, only used for error report of batch request
 )
 
 //JsonRpc version
@@ -67,6 +74,9 @@ type JsonRpcRequest struct {
 	Params  []interface{} `json:"params"`
 }
 
+//Max request count in one JSON-RPC batch, larger batch will be split
+var DEFAULT_RPC_BATCH_SIZE = 100
+
 //JsonRpcResponse object response for JsonRpcRequest
 type JsonRpcResponse struct {
 	Id     string          `json:"id"`
@@ -155,6 +165,7 @@ const (
 	WS_ACTION_GET_MEM_POOL_TX_COUNT       = "getmempooltxcount"
 	WS_ACTION_GET_VERSION                 = "getversion"
 	WS_ACTION_GET_NETWORK_ID              = "getnetworkid"
+	WS_ACTION_GET_BALANCE                 = "getbalance"
 
 	WS_SUB_ACTION_RAW_BLOCK     = "sendrawblock"
 	WS_SUB_ACTION_JSON_BLOCK    = "sendjsonblock"
@@ -175,6 +186,7 @@ type WSRequest struct {
 	Id     string
 	Params map[string]interface{}
 	ResCh  chan *WSResponse
+	ErrCh  chan error //Receive error if connection dropped before response
 }
 
 type WSResponse struct {
@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	TRANSPORT_RPC  = "RPC"
+	TRANSPORT_REST = "REST"
+	TRANSPORT_WS   = "WS"
+)
+
+//Error code of node response
+const (
+	ERR_CODE_SUCCESS             = 0
+	ERR_CODE_SESSION_EXPIRED     = 41001
+	ERR_CODE_SERVICE_CEILING     = 41002
+	ERR_CODE_ILLEGAL_DATAFORMAT  = 41003
+	ERR_CODE_INVALID_VERSION     = 41004
+	ERR_CODE_INVALID_METHOD      = 42001
+	ERR_CODE_INVALID_PARAMS      = 42002
+	ERR_CODE_INVALID_TRANSACTION = 43001
+	ERR_CODE_INVALID_ASSET       = 43002
+	ERR_CODE_INVALID_BLOCK       = 43003
+	ERR_CODE_UNKNOWN_TRANSACTION = 44001
+	ERR_CODE_UNKNOWN_ASSET       = 44002
+	ERR_CODE_UNKNOWN_BLOCK       = 44003
+	ERR_CODE_UNKNOWN_CONTRACT    = 44004
+	ERR_CODE_INTERNAL_ERROR      = 45001
+	ERR_CODE_DUPLICATED_TX       = 45002
+	ERR_CODE_TX_POOL_FULL        = 45016
+	ERR_CODE_GAS_PRICE           = 45020
+	ERR_CODE_VERIFY_SIGNATURE    = 45021
+	ERR_CODE_SMARTCODE_ERROR     = 47001
+	ERR_CODE_PRE_EXEC_ERROR      = 47002
+)
+
+//Sentinel errors of node response, using with errors.Is
+var (
+	ERR_UNKNOWN_TRANSACTION = &NodeError{Code: ERR_CODE_UNKNOWN_TRANSACTION, Desc: "unknown transaction"}
+	ERR_UNKNOWN_BLOCK       = &NodeError{Code: ERR_CODE_UNKNOWN_BLOCK, Desc: "unknown block"}
+	ERR_UNKNOWN_CONTRACT    = &NodeError{Code: ERR_CODE_UNKNOWN_CONTRACT, Desc: "unknown contract"}
+	ERR_DUPLICATED_TX       = &NodeError{Code: ERR_CODE_DUPLICATED_TX, Desc: "duplicated transaction"}
+	ERR_INVALID_PARAMS      = &NodeError{Code: ERR_CODE_INVALID_PARAMS, Desc: "invalid params"}
+	ERR_INVALID_METHOD      = &NodeError{Code: ERR_CODE_INVALID_METHOD, Desc: "invalid method"}
+	ERR_INVALID_TRANSACTION = &NodeError{Code: ERR_CODE_INVALID_TRANSACTION, Desc: "invalid transaction"}
+	ERR_GAS_PRICE           = &NodeError{Code: ERR_CODE_GAS_PRICE, Desc: "invalid gas price"}
+	ERR_VERIFY_SIGNATURE    = &NodeError{Code: ERR_CODE_VERIFY_SIGNATURE, Desc: "verify signature failed"}
+	ERR_TX_POOL_FULL        = &NodeError{Code: ERR_CODE_TX_POOL_FULL, Desc: "tx pool full"}
+	ERR_INTERNAL_ERROR      = &NodeError{Code: ERR_CODE_INTERNAL_ERROR, Desc: "internal error"}
+)
+
+//NodeError is the error response of dna node
+type NodeError struct {
+	Code      int64
+	Desc      string
+	Result    string
+	Transport string //TRANSPORT_RPC, TRANSPORT_REST or TRANSPORT_WS
+	Method    string //Rpc method, rest path or web socket action
+	Qid       string
+}
+
+func (this *NodeError) Error() string {
+	if this.Transport == "" {
+		return fmt.Sprintf("node error code:%d desc:%s", this.Code, this.Desc)
+	}
+	return fmt.Sprintf("%s response method:%s qid:%s error code:%d desc:%s result:%s",
+		this.Transport, this.Method, this.Qid, this.Code, this.Desc, this.Result)
+}
+
+//Is report whether target is a NodeError with the same error code. Desc is not compared.
+func (this *NodeError) Is(target error) bool {
+	t, ok := target.(*NodeError)
+	return ok && this.Code == t.Code
+}
+
+//TransportError is the error of sending request to node or reading response from node
+type TransportError struct {
+	Transport string
+	Method    string
+	Qid       string
+	Err       error
+}
+
+func (this *TransportError) Error() string {
+	return fmt.Sprintf("%s request method:%s qid:%s error:%s", this.Transport, this.Method, this.Qid, this.Err)
+}
+
+func (this *TransportError) Unwrap() error {
+	return this.Err
+}
+
+var (
+	//ERR_REQUEST_TIMEOUT is the error of no response from node in time, wrapped by TransportError
+	ERR_REQUEST_TIMEOUT = errors.New("request timeout")
+	//ERR_WS_DISCONNECTED is the error of request failed because websocket connection is not available, wrapped by TransportError
+	ERR_WS_DISCONNECTED = errors.New("websocket disconnected")
+	//ERR_METHOD_NOT_SUPPORTED is the error of method has no endpoint in the transport, wrapped by TransportError
+	ERR_METHOD_NOT_SUPPORTED = errors.New("method not supported by transport")
+)
+
+//GetNodeError return the NodeError in err's chain, or nil if not found
+func GetNodeError(err error) *NodeError {
+	var nodeErr *NodeError
+	if errors.As(err, &nodeErr) {
+		return nodeErr
+	}
+	return nil
+}
+
+//IsTransportError report whether err is failed to communicate with node
+func IsTransportError(err error) bool {
+	var transErr *TransportError
+	return errors.As(err, &transErr)
+}
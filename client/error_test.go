@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeError_Is(t *testing.T) {
+	err := &NodeError{
+		Code:      ERR_CODE_UNKNOWN_TRANSACTION,
+		Desc:      "UNKNOWN TRANSACTION",
+		Transport: TRANSPORT_RPC,
+		Method:    RPC_GET_MEM_POOL_TX_STATE,
+		Qid:       "1",
+	}
+	assert.True(t, errors.Is(err, ERR_UNKNOWN_TRANSACTION))
+	assert.False(t, errors.Is(err, ERR_UNKNOWN_BLOCK))
+
+	wrapped := fmt.Errorf("GetMemPoolTxState error:%w", err)
+	assert.True(t, errors.Is(wrapped, ERR_UNKNOWN_TRANSACTION))
+	nodeErr := GetNodeError(wrapped)
+	assert.NotNil(t, nodeErr)
+	assert.Equal(t, TRANSPORT_RPC, nodeErr.Transport)
+	assert.Equal(t, "1", nodeErr.Qid)
+
+	//error is matched by code only
+	invalidTx := &NodeError{Code: ERR_CODE_INVALID_TRANSACTION, Desc: "duplicated transaction detected"}
+	assert.False(t, errors.Is(invalidTx, ERR_DUPLICATED_TX))
+	assert.True(t, errors.Is(invalidTx, ERR_INVALID_TRANSACTION))
+}
+
+func TestClassifyTxError(t *testing.T) {
+	assert.Equal(t, TX_ERR_NONE, ClassifyTxError(nil))
+	transErr := &TransportError{Transport: TRANSPORT_WS, Method: WS_ACTION_SEND_TRANSACTION, Qid: "2", Err: ERR_REQUEST_TIMEOUT}
+	assert.Equal(t, TX_ERR_NETWORK, ClassifyTxError(transErr))
+	assert.True(t, errors.Is(transErr, ERR_REQUEST_TIMEOUT))
+	assert.Equal(t, TX_ERR_DUPLICATE, ClassifyTxError(&NodeError{Code: ERR_CODE_DUPLICATED_TX}))
+	assert.Equal(t, TX_ERR_INVALID_SIGNATURE, ClassifyTxError(&NodeError{Code: ERR_CODE_VERIFY_SIGNATURE}))
+	assert.Equal(t, TX_ERR_INSUFFICIENT_GAS, ClassifyTxError(&NodeError{Code: ERR_CODE_GAS_PRICE}))
+	//error is not classified by message
+	assert.Equal(t, TX_ERR_REJECTED, ClassifyTxError(&NodeError{Code: ERR_CODE_INVALID_TRANSACTION, Desc: "insufficient balance to pay fee"}))
+	assert.Equal(t, TX_ERR_REJECTED, ClassifyTxError(&NodeError{Code: ERR_CODE_INVALID_TRANSACTION, Desc: "duplicated transaction detected"}))
+	assert.Equal(t, TX_ERR_REJECTED, ClassifyTxError(fmt.Errorf("http post request timeout")))
+	assert.Equal(t, TX_ERR_REJECTED, ClassifyTxError(&NodeError{Code: ERR_CODE_INVALID_PARAMS, Desc: "INVALID PARAMS"}))
+}
@@ -0,0 +1,265 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	sdkcom "github.com/DNAProject/DNA-go-sdk/common"
+	"github.com/DNAProject/DNA-go-sdk/utils"
+	"github.com/DNAProject/DNA/common"
+)
+
+//EventFilter select notifies of smart contract events on client side.
+//Conditions of different kinds are ANDed, values of the same kind are ORed, empty kind matches everything.
+//
+//  filter := NewEventFilter().Contract(contractAddress).EventName("transfer").From(address).MinAmount(big.NewInt(100))
+type EventFilter struct {
+	contracts  map[string]bool
+	eventNames map[string]bool
+	from       map[string]bool
+	to         map[string]bool
+	txHashes   map[string]bool
+	minAmount  *big.Int
+}
+
+func NewEventFilter() *EventFilter {
+	return &EventFilter{}
+}
+
+//Contract match notify of contract, contract address is hex string
+func (this *EventFilter) Contract(contractAddresses ...string) *EventFilter {
+	this.contracts = addFilterValues(this.contracts, contractAddresses, true)
+	return this
+}
+
+//EventName match the decoded event name, which is the first element of states
+func (this *EventFilter) EventName(names ...string) *EventFilter {
+	this.eventNames = addFilterValues(this.eventNames, names, false)
+	return this
+}
+
+//From match the second element of states, address is base58 string
+func (this *EventFilter) From(addresses ...string) *EventFilter {
+	this.from = addFilterValues(this.from, addresses, false)
+	return this
+}
+
+//To match the third element of states, address is base58 string
+func (this *EventFilter) To(addresses ...string) *EventFilter {
+	this.to = addFilterValues(this.to, addresses, false)
+	return this
+}
+
+//TxHash match notify of transactions
+func (this *EventFilter) TxHash(txHashes ...string) *EventFilter {
+	this.txHashes = addFilterValues(this.txHashes, txHashes, true)
+	return this
+}
+
+//MinAmount match notify whose amount, the fourth element of states, is not less than amount
+func (this *EventFilter) MinAmount(amount *big.Int) *EventFilter {
+	this.minAmount = amount
+	return this
+}
+
+func addFilterValues(values map[string]bool, added []string, ignoreCase bool) map[string]bool {
+	if values == nil {
+		values = make(map[string]bool, len(added))
+	}
+	for _, value := range added {
+		if ignoreCase {
+			value = strings.ToLower(value)
+		}
+		values[value] = true
+	}
+	return values
+}
+
+//FilteredNotify is a notify matched by EventFilter, with its transaction and block height
+type FilteredNotify struct {
+	TxHash      string
+	Height      uint32
+	State       byte
+	GasConsumed uint64
+	NotifyIndex int
+	Notify      *sdkcom.NotifyEventInfo
+	Decoded     *DecodedNotify
+}
+
+//DecodedNotify is the states of notify decoded in the layout of [event name, from, to, amount],
+//which is used by native transfer and oep4 transfer. Fields which cannot be decoded are empty.
+type DecodedNotify struct {
+	EventName string
+	From      string
+	To        string
+	Amount    *big.Int
+}
+
+//DecodeNotify decode states of notify. Event name is hex decoded if it's hex string,
+//address in hex is converted to base58, and amount in neo bytes is converted to big.Int.
+func DecodeNotify(notify *sdkcom.NotifyEventInfo) *DecodedNotify {
+	decoded := &DecodedNotify{}
+	states, ok := notify.States.([]interface{})
+	if !ok || len(states) == 0 {
+		return decoded
+	}
+	name, ok := states[0].(string)
+	if ok {
+		decoded.EventName = decodeEventName(name)
+	}
+	if len(states) > 1 {
+		decoded.From = decodeNotifyAddress(states[1])
+	}
+	if len(states) > 2 {
+		decoded.To = decodeNotifyAddress(states[2])
+	}
+	if len(states) > 3 {
+		decoded.Amount = decodeNotifyAmount(states[3])
+	}
+	return decoded
+}
+
+func decodeEventName(name string) string {
+	data, err := hex.DecodeString(name)
+	if err != nil || len(data) == 0 {
+		return name
+	}
+	for _, c := range data {
+		if c < 0x20 || c > 0x7e {
+			return name
+		}
+	}
+	return string(data)
+}
+
+func decodeNotifyAddress(state interface{}) string {
+	address, ok := state.(string)
+	if !ok {
+		return ""
+	}
+	if _, err := utils.AddressFromBase58(address); err == nil {
+		return address
+	}
+	addr, err := utils.AddressFromHexString(address)
+	if err != nil {
+		return ""
+	}
+	return addr.ToBase58()
+}
+
+func decodeNotifyAmount(state interface{}) *big.Int {
+	switch amount := state.(type) {
+	case uint64:
+		return new(big.Int).SetUint64(amount)
+	case float64:
+		value, _ := big.NewFloat(amount).Int(nil)
+		return value
+	case json.Number:
+		value, ok := new(big.Int).SetString(amount.String(), 10)
+		if ok {
+			return value
+		}
+	case string:
+		data, err := hex.DecodeString(amount)
+		if err == nil {
+			return common.BigIntFromNeoBytes(data)
+		}
+	}
+	return nil
+}
+
+//MatchNotify return the decoded notify if notify of transaction matched, otherwise return nil
+func (this *EventFilter) MatchNotify(txHash string, notify *sdkcom.NotifyEventInfo) *DecodedNotify {
+	if len(this.txHashes) > 0 && !this.txHashes[strings.ToLower(txHash)] {
+		return nil
+	}
+	if len(this.contracts) > 0 && !this.contracts[strings.ToLower(notify.ContractAddress)] {
+		return nil
+	}
+	decoded := DecodeNotify(notify)
+	if len(this.eventNames) > 0 && !this.eventNames[decoded.EventName] {
+		return nil
+	}
+	if len(this.from) > 0 && !this.from[decoded.From] {
+		return nil
+	}
+	if len(this.to) > 0 && !this.to[decoded.To] {
+		return nil
+	}
+	if this.minAmount != nil && (decoded.Amount == nil || decoded.Amount.Cmp(this.minAmount) < 0) {
+		return nil
+	}
+	return decoded
+}
+
+//FilterEvent return matched notifies of event. Filter may be nil, which matches everything.
+func (this *EventFilter) FilterEvent(event *sdkcom.SmartContactEvent, height uint32) []*FilteredNotify {
+	result := make([]*FilteredNotify, 0)
+	if event == nil {
+		return result
+	}
+	if this != nil && len(this.txHashes) > 0 && !this.txHashes[strings.ToLower(event.TxHash)] {
+		return result
+	}
+	for index, notify := range event.Notify {
+		var decoded *DecodedNotify
+		if this == nil {
+			decoded = DecodeNotify(notify)
+		} else {
+			decoded = this.MatchNotify(event.TxHash, notify)
+			if decoded == nil {
+				continue
+			}
+		}
+		result = append(result, &FilteredNotify{
+			TxHash:      event.TxHash,
+			Height:      height,
+			State:       event.State,
+			GasConsumed: event.GasConsumed,
+			NotifyIndex: index,
+			Notify:      notify,
+			Decoded:     decoded,
+		})
+	}
+	return result
+}
+
+//FilterEvents return matched notifies of all events of block
+func (this *EventFilter) FilterEvents(events []*sdkcom.SmartContactEvent, height uint32) []*FilteredNotify {
+	result := make([]*FilteredNotify, 0)
+	for _, event := range events {
+		result = append(result, this.FilterEvent(event, height)...)
+	}
+	return result
+}
+
+//GetFilteredEventByBlock return notifies of block matched by filter
+func (this *ClientMgr) GetFilteredEventByBlock(height uint32, filter *EventFilter) ([]*FilteredNotify, error) {
+	events, err := this.GetSmartContractEventByBlock(height)
+	if err != nil {
+		return nil, err
+	}
+	return filter.FilterEvents(events, height), nil
+}
+
+//GetFilteredEventByTxHash return notifies of transaction matched by filter
+func (this *ClientMgr) GetFilteredEventByTxHash(txHash string, filter *EventFilter) ([]*FilteredNotify, error) {
+	event, err := this.GetSmartContractEvent(txHash)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, fmt.Errorf("event of tx:%s not found", txHash)
+	}
+	height, err := this.GetBlockHeightByTxHash(txHash)
+	if err != nil {
+		return nil, err
+	}
+	return filter.FilterEvent(event, height), nil
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	sdkcom "github.com/DNAProject/DNA-go-sdk/common"
+	"github.com/DNAProject/DNA/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventFilter(t *testing.T) {
+	from := common.Address{1}
+	to := common.Address{2}
+	event := &sdkcom.SmartContactEvent{
+		TxHash: "AB",
+		State:  1,
+		Notify: []*sdkcom.NotifyEventInfo{
+			{
+				ContractAddress: "0200000000000000000000000000000000000000",
+				States:          []interface{}{"transfer", from.ToBase58(), to.ToBase58(), uint64(100)},
+			},
+			{
+				ContractAddress: "FF00000000000000000000000000000000000000",
+				States: []interface{}{hex.EncodeToString([]byte("transfer")), from.ToHexString(), to.ToHexString(),
+					hex.EncodeToString(common.BigIntToNeoBytes(big.NewInt(10)))},
+			},
+		},
+	}
+	decoded := DecodeNotify(event.Notify[1])
+	assert.Equal(t, "transfer", decoded.EventName)
+	assert.Equal(t, from.ToBase58(), decoded.From)
+	assert.Equal(t, to.ToBase58(), decoded.To)
+	assert.Equal(t, big.NewInt(10), decoded.Amount)
+
+	var filter *EventFilter
+	assert.Equal(t, 2, len(filter.FilterEvent(event, 1)))
+	notifies := NewEventFilter().EventName("transfer").From(from.ToBase58()).MinAmount(big.NewInt(50)).FilterEvent(event, 1)
+	assert.Equal(t, 1, len(notifies))
+	assert.Equal(t, uint32(1), notifies[0].Height)
+	assert.Equal(t, 0, notifies[0].NotifyIndex)
+	notifies = NewEventFilter().Contract("ff00000000000000000000000000000000000000").TxHash("ab").FilterEvent(event, 1)
+	assert.Equal(t, 1, len(notifies))
+	assert.Equal(t, 1, notifies[0].NotifyIndex)
+	assert.Equal(t, 0, len(NewEventFilter().To(from.ToBase58()).FilterEvent(event, 1)))
+}
@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+//ClientRequest is a request to node, which is seen by interceptors
+type ClientRequest struct {
+	Transport string      //TRANSPORT_RPC, TRANSPORT_REST or TRANSPORT_WS
+	Method    string      //Rpc method, rest path or web socket action
+	Params    interface{} //[]interface{} of rpc, *RestParams of rest, map[string]interface{} of web socket
+	Qid       string
+}
+
+//RestParams is the params of rest request
+type RestParams struct {
+	Values *url.Values
+	Data   []byte //Posted data, nil for get request
+}
+
+//ClientResult is the result of request to node
+type ClientResult struct {
+	Data     []byte //Result of response
+	Err      error
+	Duration time.Duration
+}
+
+//RequestHandler send request to node, and return the result of response
+type RequestHandler func(req *ClientRequest) ([]byte, error)
+
+//Interceptor wrap request to node. It can modify req, inspect the result of next, or return without calling next.
+//Params of req may be modified, but cannot be replaced by another type.
+type Interceptor func(req *ClientRequest, next RequestHandler) ([]byte, error)
+
+//NewObserverInterceptor return an interceptor which calls observe after each request completed
+func NewObserverInterceptor(observe func(req *ClientRequest, result *ClientResult)) Interceptor {
+	return func(req *ClientRequest, next RequestHandler) ([]byte, error) {
+		start := time.Now()
+		data, err := next(req)
+		observe(req, &ClientResult{Data: data, Err: err, Duration: time.Since(start)})
+		return data, err
+	}
+}
+
+//InterceptorChain is the interceptors shared by rpc, rest and web socket client of ClientMgr.
+//The first added interceptor is the outermost.
+type InterceptorChain struct {
+	interceptors []Interceptor
+	lock         sync.RWMutex
+}
+
+func (this *InterceptorChain) Add(interceptors ...Interceptor) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	chain := make([]Interceptor, 0, len(this.interceptors)+len(interceptors))
+	chain = append(chain, this.interceptors...)
+	this.interceptors = append(chain, interceptors...)
+}
+
+func (this *InterceptorChain) Clear() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.interceptors = nil
+}
+
+//invoke pass req through interceptors to handler. Chain may be nil.
+func (this *InterceptorChain) invoke(req *ClientRequest, handler RequestHandler) ([]byte, error) {
+	if this == nil {
+		return handler(req)
+	}
+	this.lock.RLock()
+	interceptors := this.interceptors
+	this.lock.RUnlock()
+	next := handler
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, inner := interceptors[i], next
+		next = func(req *ClientRequest) ([]byte, error) {
+			return interceptor(req, inner)
+		}
+	}
+	return next(req)
+}
+
+func invalidParamsType(req *ClientRequest) error {
+	return fmt.Errorf("invalid params type:%T of %s request method:%s", req.Params, req.Transport, req.Method)
+}
+
+//getInterceptors return the chain shared by clients, which is created on first use.
+//Like EnableCache, clients and interceptors should be set up before ClientMgr is used concurrently.
+func (this *ClientMgr) getInterceptors() *InterceptorChain {
+	if this.interceptors == nil {
+		this.interceptors = &InterceptorChain{}
+	}
+	return this.interceptors
+}
+
+//AddInterceptor add interceptors to requests of rpc, rest and web socket client
+func (this *ClientMgr) AddInterceptor(interceptors ...Interceptor) {
+	this.getInterceptors().Add(interceptors...)
+}
+
+//ClearInterceptors remove all of interceptors
+func (this *ClientMgr) ClearInterceptors() {
+	this.getInterceptors().Clear()
+}
+
+func (this *ClientMgr) setInterceptors(client DNAClient) {
+	interceptors := this.getInterceptors()
+	switch c := client.(type) {
+	case *RpcClient:
+		c.interceptors = interceptors
+	case *RestClient:
+		c.interceptors = interceptors
+	case *WSClient:
+		c.setInterceptors(interceptors)
+	}
+}
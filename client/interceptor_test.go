@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterceptor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &JsonRpcRequest{}
+		json.NewDecoder(r.Body).Decode(req)
+		rsp := &JsonRpcResponse{Id: req.Id, Result: json.RawMessage(`10`)}
+		if req.Method != RPC_GET_BLOCK_COUNT {
+			rsp.Error, rsp.Desc = ERR_CODE_INVALID_METHOD, "INVALID METHOD"
+		}
+		json.NewEncoder(w).Encode(rsp)
+	}))
+	defer server.Close()
+
+	mgr := &ClientMgr{}
+	mgr.NewRpcClient().SetAddress(server.URL)
+	order := make([]string, 0)
+	metrics := NewRequestMetrics()
+	logs := &bytes.Buffer{}
+	mgr.AddInterceptor(func(req *ClientRequest, next RequestHandler) ([]byte, error) {
+		order = append(order, "outer:"+req.Method)
+		return next(req)
+	}, metrics.Interceptor(), NewLoggingInterceptor(logs, nil))
+	mgr.AddInterceptor(func(req *ClientRequest, next RequestHandler) ([]byte, error) {
+		order = append(order, "inner:"+req.Method)
+		if req.Method == RPC_GET_VERSION {
+			return json.Marshal("cached")
+		}
+		return next(req)
+	})
+
+	height, err := mgr.GetCurrentBlockHeight()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(9), height)
+	version, err := mgr.GetVersion()
+	assert.Nil(t, err)
+	assert.Equal(t, "cached", version)
+	_, err = mgr.GetNetworkId()
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"outer:getblockcount", "inner:getblockcount", "outer:getversion", "inner:getversion",
+		"outer:getnetworkid", "inner:getnetworkid"}, order)
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	assert.Equal(t, 3, len(lines))
+	entry := &RequestLogEntry{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[2]), entry))
+	assert.Equal(t, RPC_GET_NETWORK_ID, entry.Method)
+	assert.Equal(t, int64(ERR_CODE_INVALID_METHOD), entry.ErrorCode)
+
+	out := &bytes.Buffer{}
+	assert.Nil(t, metrics.WritePrometheus(out))
+	assert.Contains(t, out.String(), `dna_sdk_requests_total{transport="rpc",method="getversion"} 1`)
+	assert.Contains(t, out.String(), `dna_sdk_request_errors_total{transport="rpc",method="getnetworkid",kind="node"} 1`)
+	assert.Contains(t, out.String(), `dna_sdk_request_duration_seconds_count{transport="rpc",method="getblockcount"} 1`)
+}
@@ -0,0 +1,211 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	sdkcom "github.com/DNAProject/DNA-go-sdk/common"
+	"github.com/DNAProject/DNA/common"
+	"github.com/DNAProject/DNA/core/types"
+)
+
+//MerkleProofFailure is the reason of merkle proof verification failed
+type MerkleProofFailure int
+
+const (
+	MERKLE_PROOF_OK                  MerkleProofFailure = iota
+	MERKLE_PROOF_INVALID_FORMAT                         //Hash in proof cannot be decoded
+	MERKLE_PROOF_HEIGHT_MISMATCH                        //Height of proof doesn't match the trusted header
+	MERKLE_PROOF_BLOCK_ROOT_MISMATCH                    //CurBlockRoot of proof doesn't match BlockRoot of the trusted header
+	MERKLE_PROOF_TOO_SHORT                              //Count of target hashes is less than the path length
+	MERKLE_PROOF_TOO_LONG                               //Count of target hashes is more than the path length
+	MERKLE_PROOF_ROOT_MISMATCH                          //Root computed from audit path doesn't match the block root
+	MERKLE_PROOF_TX_NOT_IN_BLOCK                        //Transaction is not included by TransactionsRoot of proof
+)
+
+func (this MerkleProofFailure) String() string {
+	switch this {
+	case MERKLE_PROOF_OK:
+		return "ok"
+	case MERKLE_PROOF_INVALID_FORMAT:
+		return "invalid format"
+	case MERKLE_PROOF_HEIGHT_MISMATCH:
+		return "height mismatch"
+	case MERKLE_PROOF_BLOCK_ROOT_MISMATCH:
+		return "block root mismatch"
+	case MERKLE_PROOF_TOO_SHORT:
+		return "proof too short"
+	case MERKLE_PROOF_TOO_LONG:
+		return "proof too long"
+	case MERKLE_PROOF_ROOT_MISMATCH:
+		return "root mismatch"
+	case MERKLE_PROOF_TX_NOT_IN_BLOCK:
+		return "tx not in block"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(this))
+	}
+}
+
+//MerkleProofResult is the result of merkle proof verification.
+//If Valid is false, Failure and Reason explain which check failed, and ExpectedRoot and ComputedRoot are set for root mismatch.
+type MerkleProofResult struct {
+	Valid        bool
+	Failure      MerkleProofFailure
+	Reason       string
+	ExpectedRoot string
+	ComputedRoot string
+}
+
+func merkleProofFailed(failure MerkleProofFailure, format string, args ...interface{}) *MerkleProofResult {
+	return &MerkleProofResult{
+		Failure: failure,
+		Reason:  fmt.Sprintf(format, args...),
+	}
+}
+
+//VerifyMerkleProof verify that TransactionsRoot of block at proof.BlockHeight is in the block root merkle tree,
+//whose root is BlockRoot of trustedHeader at proof.CurBlockHeight. TrustedHeader should be obtained independently,
+//not from the node giving the proof.
+func VerifyMerkleProof(proof *sdkcom.MerkleProof, trustedHeader *types.Header) *MerkleProofResult {
+	if proof == nil || trustedHeader == nil {
+		return merkleProofFailed(MERKLE_PROOF_INVALID_FORMAT, "proof or trusted header is nil")
+	}
+	if proof.CurBlockHeight != trustedHeader.Height {
+		return merkleProofFailed(MERKLE_PROOF_HEIGHT_MISMATCH, "proof is of height:%d, trusted header is of height:%d",
+			proof.CurBlockHeight, trustedHeader.Height)
+	}
+	if proof.BlockHeight > proof.CurBlockHeight {
+		return merkleProofFailed(MERKLE_PROOF_HEIGHT_MISMATCH, "block height:%d is larger than current block height:%d",
+			proof.BlockHeight, proof.CurBlockHeight)
+	}
+	curBlockRoot, err := common.Uint256FromHexString(proof.CurBlockRoot)
+	if err != nil {
+		return merkleProofFailed(MERKLE_PROOF_INVALID_FORMAT, "invalid CurBlockRoot:%s error:%s", proof.CurBlockRoot, err)
+	}
+	if curBlockRoot != trustedHeader.BlockRoot {
+		result := merkleProofFailed(MERKLE_PROOF_BLOCK_ROOT_MISMATCH, "CurBlockRoot of proof doesn't match BlockRoot of trusted header")
+		result.ExpectedRoot = trustedHeader.BlockRoot.ToHexString()
+		result.ComputedRoot = proof.CurBlockRoot
+		return result
+	}
+	txRoot, err := common.Uint256FromHexString(proof.TransactionsRoot)
+	if err != nil {
+		return merkleProofFailed(MERKLE_PROOF_INVALID_FORMAT, "invalid TransactionsRoot:%s error:%s", proof.TransactionsRoot, err)
+	}
+	path := make([]common.Uint256, 0, len(proof.TargetHashes))
+	for i, hash := range proof.TargetHashes {
+		targetHash, err := common.Uint256FromHexString(hash)
+		if err != nil {
+			return merkleProofFailed(MERKLE_PROOF_INVALID_FORMAT, "invalid TargetHashes[%d]:%s error:%s", i, hash, err)
+		}
+		path = append(path, targetHash)
+	}
+	//The block root tree contains TransactionsRoot of blocks [0, CurBlockHeight]
+	root, failure, reason := computeMerkleRootFromPath(txRoot, proof.BlockHeight, path, proof.CurBlockHeight+1)
+	if failure != MERKLE_PROOF_OK {
+		return merkleProofFailed(failure, reason)
+	}
+	if root != trustedHeader.BlockRoot {
+		result := merkleProofFailed(MERKLE_PROOF_ROOT_MISMATCH, "root computed from TargetHashes doesn't match BlockRoot of trusted header")
+		result.ExpectedRoot = trustedHeader.BlockRoot.ToHexString()
+		result.ComputedRoot = root.ToHexString()
+		return result
+	}
+	return &MerkleProofResult{Valid: true}
+}
+
+//VerifyTxInBlock verify that txHash is one of txHashes, and txHashes match TransactionsRoot of proof
+func VerifyTxInBlock(proof *sdkcom.MerkleProof, txHash common.Uint256, txHashes []common.Uint256) *MerkleProofResult {
+	found := false
+	for _, hash := range txHashes {
+		if hash == txHash {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return merkleProofFailed(MERKLE_PROOF_TX_NOT_IN_BLOCK, "tx:%s is not in block:%d", txHash.ToHexString(), proof.BlockHeight)
+	}
+	txRoot, err := common.Uint256FromHexString(proof.TransactionsRoot)
+	if err != nil {
+		return merkleProofFailed(MERKLE_PROOF_INVALID_FORMAT, "invalid TransactionsRoot:%s error:%s", proof.TransactionsRoot, err)
+	}
+	root := common.ComputeMerkleRoot(txHashes)
+	if root != txRoot {
+		result := merkleProofFailed(MERKLE_PROOF_TX_NOT_IN_BLOCK, "root of tx hashes of block:%d doesn't match TransactionsRoot of proof", proof.BlockHeight)
+		result.ExpectedRoot = proof.TransactionsRoot
+		result.ComputedRoot = root.ToHexString()
+		return result
+	}
+	return &MerkleProofResult{Valid: true}
+}
+
+//computeMerkleRootFromPath compute root of merkle tree of treeSize from leaf at index and audit path
+func computeMerkleRootFromPath(leaf common.Uint256, index uint32, path []common.Uint256, treeSize uint32) (common.Uint256, MerkleProofFailure, string) {
+	hash := leaf
+	nodeIndex := index
+	lastNode := treeSize - 1
+	pos := 0
+	for lastNode > 0 {
+		if nodeIndex%2 == 1 {
+			if pos >= len(path) {
+				return common.UINT256_EMPTY, MERKLE_PROOF_TOO_SHORT, fmt.Sprintf("proof has %d hashes, need more", len(path))
+			}
+			hash = hashMerkleChildren(path[pos], hash)
+			pos++
+		} else if nodeIndex < lastNode {
+			if pos >= len(path) {
+				return common.UINT256_EMPTY, MERKLE_PROOF_TOO_SHORT, fmt.Sprintf("proof has %d hashes, need more", len(path))
+			}
+			hash = hashMerkleChildren(hash, path[pos])
+			pos++
+		}
+		nodeIndex /= 2
+		lastNode /= 2
+	}
+	if pos < len(path) {
+		return common.UINT256_EMPTY, MERKLE_PROOF_TOO_LONG, fmt.Sprintf("proof has %d hashes, only %d used", len(path), pos)
+	}
+	return hash, MERKLE_PROOF_OK, ""
+}
+
+func hashMerkleChildren(left, right common.Uint256) common.Uint256 {
+	data := make([]byte, 0, 1+common.UINT256_SIZE*2)
+	data = append(data, 1)
+	data = append(data, left[:]...)
+	data = append(data, right[:]...)
+	return sha256.Sum256(data)
+}
+
+//VerifyTxMerkleProof get merkle proof and block of tx from node, and verify them against the trusted header
+//returned by getTrustedHeader, which should not come from the same node.
+func (this *ClientMgr) VerifyTxMerkleProof(txHash string, getTrustedHeader func(height uint32) (*types.Header, error)) (*MerkleProofResult, error) {
+	hash, err := common.Uint256FromHexString(txHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tx hash:%s error:%s", txHash, err)
+	}
+	proof, err := this.GetMerkleProof(txHash)
+	if err != nil {
+		return nil, fmt.Errorf("GetMerkleProof error:%s", err)
+	}
+	trustedHeader, err := getTrustedHeader(proof.CurBlockHeight)
+	if err != nil {
+		return nil, fmt.Errorf("get trusted header:%d error:%s", proof.CurBlockHeight, err)
+	}
+	result := VerifyMerkleProof(proof, trustedHeader)
+	if !result.Valid {
+		return result, nil
+	}
+	block, err := this.GetBlockByHeight(proof.BlockHeight)
+	if err != nil {
+		return nil, fmt.Errorf("GetBlockByHeight error:%s", err)
+	}
+	txHashes := make([]common.Uint256, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		txHashes = append(txHashes, tx.Hash())
+	}
+	return VerifyTxInBlock(proof, hash, txHashes), nil
+}
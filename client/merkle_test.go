@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"testing"
+
+	sdkcom "github.com/DNAProject/DNA-go-sdk/common"
+	"github.com/DNAProject/DNA/common"
+	"github.com/DNAProject/DNA/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyMerkleProof(t *testing.T) {
+	a, b, c := common.Uint256{1}, common.Uint256{2}, common.Uint256{3}
+	ab := hashMerkleChildren(a, b)
+	root := hashMerkleChildren(ab, c)
+	header := &types.Header{Height: 2, BlockRoot: root}
+	newProof := func(leaf common.Uint256, height uint32, path ...common.Uint256) *sdkcom.MerkleProof {
+		targetHashes := make([]string, 0, len(path))
+		for _, hash := range path {
+			targetHashes = append(targetHashes, hash.ToHexString())
+		}
+		return &sdkcom.MerkleProof{
+			TransactionsRoot: leaf.ToHexString(),
+			BlockHeight:      height,
+			CurBlockRoot:     root.ToHexString(),
+			CurBlockHeight:   2,
+			TargetHashes:     targetHashes,
+		}
+	}
+
+	assert.True(t, VerifyMerkleProof(newProof(a, 0, b, c), header).Valid)
+	assert.True(t, VerifyMerkleProof(newProof(c, 2, ab), header).Valid)
+	assert.Equal(t, MERKLE_PROOF_TOO_SHORT, VerifyMerkleProof(newProof(a, 0, b), header).Failure)
+	assert.Equal(t, MERKLE_PROOF_TOO_LONG, VerifyMerkleProof(newProof(c, 2, ab, a), header).Failure)
+	result := VerifyMerkleProof(newProof(b, 0, b, c), header)
+	assert.Equal(t, MERKLE_PROOF_ROOT_MISMATCH, result.Failure)
+	assert.Equal(t, root.ToHexString(), result.ExpectedRoot)
+	assert.Equal(t, MERKLE_PROOF_HEIGHT_MISMATCH, VerifyMerkleProof(newProof(a, 0, b, c), &types.Header{Height: 3, BlockRoot: root}).Failure)
+	assert.Equal(t, MERKLE_PROOF_BLOCK_ROOT_MISMATCH, VerifyMerkleProof(newProof(a, 0, b, c), &types.Header{Height: 2, BlockRoot: ab}).Failure)
+
+	txHashes := []common.Uint256{a, b, c}
+	proof := newProof(common.ComputeMerkleRoot(txHashes), 0)
+	assert.True(t, VerifyTxInBlock(proof, b, txHashes).Valid)
+	assert.Equal(t, MERKLE_PROOF_TX_NOT_IN_BLOCK, VerifyTxInBlock(proof, ab, txHashes).Failure)
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+//RequestLogEntry is the structured log of a request to node
+type RequestLogEntry struct {
+	Time         time.Time   `json:"time"`
+	Transport    string      `json:"transport"`
+	Method       string      `json:"method"`
+	Qid          string      `json:"qid"`
+	Params       interface{} `json:"params,omitempty"`
+	DurationMs   float64     `json:"duration_ms"`
+	ResponseSize int         `json:"response_size"`
+	ErrorCode    int64       `json:"error_code,omitempty"` //Error code of NodeError
+	Error        string      `json:"error,omitempty"`
+}
+
+//RequestLogConfig config of logging interceptor
+type RequestLogConfig struct {
+	WithParams bool //Log params of request, which may be large, such as raw transaction
+	ErrorsOnly bool //Only log failed requests
+}
+
+//NewLoggingInterceptor return an interceptor which write each request as a json line to w.
+//If w is nil, os.Stderr is used. If config is nil, params are not logged.
+func NewLoggingInterceptor(w io.Writer, config *RequestLogConfig) Interceptor {
+	if w == nil {
+		w = os.Stderr
+	}
+	if config == nil {
+		config = &RequestLogConfig{}
+	}
+	lock := &sync.Mutex{}
+	encoder := json.NewEncoder(w)
+	return NewObserverInterceptor(func(req *ClientRequest, result *ClientResult) {
+		if config.ErrorsOnly && result.Err == nil {
+			return
+		}
+		entry := &RequestLogEntry{
+			Time:         time.Now(),
+			Transport:    req.Transport,
+			Method:       req.Method,
+			Qid:          req.Qid,
+			DurationMs:   float64(result.Duration) / float64(time.Millisecond),
+			ResponseSize: len(result.Data),
+		}
+		if config.WithParams {
+			entry.Params = req.Params
+		}
+		if result.Err != nil {
+			entry.Error = result.Err.Error()
+			if nodeErr := GetNodeError(result.Err); nodeErr != nil {
+				entry.ErrorCode = nodeErr.Code
+			}
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		encoder.Encode(entry)
+	})
+}
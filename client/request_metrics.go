@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//DEFAULT_METRICS_BUCKETS is the upper bounds in second of request duration histogram
+var DEFAULT_METRICS_BUCKETS = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+const METRICS_NAMESPACE = "dna_sdk"
+
+//Kind of failed request
+const (
+	REQUEST_ERROR_NODE      = "node"
+	REQUEST_ERROR_TRANSPORT = "transport"
+	REQUEST_ERROR_OTHER     = "other"
+)
+
+type metricsKey struct {
+	transport string
+	method    string
+}
+
+type metricsSeries struct {
+	count        uint64
+	errors       map[string]uint64 //By kind of error
+	durationSum  float64
+	bucketCounts []uint64
+	responseSize uint64
+}
+
+//RequestMetrics collect count, errors, duration and response size of requests to node by transport and method,
+//and export them in prometheus text format without depending on prometheus client.
+type RequestMetrics struct {
+	buckets []float64
+	series  map[metricsKey]*metricsSeries
+	lock    sync.Mutex
+}
+
+//NewRequestMetrics return RequestMetrics with buckets of duration histogram, DEFAULT_METRICS_BUCKETS is used if buckets is empty
+func NewRequestMetrics(buckets ...float64) *RequestMetrics {
+	if len(buckets) == 0 {
+		buckets = DEFAULT_METRICS_BUCKETS
+	}
+	sorted := make([]float64, len(buckets))
+	copy(sorted, buckets)
+	sort.Float64s(sorted)
+	return &RequestMetrics{
+		buckets: sorted,
+		series:  make(map[metricsKey]*metricsSeries),
+	}
+}
+
+//Interceptor return the interceptor which records requests into metrics
+func (this *RequestMetrics) Interceptor() Interceptor {
+	return NewObserverInterceptor(this.observe)
+}
+
+func (this *RequestMetrics) observe(req *ClientRequest, result *ClientResult) {
+	key := metricsKey{transport: req.Transport, method: req.Method}
+	seconds := result.Duration.Seconds()
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	series, ok := this.series[key]
+	if !ok {
+		series = &metricsSeries{
+			errors:       make(map[string]uint64),
+			bucketCounts: make([]uint64, len(this.buckets)),
+		}
+		this.series[key] = series
+	}
+	series.count++
+	series.durationSum += seconds
+	series.responseSize += uint64(len(result.Data))
+	for i, bound := range this.buckets {
+		if seconds <= bound {
+			series.bucketCounts[i]++
+		}
+	}
+	if result.Err != nil {
+		series.errors[requestErrorKind(result.Err)]++
+	}
+}
+
+func requestErrorKind(err error) string {
+	switch {
+	case GetNodeError(err) != nil:
+		return REQUEST_ERROR_NODE
+	case IsTransportError(err):
+		return REQUEST_ERROR_TRANSPORT
+	default:
+		return REQUEST_ERROR_OTHER
+	}
+}
+
+//Reset clear all of collected metrics
+func (this *RequestMetrics) Reset() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.series = make(map[metricsKey]*metricsSeries)
+}
+
+//WritePrometheus write metrics to w in prometheus text exposition format
+func (this *RequestMetrics) WritePrometheus(w io.Writer) error {
+	this.lock.Lock()
+	keys := make([]metricsKey, 0, len(this.series))
+	for key := range this.series {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].transport != keys[j].transport {
+			return keys[i].transport < keys[j].transport
+		}
+		return keys[i].method < keys[j].method
+	})
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "# HELP %s_requests_total Total requests to node.\n", METRICS_NAMESPACE)
+	fmt.Fprintf(buf, "# TYPE %s_requests_total counter\n", METRICS_NAMESPACE)
+	for _, key := range keys {
+		fmt.Fprintf(buf, "%s_requests_total{%s} %d\n", METRICS_NAMESPACE, key.labels(), this.series[key].count)
+	}
+	fmt.Fprintf(buf, "# HELP %s_request_errors_total Failed requests to node by kind of error.\n", METRICS_NAMESPACE)
+	fmt.Fprintf(buf, "# TYPE %s_request_errors_total counter\n", METRICS_NAMESPACE)
+	for _, key := range keys {
+		for _, kind := range []string{REQUEST_ERROR_NODE, REQUEST_ERROR_TRANSPORT, REQUEST_ERROR_OTHER} {
+			count, ok := this.series[key].errors[kind]
+			if ok {
+				fmt.Fprintf(buf, "%s_request_errors_total{%s,kind=%q} %d\n", METRICS_NAMESPACE, key.labels(), kind, count)
+			}
+		}
+	}
+	fmt.Fprintf(buf, "# HELP %s_response_bytes_total Total bytes of response results.\n", METRICS_NAMESPACE)
+	fmt.Fprintf(buf, "# TYPE %s_response_bytes_total counter\n", METRICS_NAMESPACE)
+	for _, key := range keys {
+		fmt.Fprintf(buf, "%s_response_bytes_total{%s} %d\n", METRICS_NAMESPACE, key.labels(), this.series[key].responseSize)
+	}
+	fmt.Fprintf(buf, "# HELP %s_request_duration_seconds Duration of requests to node.\n", METRICS_NAMESPACE)
+	fmt.Fprintf(buf, "# TYPE %s_request_duration_seconds histogram\n", METRICS_NAMESPACE)
+	for _, key := range keys {
+		series := this.series[key]
+		for i, bound := range this.buckets {
+			fmt.Fprintf(buf, "%s_request_duration_seconds_bucket{%s,le=%q} %d\n", METRICS_NAMESPACE, key.labels(),
+				strconv.FormatFloat(bound, 'g', -1, 64), series.bucketCounts[i])
+		}
+		fmt.Fprintf(buf, "%s_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", METRICS_NAMESPACE, key.labels(), series.count)
+		fmt.Fprintf(buf, "%s_request_duration_seconds_sum{%s} %s\n", METRICS_NAMESPACE, key.labels(),
+			strconv.FormatFloat(series.durationSum, 'g', -1, 64))
+		fmt.Fprintf(buf, "%s_request_duration_seconds_count{%s} %d\n", METRICS_NAMESPACE, key.labels(), series.count)
+	}
+	this.lock.Unlock()
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+//ServeHTTP serve metrics as prometheus scrape endpoint
+func (this *RequestMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	this.WritePrometheus(w)
+}
+
+func (this metricsKey) labels() string {
+	return fmt.Sprintf("transport=%q,method=%q", strings.ToLower(this.transport), this.method)
+}
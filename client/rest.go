@@ -39,8 +39,9 @@ import (
 
 //RpcClient for dna rpc api
 type RestClient struct {
-	addr       string
-	httpClient *http.Client
+	addr         string
+	httpClient   *http.Client
+	interceptors *InterceptorChain
 }
 
 //NewRpcClient return RpcClient instance
@@ -72,38 +73,38 @@ func (this *RestClient) SetHttpClient(httpClient *http.Client) *RestClient {
 
 func (this *RestClient) getVersion(qid string) ([]byte, error) {
 	reqPath := GET_VERSION
-	return this.sendRestGetRequest(reqPath)
+	return this.sendRestGetRequest(qid, reqPath)
 }
 
 func (this *RestClient) getNetworkId(qid string) ([]byte, error) {
 	reqPath := GET_NETWORK_ID
-	return this.sendRestGetRequest(reqPath)
+	return this.sendRestGetRequest(qid, reqPath)
 }
 
 func (this *RestClient) getBlockByHash(qid, hash string) ([]byte, error) {
 	reqPath := GET_BLK_BY_HASH + hash
 	reqValues := &url.Values{}
 	reqValues.Add("raw", "1")
-	return this.sendRestGetRequest(reqPath, reqValues)
+	return this.sendRestGetRequest(qid, reqPath, reqValues)
 }
 
 func (this *RestClient) getBlockByHeight(qid string, height uint32) ([]byte, error) {
 	reqPath := fmt.Sprintf("%s%d", GET_BLK_BY_HEIGHT, height)
 	reqValues := &url.Values{}
 	reqValues.Add("raw", "1")
-	return this.sendRestGetRequest(reqPath, reqValues)
+	return this.sendRestGetRequest(qid, reqPath, reqValues)
 }
 
 func (this *RestClient) getBlockInfoByHeight(qid string, height uint32) ([]byte, error) {
 	reqPath := fmt.Sprintf("%s%d", GET_BLK_BY_HEIGHT, height)
 	reqValues := &url.Values{}
 	reqValues.Add("raw", "0")
-	return this.sendRestGetRequest(reqPath, reqValues)
+	return this.sendRestGetRequest(qid, reqPath, reqValues)
 }
 
 func (this *RestClient) getCurrentBlockHeight(qid string) ([]byte, error) {
 	reqPath := GET_BLK_HEIGHT
-	return this.sendRestGetRequest(reqPath)
+	return this.sendRestGetRequest(qid, reqPath)
 }
 
 func (this *RestClient) getCurrentBlockHash(qid string) ([]byte, error) {
@@ -120,7 +121,7 @@ func (this *RestClient) getCurrentBlockHash(qid string) ([]byte, error) {
 
 func (this *RestClient) getBlockHash(qid string, height uint32) ([]byte, error) {
 	reqPath := fmt.Sprintf("%s%d", GET_BLK_HASH, height)
-	return this.sendRestGetRequest(reqPath)
+	return this.sendRestGetRequest(qid, reqPath)
 }
 
 //GetRawTransaction return transaction by transaction hash in hex string code
@@ -128,55 +129,75 @@ func (this *RestClient) getRawTransaction(qid, txHash string) ([]byte, error) {
 	reqPath := GET_TX + txHash
 	reqValues := &url.Values{}
 	reqValues.Add("raw", "1")
-	return this.sendRestGetRequest(reqPath, reqValues)
+	return this.sendRestGetRequest(qid, reqPath, reqValues)
 }
 
 func (this *RestClient) getStorage(qid, contractAddress string, key []byte) ([]byte, error) {
 	reqPath := GET_STORAGE + contractAddress + "/" + hex.EncodeToString(key)
-	return this.sendRestGetRequest(reqPath)
+	return this.sendRestGetRequest(qid, reqPath)
 }
 
 //GetSmartContractEvent return smart contract event execute by invoke transaction by hex string code
 func (this *RestClient) getSmartContractEvent(qid, txHash string) ([]byte, error) {
 	reqPath := GET_SMTCOCE_EVTS + txHash
-	return this.sendRestGetRequest(reqPath)
+	return this.sendRestGetRequest(qid, reqPath)
 }
 
 func (this *RestClient) getSmartContractEventByBlock(qid string, blockHeight uint32) ([]byte, error) {
 	reqPath := fmt.Sprintf("%s%d", GET_SMTCOCE_EVT_TXS, blockHeight)
-	return this.sendRestGetRequest(reqPath)
+	return this.sendRestGetRequest(qid, reqPath)
 }
 
 func (this *RestClient) getSmartContract(qid, contractAddress string) ([]byte, error) {
 	reqPath := GET_CONTRACT_STATE + contractAddress
 	reqValues := &url.Values{}
 	reqValues.Add("raw", "1")
-	return this.sendRestGetRequest(reqPath, reqValues)
+	return this.sendRestGetRequest(qid, reqPath, reqValues)
 }
 
 func (this RestClient) getMerkleProof(qid, txHash string) ([]byte, error) {
 	reqPath := GET_MERKLE_PROOF + txHash
-	return this.sendRestGetRequest(reqPath)
+	return this.sendRestGetRequest(qid, reqPath)
 }
 
 func (this *RestClient) getMemPoolTxState(qid, txHash string) ([]byte, error) {
 	reqPath := GET_MEMPOOL_TXSTATE + txHash
-	return this.sendRestGetRequest(reqPath)
+	return this.sendRestGetRequest(qid, reqPath)
 }
 
 func (this *RestClient) getMemPoolTxCount(qid string) ([]byte, error) {
 	reqPath := GET_MEMPOOL_TXCOUNT
-	return this.sendRestGetRequest(reqPath)
+	return this.sendRestGetRequest(qid, reqPath)
 }
 
 func (this *RestClient) getBlockHeightByTxHash(qid, txHash string) ([]byte, error) {
 	reqPath := GET_BLK_HGT_BY_TXHASH + txHash
-	return this.sendRestGetRequest(reqPath)
+	return this.sendRestGetRequest(qid, reqPath)
 }
 
 func (this *RestClient) getBlockTxHashesByHeight(qid string, height uint32) ([]byte, error) {
 	reqPath := fmt.Sprintf("%s%d", GET_BLK_TXS_BY_HEIGHT, height)
-	return this.sendRestGetRequest(reqPath)
+	return this.sendRestGetRequest(qid, reqPath)
+}
+
+func (this *RestClient) getBalance(qid, address string) ([]byte, error) {
+	reqPath := GET_BALANCE + address
+	return this.sendRestGetRequest(qid, reqPath)
+}
+
+func (this *RestClient) getGenerateBlockTime(qid string) ([]byte, error) {
+	reqPath := GET_GEN_BLK_TIME
+	return this.sendRestGetRequest(qid, reqPath)
+}
+
+//sendEmergencyGovReq is only supported by rpc
+func (this *RestClient) sendEmergencyGovReq(qid string, data []byte) ([]byte, error) {
+	return nil, &TransportError{Transport: TRANSPORT_REST, Method: SEND_EMERGENCY_GOV_REQ, Qid: qid, Err: ERR_METHOD_NOT_SUPPORTED}
+}
+
+//getBlockRootWithNewTxRoot is only supported by rpc
+func (this *RestClient) getBlockRootWithNewTxRoot(qid string, txRoot common.Uint256) ([]byte, error) {
+	return nil, &TransportError{Transport: TRANSPORT_REST, Method: GET_BLOCK_ROOT_WITH_NEW_TX_ROOT, Qid: qid, Err: ERR_METHOD_NOT_SUPPORTED}
 }
 
 func (this *RestClient) sendRawTransaction(qid string, tx *types.Transaction, isPreExec bool) ([]byte, error) {
@@ -188,7 +209,7 @@ func (this *RestClient) sendRawTransaction(qid string, tx *types.Transaction, is
 		reqValues = &url.Values{}
 		reqValues.Add("preExec", "1")
 	}
-	return this.sendRestPostRequest(sink.Bytes(), reqPath, reqValues)
+	return this.sendRestPostRequest(qid, sink.Bytes(), reqPath, reqValues)
 }
 
 func (this *RestClient) getAddress() (string, error) {
@@ -217,20 +238,56 @@ func (this *RestClient) getRequestUrl(reqPath string, values ...*url.Values) (st
 	return reqUrl.String(), nil
 }
 
-func (this *RestClient) sendRestGetRequest(reqPath string, values ...*url.Values) ([]byte, error) {
+//sendRestGetRequest send rest get request to dna through interceptors
+func (this *RestClient) sendRestGetRequest(qid, reqPath string, values ...*url.Values) ([]byte, error) {
+	params := &RestParams{}
+	if len(values) > 0 {
+		params.Values = values[0]
+	}
+	return this.sendRestRequest(qid, reqPath, params)
+}
+
+//sendRestPostRequest send rest post request to dna through interceptors
+func (this *RestClient) sendRestPostRequest(qid string, data []byte, reqPath string, values ...*url.Values) ([]byte, error) {
+	params := &RestParams{Data: data}
+	if len(values) > 0 {
+		params.Values = values[0]
+	}
+	return this.sendRestRequest(qid, reqPath, params)
+}
+
+func (this *RestClient) sendRestRequest(qid, reqPath string, params *RestParams) ([]byte, error) {
+	req := &ClientRequest{Transport: TRANSPORT_REST, Method: reqPath, Params: params, Qid: qid}
+	return this.interceptors.invoke(req, func(req *ClientRequest) ([]byte, error) {
+		params, ok := req.Params.(*RestParams)
+		if !ok {
+			return nil, invalidParamsType(req)
+		}
+		if params.Data == nil {
+			return this.doRestGetRequest(req.Qid, req.Method, params.Values)
+		}
+		return this.doRestPostRequest(req.Qid, params.Data, req.Method, params.Values)
+	})
+}
+
+func (this *RestClient) doRestGetRequest(qid, reqPath string, values ...*url.Values) ([]byte, error) {
 	reqUrl, err := this.getRequestUrl(reqPath, values...)
 	if err != nil {
 		return nil, err
 	}
 	resp, err := this.httpClient.Get(reqUrl)
 	if err != nil {
-		return nil, fmt.Errorf("send http get request error:%s", err)
+		return nil, &TransportError{Transport: TRANSPORT_REST, Method: reqPath, Qid: qid, Err: fmt.Errorf("send http get request error:%s", err)}
 	}
 	defer resp.Body.Close()
-	return this.dealRestResponse(resp.Body)
+	if resp.StatusCode == http.StatusNotFound {
+		//Path is not routed by node
+		return nil, &NodeError{Code: ERR_CODE_INVALID_METHOD, Desc: resp.Status, Transport: TRANSPORT_REST, Method: reqPath, Qid: qid}
+	}
+	return this.dealRestResponse(qid, reqPath, resp.Body)
 }
 
-func (this *RestClient) sendRestPostRequest(data []byte, reqPath string, values ...*url.Values) ([]byte, error) {
+func (this *RestClient) doRestPostRequest(qid string, data []byte, reqPath string, values ...*url.Values) ([]byte, error) {
 	reqUrl, err := this.getRequestUrl(reqPath, values...)
 	if err != nil {
 		return nil, err
@@ -246,24 +303,31 @@ func (this *RestClient) sendRestPostRequest(data []byte, reqPath string, values
 	}
 	resp, err := this.httpClient.Post(reqUrl, "application/json", bytes.NewReader(reqData))
 	if err != nil {
-		return nil, fmt.Errorf("send http post request error:%s", err)
+		return nil, &TransportError{Transport: TRANSPORT_REST, Method: reqPath, Qid: qid, Err: fmt.Errorf("send http post request error:%s", err)}
 	}
 	defer resp.Body.Close()
-	return this.dealRestResponse(resp.Body)
+	return this.dealRestResponse(qid, reqPath, resp.Body)
 }
 
-func (this *RestClient) dealRestResponse(body io.Reader) ([]byte, error) {
+func (this *RestClient) dealRestResponse(qid, reqPath string, body io.Reader) ([]byte, error) {
 	data, err := ioutil.ReadAll(body)
 	if err != nil {
-		return nil, fmt.Errorf("read http body error:%s", err)
+		return nil, &TransportError{Transport: TRANSPORT_REST, Method: reqPath, Qid: qid, Err: fmt.Errorf("read http body error:%s", err)}
 	}
 	restRsp := &RestfulResp{}
 	err = json.Unmarshal(data, restRsp)
 	if err != nil {
-		return nil, fmt.Errorf("json.Unmarshal RestfulResp:%s error:%s", body, err)
+		return nil, fmt.Errorf("json.Unmarshal RestfulResp:%s error:%s", data, err)
 	}
 	if restRsp.Error != 0 {
-		return nil, fmt.Errorf("sendRestRequest error code:%d desc:%s result:%s", restRsp.Error, restRsp.Desc, restRsp.Result)
+		return nil, &NodeError{
+			Code:      restRsp.Error,
+			Desc:      restRsp.Desc,
+			Result:    string(restRsp.Result),
+			Transport: TRANSPORT_REST,
+			Method:    reqPath,
+			Qid:       qid,
+		}
 	}
 	return restRsp.Result, nil
 }
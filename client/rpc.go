@@ -38,8 +38,9 @@ import (
 
 //RpcClient for dna rpc api
 type RpcClient struct {
-	addr       string
-	httpClient *http.Client
+	addr         string
+	httpClient   *http.Client
+	interceptors *InterceptorChain
 }
 
 //NewRpcClient return RpcClient instance
@@ -166,6 +167,22 @@ func (this *RpcClient) getBlockTxHashesByHeight(qid string, height uint32) ([]by
 	return this.sendRpcRequest(qid, RPC_GET_BLOCK_TX_HASH_BY_HEIGHT, []interface{}{height})
 }
 
+func (this *RpcClient) getBalance(qid, address string) ([]byte, error) {
+	return this.sendRpcRequest(qid, RPC_GET_ONT_BALANCE, []interface{}{address})
+}
+
+func (this *RpcClient) getGenerateBlockTime(qid string) ([]byte, error) {
+	return this.sendRpcRequest(qid, RPC_GET_GENERATE_BLOCK_TIME, []interface{}{})
+}
+
+func (this *RpcClient) sendEmergencyGovReq(qid string, data []byte) ([]byte, error) {
+	return this.sendRpcRequest(qid, SEND_EMERGENCY_GOV_REQ, []interface{}{hex.EncodeToString(data)})
+}
+
+func (this *RpcClient) getBlockRootWithNewTxRoot(qid string, txRoot common.Uint256) ([]byte, error) {
+	return this.sendRpcRequest(qid, GET_BLOCK_ROOT_WITH_NEW_TX_ROOT, []interface{}{txRoot.ToHexString()})
+}
+
 func (this *RpcClient) sendRawTransaction(qid string, tx *types.Transaction, isPreExec bool) ([]byte, error) {
 	sink := common.NewZeroCopySink(nil)
 	tx.Serialization(sink)
@@ -177,8 +194,19 @@ func (this *RpcClient) sendRawTransaction(qid string, tx *types.Transaction, isP
 	return this.sendRpcRequest(qid, RPC_SEND_TRANSACTION, params)
 }
 
-//sendRpcRequest send Rpc request to dna
+//sendRpcRequest send Rpc request to dna through interceptors
 func (this *RpcClient) sendRpcRequest(qid, method string, params []interface{}) ([]byte, error) {
+	req := &ClientRequest{Transport: TRANSPORT_RPC, Method: method, Params: params, Qid: qid}
+	return this.interceptors.invoke(req, func(req *ClientRequest) ([]byte, error) {
+		params, ok := req.Params.([]interface{})
+		if !ok {
+			return nil, invalidParamsType(req)
+		}
+		return this.doRpcRequest(req.Qid, req.Method, params)
+	})
+}
+
+func (this *RpcClient) doRpcRequest(qid, method string, params []interface{}) ([]byte, error) {
 	rpcReq := &JsonRpcRequest{
 		Version: JSON_RPC_VERSION,
 		Id:      qid,
@@ -191,13 +219,13 @@ func (this *RpcClient) sendRpcRequest(qid, method string, params []interface{})
 	}
 	resp, err := this.httpClient.Post(this.addr, "application/json", bytes.NewReader(data))
 	if err != nil {
-		return nil, fmt.Errorf("http post request:%s error:%s", data, err)
+		return nil, &TransportError{Transport: TRANSPORT_RPC, Method: method, Qid: qid, Err: fmt.Errorf("http post request:%s error:%s", data, err)}
 	}
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("read rpc response body error:%s", err)
+		return nil, &TransportError{Transport: TRANSPORT_RPC, Method: method, Qid: qid, Err: fmt.Errorf("read rpc response body error:%s", err)}
 	}
 	rpcRsp := &JsonRpcResponse{}
 	err = json.Unmarshal(body, rpcRsp)
@@ -205,7 +233,99 @@ func (this *RpcClient) sendRpcRequest(qid, method string, params []interface{})
 		return nil, fmt.Errorf("json.Unmarshal JsonRpcResponse:%s error:%s", body, err)
 	}
 	if rpcRsp.Error != 0 {
-		return nil, fmt.Errorf("JsonRpcResponse error code:%d desc:%s result:%s", rpcRsp.Error, rpcRsp.Desc, rpcRsp.Result)
+		return nil, &NodeError{
+			Code:      rpcRsp.Error,
+			Desc:      rpcRsp.Desc,
+			Result:    string(rpcRsp.Result),
+			Transport: TRANSPORT_RPC,
+			Method:    method,
+			Qid:       qid,
+		}
 	}
 	return rpcRsp.Result, nil
 }
+
+//sendRpcBatchRequest send JSON-RPC 2.0 batch request to dna. Results are returned in order of requests.
+//If node doesn't support batch request, requests will be sent one by one.
+func (this *RpcClient) sendRpcBatchRequest(reqs []*JsonRpcRequest) ([]*rpcBatchResult, error) {
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+	//Interceptors see the whole batch as one request of RPC_BATCH, params are []*JsonRpcRequest
+	req := &ClientRequest{Transport: TRANSPORT_RPC, Method: RPC_BATCH, Params: reqs, Qid: reqs[0].Id}
+	body, err := this.interceptors.invoke(req, func(req *ClientRequest) ([]byte, error) {
+		reqs, ok := req.Params.([]*JsonRpcRequest)
+		if !ok {
+			return nil, invalidParamsType(req)
+		}
+		return this.doRpcBatchRequest(req.Qid, reqs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	rpcRsps := make([]*JsonRpcResponse, 0, len(reqs))
+	err = json.Unmarshal(body, &rpcRsps)
+	if err != nil {
+		//node doesn't support batch request
+		return this.sendRpcRequestOneByOne(reqs), nil
+	}
+	rspMap := make(map[string]*JsonRpcResponse, len(rpcRsps))
+	for _, rsp := range rpcRsps {
+		if rsp != nil {
+			rspMap[rsp.Id] = rsp
+		}
+	}
+	results := make([]*rpcBatchResult, 0, len(reqs))
+	for _, req := range reqs {
+		rsp, ok := rspMap[req.Id]
+		if !ok {
+			results = append(results, &rpcBatchResult{Err: fmt.Errorf("missing response of method:%s qid:%s in batch", req.Method, req.Id)})
+			continue
+		}
+		if rsp.Error != 0 {
+			results = append(results, &rpcBatchResult{Err: &NodeError{
+				Code:      rsp.Error,
+				Desc:      rsp.Desc,
+				Result:    string(rsp.Result),
+				Transport: TRANSPORT_RPC,
+				Method:    req.Method,
+				Qid:       req.Id,
+			}})
+			continue
+		}
+		results = append(results, &rpcBatchResult{Result: rsp.Result})
+	}
+	return results, nil
+}
+
+func (this *RpcClient) doRpcBatchRequest(qid string, reqs []*JsonRpcRequest) ([]byte, error) {
+	data, err := json.Marshal(reqs)
+	if err != nil {
+		return nil, fmt.Errorf("JsonRpcRequest json.Marsha error:%s", err)
+	}
+	resp, err := this.httpClient.Post(this.addr, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return nil, &TransportError{Transport: TRANSPORT_RPC, Method: RPC_BATCH, Qid: qid, Err: fmt.Errorf("http post request error:%s", err)}
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &TransportError{Transport: TRANSPORT_RPC, Method: RPC_BATCH, Qid: qid, Err: fmt.Errorf("read rpc response body error:%s", err)}
+	}
+	return body, nil
+}
+
+func (this *RpcClient) sendRpcRequestOneByOne(reqs []*JsonRpcRequest) []*rpcBatchResult {
+	results := make([]*rpcBatchResult, 0, len(reqs))
+	for _, req := range reqs {
+		data, err := this.sendRpcRequest(req.Id, req.Method, req.Params)
+		results = append(results, &rpcBatchResult{Result: data, Err: err})
+	}
+	return results
+}
+
+type rpcBatchResult struct {
+	Result json.RawMessage
+	Err    error
+}
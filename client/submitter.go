@@ -0,0 +1,304 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	sdkcom "github.com/DNAProject/DNA-go-sdk/common"
+	"github.com/DNAProject/DNA-go-sdk/utils"
+	"github.com/DNAProject/DNA/common"
+	"github.com/DNAProject/DNA/core/types"
+)
+
+//TxErrorKind is the class of an error returned by node when sending transaction
+type TxErrorKind int
+
+const (
+	TX_ERR_NONE              TxErrorKind = iota
+	TX_ERR_DUPLICATE                     //Transaction is already in tx pool or ledger
+	TX_ERR_INSUFFICIENT_GAS              //Gas price, gas limit or balance of payer is not enough
+	TX_ERR_INVALID_SIGNATURE             //Signature of transaction cannot pass verify
+	TX_ERR_NETWORK                       //Transport error, node may be unreachable
+	TX_ERR_REJECTED                      //Other error, transaction was rejected by node
+)
+
+func (this TxErrorKind) String() string {
+	switch this {
+	case TX_ERR_NONE:
+		return "none"
+	case TX_ERR_DUPLICATE:
+		return "duplicate"
+	case TX_ERR_INSUFFICIENT_GAS:
+		return "insufficient gas"
+	case TX_ERR_INVALID_SIGNATURE:
+		return "invalid signature"
+	case TX_ERR_NETWORK:
+		return "network"
+	default:
+		return "rejected"
+	}
+}
+
+//Retryable return whether a transaction failed by this kind of error can be sent again
+func (this TxErrorKind) Retryable() bool {
+	return this == TX_ERR_NETWORK
+}
+
+//ClassifyTxError return the kind of error returned by sendRawTransaction.
+//Only TransportError and code of NodeError are classified, other errors are TX_ERR_REJECTED.
+func ClassifyTxError(err error) TxErrorKind {
+	if err == nil {
+		return TX_ERR_NONE
+	}
+	if IsTransportError(err) {
+		return TX_ERR_NETWORK
+	}
+	switch {
+	case errors.Is(err, ERR_DUPLICATED_TX):
+		return TX_ERR_DUPLICATE
+	case errors.Is(err, ERR_VERIFY_SIGNATURE):
+		return TX_ERR_INVALID_SIGNATURE
+	case errors.Is(err, ERR_GAS_PRICE):
+		return TX_ERR_INSUFFICIENT_GAS
+	case errors.Is(err, ERR_TX_POOL_FULL):
+		return TX_ERR_NETWORK
+	}
+	return TX_ERR_REJECTED
+}
+
+//TxSubmitState is the final state of transaction submitted by TxSubmitter
+type TxSubmitState int
+
+const (
+	TX_SUBMIT_CONFIRMED TxSubmitState = iota //Transaction has been packed into block
+	TX_SUBMIT_FAILED                         //Transaction was rejected, or cannot send to any node
+	TX_SUBMIT_TIMEOUT                        //Transaction was accepted, but no block include it before timeout
+)
+
+func (this TxSubmitState) String() string {
+	switch this {
+	case TX_SUBMIT_CONFIRMED:
+		return "confirmed"
+	case TX_SUBMIT_FAILED:
+		return "failed"
+	default:
+		return "timeout"
+	}
+}
+
+var (
+	DEFAULT_SUBMIT_MAX_RETRY       = 5
+	DEFAULT_SUBMIT_INIT_BACKOFF    = 500 * time.Millisecond
+	DEFAULT_SUBMIT_MAX_BACKOFF     = 10 * time.Second
+	DEFAULT_SUBMIT_POLL_INTERVAL   = time.Second
+	DEFAULT_SUBMIT_CONFIRM_TIMEOUT = 60 * time.Second
+	DEFAULT_SUBMIT_MAX_REBROADCAST = 3
+)
+
+//TxSubmitConfig config of TxSubmitter
+type TxSubmitConfig struct {
+	MaxRetry       int           //Max retry times of transient error for each broadcast
+	InitBackoff    time.Duration //Backoff before first retry, doubled after each retry
+	MaxBackoff     time.Duration //Upper limit of backoff
+	PollInterval   time.Duration //Interval of polling transaction state
+	ConfirmTimeout time.Duration //Max time waiting for transaction packed into block
+	MaxRebroadcast int           //Max times of rebroadcast when transaction dropped out of tx pool
+}
+
+func NewTxSubmitConfig() *TxSubmitConfig {
+	return &TxSubmitConfig{
+		MaxRetry:       DEFAULT_SUBMIT_MAX_RETRY,
+		InitBackoff:    DEFAULT_SUBMIT_INIT_BACKOFF,
+		MaxBackoff:     DEFAULT_SUBMIT_MAX_BACKOFF,
+		PollInterval:   DEFAULT_SUBMIT_POLL_INTERVAL,
+		ConfirmTimeout: DEFAULT_SUBMIT_CONFIRM_TIMEOUT,
+		MaxRebroadcast: DEFAULT_SUBMIT_MAX_REBROADCAST,
+	}
+}
+
+//TxSubmitResult is the final outcome of a transaction submitted by TxSubmitter
+type TxSubmitResult struct {
+	TxHash       common.Uint256
+	State        TxSubmitState
+	Height       uint32                    //Block height of transaction, valid when State is TX_SUBMIT_CONFIRMED
+	Event        *sdkcom.SmartContactEvent //Execute event of transaction, may be nil if node doesn't return it
+	Attempts     int                       //Total times of sending transaction to node
+	Rebroadcasts int                       //Times of rebroadcast after transaction dropped out of tx pool
+	ErrKind      TxErrorKind
+	Err          error
+}
+
+//TxSubmitter send transaction to nodes with retry, and wait for transaction packed into block
+type TxSubmitter struct {
+	mgr     *ClientMgr
+	clients []DNAClient
+	config  *TxSubmitConfig
+}
+
+//NewTxSubmitter return a TxSubmitter. If clients is empty, use all of clients in ClientMgr
+func (this *ClientMgr) NewTxSubmitter(config *TxSubmitConfig, clients ...DNAClient) *TxSubmitter {
+	if config == nil {
+		config = NewTxSubmitConfig()
+	}
+	if len(clients) == 0 {
+		clients = this.getClients()
+	}
+	return &TxSubmitter{
+		mgr:     this,
+		clients: clients,
+		config:  config,
+	}
+}
+
+//Submit send transaction and wait for the final outcome.
+//Transient errors are retried with exponential backoff, switching to the next node on each retry.
+//If transaction vanished from tx pool before packed into block, it will be rebroadcast.
+func (this *TxSubmitter) Submit(mutTx *types.MutableTransaction) *TxSubmitResult {
+	result := &TxSubmitResult{}
+	if len(this.clients) == 0 {
+		result.State = TX_SUBMIT_FAILED
+		result.Err = fmt.Errorf("don't have available client of dna")
+		return result
+	}
+	tx, err := mutTx.IntoImmutable()
+	if err != nil {
+		result.State = TX_SUBMIT_FAILED
+		result.Err = err
+		return result
+	}
+	result.TxHash = tx.Hash()
+
+	err = this.broadcast(tx, result)
+	if err != nil {
+		result.State = TX_SUBMIT_FAILED
+		result.Err = err
+		return result
+	}
+	this.waitConfirm(tx, result)
+	return result
+}
+
+func (this *TxSubmitter) broadcast(tx *types.Transaction, result *TxSubmitResult) error {
+	backoff := this.config.InitBackoff
+	var err error
+	for i := 0; i <= this.config.MaxRetry; i++ {
+		if i > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+			if backoff > this.config.MaxBackoff {
+				backoff = this.config.MaxBackoff
+			}
+		}
+		client := this.clients[(result.Attempts)%len(this.clients)]
+		result.Attempts++
+		_, err = client.sendRawTransaction(this.mgr.getNextQid(), tx, false)
+		kind := ClassifyTxError(err)
+		result.ErrKind = kind
+		switch {
+		case kind == TX_ERR_NONE, kind == TX_ERR_DUPLICATE:
+			//duplicate means that tx has already been accepted by node
+			return nil
+		case !kind.Retryable():
+			return err
+		}
+	}
+	return fmt.Errorf("send transaction failed after %d attempts, last error:%s", result.Attempts, err)
+}
+
+func (this *TxSubmitter) waitConfirm(tx *types.Transaction, result *TxSubmitResult) {
+	txHash := result.TxHash.ToHexString()
+	deadline := time.Now().Add(this.config.ConfirmTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(this.config.PollInterval)
+		height, ok := this.getTxHeight(txHash)
+		if ok {
+			result.State = TX_SUBMIT_CONFIRMED
+			result.Height = height
+			result.Event = this.getTxEvent(txHash)
+			return
+		}
+		inPool, err := this.inTxPool(txHash)
+		if err != nil || inPool {
+			continue
+		}
+		//tx may be packed into block just after the first query
+		height, ok = this.getTxHeight(txHash)
+		if ok {
+			result.State = TX_SUBMIT_CONFIRMED
+			result.Height = height
+			result.Event = this.getTxEvent(txHash)
+			return
+		}
+		if result.Rebroadcasts >= this.config.MaxRebroadcast {
+			result.State = TX_SUBMIT_FAILED
+			result.Err = fmt.Errorf("transaction:%s dropped out of tx pool after %d rebroadcasts", txHash, result.Rebroadcasts)
+			return
+		}
+		result.Rebroadcasts++
+		err = this.broadcast(tx, result)
+		if err != nil {
+			result.State = TX_SUBMIT_FAILED
+			result.Err = err
+			return
+		}
+	}
+	result.State = TX_SUBMIT_TIMEOUT
+	result.Err = fmt.Errorf("transaction:%s is not packed into block after %s", txHash, this.config.ConfirmTimeout)
+}
+
+func (this *TxSubmitter) getTxHeight(txHash string) (uint32, bool) {
+	for _, client := range this.clients {
+		data, err := client.getBlockHeightByTxHash(this.mgr.getNextQid(), txHash)
+		if err != nil {
+			continue
+		}
+		height, err := utils.GetUint32(data)
+		if err != nil || height == 0 {
+			continue
+		}
+		return height, true
+	}
+	return 0, false
+}
+
+//inTxPool return true if any of node has the transaction in tx pool.
+//Only ERR_UNKNOWN_TRANSACTION means transaction is not in tx pool of node.
+//Return error if none of node answer it
+func (this *TxSubmitter) inTxPool(txHash string) (bool, error) {
+	var lastErr error
+	answered := false
+	for _, client := range this.clients {
+		_, err := client.getMemPoolTxState(this.mgr.getNextQid(), txHash)
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, ERR_UNKNOWN_TRANSACTION) {
+			lastErr = err
+			continue
+		}
+		answered = true
+	}
+	if answered {
+		return false, nil
+	}
+	return false, lastErr
+}
+
+func (this *TxSubmitter) getTxEvent(txHash string) *sdkcom.SmartContactEvent {
+	for _, client := range this.clients {
+		data, err := client.getSmartContractEvent(this.mgr.getNextQid(), txHash)
+		if err != nil {
+			continue
+		}
+		event, err := utils.GetSmartContractEvent(data)
+		if err != nil {
+			continue
+		}
+		return event
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DNAProject/DNA/core/payload"
+	"github.com/DNAProject/DNA/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSubmitTestTx() *types.MutableTransaction {
+	return &types.MutableTransaction{
+		TxType:  types.InvokeNeo,
+		Nonce:   1,
+		Payload: &payload.InvokeCode{Code: []byte{1}},
+		Sigs:    make([]types.Sig, 0),
+	}
+}
+
+func newSubmitTestConfig() *TxSubmitConfig {
+	return &TxSubmitConfig{
+		MaxRetry:       2,
+		InitBackoff:    time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		PollInterval:   time.Millisecond,
+		ConfirmTimeout: time.Second,
+		MaxRebroadcast: 2,
+	}
+}
+
+//newSubmitTestServer accept transaction, which is packed into block at height 10 once it's sent packedAfter times.
+//Before that, query of tx pool is answered with error poolErrCode.
+func newSubmitTestServer(sends *int32, packedAfter int32, poolErrCode int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &JsonRpcRequest{}
+		json.NewDecoder(r.Body).Decode(req)
+		rsp := &JsonRpcResponse{Id: req.Id}
+		switch req.Method {
+		case RPC_SEND_TRANSACTION:
+			atomic.AddInt32(sends, 1)
+			rsp.Result = json.RawMessage(`"txhash"`)
+		case RPC_GET_BLOCK_HEIGHT_BY_TX_HASH:
+			if atomic.LoadInt32(sends) >= packedAfter {
+				rsp.Result = json.RawMessage(`10`)
+			} else {
+				rsp.Error, rsp.Desc = ERR_CODE_UNKNOWN_TRANSACTION, "UNKNOWN TRANSACTION"
+			}
+		case RPC_GET_SMART_CONTRACT_EVENT:
+			rsp.Result = json.RawMessage(`null`)
+		case RPC_GET_MEM_POOL_TX_STATE:
+			rsp.Error, rsp.Desc = poolErrCode, "MEM POOL ERROR"
+		default:
+			rsp.Error, rsp.Desc = ERR_CODE_UNKNOWN_TRANSACTION, "UNKNOWN TRANSACTION"
+		}
+		json.NewEncoder(w).Encode(rsp)
+	}))
+}
+
+func TestTxSubmitter_RetryNextClient(t *testing.T) {
+	var sends int32
+	server := newSubmitTestServer(&sends, 1, ERR_CODE_UNKNOWN_TRANSACTION)
+	defer server.Close()
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	mgr := &ClientMgr{}
+	down := mgr.NewRpcClient()
+	down.SetAddress(unreachable.URL)
+	up := mgr.NewRpcClient()
+	up.SetAddress(server.URL)
+	result := mgr.NewTxSubmitter(newSubmitTestConfig(), down, up).Submit(newSubmitTestTx())
+	assert.Equal(t, TX_SUBMIT_CONFIRMED, result.State, result.Err)
+	assert.Equal(t, 2, result.Attempts)
+	assert.Equal(t, int32(1), sends)
+	assert.Equal(t, uint32(10), result.Height)
+
+	//transport error is retried until MaxRetry
+	result = mgr.NewTxSubmitter(newSubmitTestConfig(), down).Submit(newSubmitTestTx())
+	assert.Equal(t, TX_SUBMIT_FAILED, result.State)
+	assert.Equal(t, TX_ERR_NETWORK, result.ErrKind)
+	assert.Equal(t, 3, result.Attempts)
+}
+
+func TestTxSubmitter_Rebroadcast(t *testing.T) {
+	var sends int32
+	server := newSubmitTestServer(&sends, 2, ERR_CODE_UNKNOWN_TRANSACTION)
+	defer server.Close()
+
+	mgr := &ClientMgr{}
+	mgr.NewRpcClient().SetAddress(server.URL)
+	result := mgr.NewTxSubmitter(newSubmitTestConfig()).Submit(newSubmitTestTx())
+	assert.Equal(t, TX_SUBMIT_CONFIRMED, result.State, result.Err)
+	assert.Equal(t, 1, result.Rebroadcasts)
+	assert.Equal(t, 2, result.Attempts)
+	assert.Equal(t, int32(2), sends)
+
+	//transaction which is never packed is rebroadcast until MaxRebroadcast
+	var dropped int32
+	dropServer := newSubmitTestServer(&dropped, 100, ERR_CODE_UNKNOWN_TRANSACTION)
+	defer dropServer.Close()
+	mgr.NewRpcClient().SetAddress(dropServer.URL)
+	result = mgr.NewTxSubmitter(newSubmitTestConfig()).Submit(newSubmitTestTx())
+	assert.Equal(t, TX_SUBMIT_FAILED, result.State)
+	assert.Equal(t, 2, result.Rebroadcasts)
+	assert.Equal(t, 3, result.Attempts)
+	assert.Equal(t, int32(3), dropped)
+}
+
+func TestTxSubmitter_PoolQueryError(t *testing.T) {
+	var sends int32
+	server := newSubmitTestServer(&sends, 100, ERR_CODE_INTERNAL_ERROR)
+	defer server.Close()
+
+	mgr := &ClientMgr{}
+	mgr.NewRpcClient().SetAddress(server.URL)
+	config := newSubmitTestConfig()
+	config.ConfirmTimeout = 50 * time.Millisecond
+	//error other than unknown transaction doesn't mean transaction is dropped
+	result := mgr.NewTxSubmitter(config).Submit(newSubmitTestTx())
+	assert.Equal(t, TX_SUBMIT_TIMEOUT, result.State)
+	assert.Equal(t, 0, result.Rebroadcasts)
+	assert.Equal(t, int32(1), sends)
+}
@@ -101,6 +101,19 @@ type WSClient struct {
 	onConnect         func(address string)
 	onClose           func(address string)
 	onError           func(address string, err error)
+	subs              map[string][]*wsSubscription
+	nextSubId         uint64
+	legacyAction      bool
+	subLock           sync.RWMutex
+	subStatusLock     sync.Mutex
+	pushQueue         *wsPushQueue
+	delivery          *wsDeliveryState
+	reconnectConfig   *WSReconnectConfig
+	connState         int32
+	reconnecting      int32
+	onStateChange     func(address string, state WSConnState)
+	interceptors      *InterceptorChain
+	closeOnce         sync.Once
 	lock              sync.RWMutex
 }
 
@@ -111,6 +124,10 @@ func NewWSClient() *WSClient {
 		heartbeatTimeout:  DEFAULT_WS_HEARTBEAT_TIMEOUT,
 		subStatus:         &WSSubscribeStatus{},
 		reqMap:            make(map[string]*WSRequest),
+		subs:              make(map[string][]*wsSubscription),
+		pushQueue:         newWSPushQueue(),
+		delivery:          newWSDeliveryState(),
+		reconnectConfig:   NewWSReconnectConfig(),
 		recvCh:            make(chan []byte, WS_RECV_CHAN_SIZE),
 		actionCh:          make(chan *WSAction, WS_RECV_CHAN_SIZE),
 		lastHeartbeatTime: time.Now(),
@@ -118,28 +135,25 @@ func NewWSClient() *WSClient {
 		exitCh:            make(chan interface{}, 0),
 	}
 	go wsClient.start()
+	go wsClient.pushLoop()
 	return wsClient
 }
 
 func (this *WSClient) Connect(address string) error {
 	if this.getWsClient() != nil {
-		return fmt.Errorf("address:%s has already connect", this.addr)
+		return fmt.Errorf("address:%s has already connect", this.getAddr())
 	}
 	if address == "" {
 		return fmt.Errorf("address cannot empty")
 	}
-	this.addr = address
-	ws := utils.NewWebSocketClient()
-	ws.OnMessage = this.onMessage
-	ws.OnError = this.GetOnError()
-	ws.OnConnect = this.GetOnConnect()
-	ws.OnClose = this.GetOnClose()
-
-	err := ws.Connect(address)
+	this.setConnState(WS_STATE_CONNECTING)
+	err := this.dial(address)
 	if err != nil {
+		this.setConnState(WS_STATE_CLOSED)
 		return err
 	}
-	this.setWsClient(ws)
+	this.updateLastRecvTime()
+	this.setConnState(WS_STATE_CONNECTED)
 	return nil
 }
 
@@ -216,10 +230,6 @@ func (this *WSClient) SetOnConnect(f func(address string)) {
 	this.lock.Lock()
 	defer this.lock.Unlock()
 	this.onConnect = f
-	ws := this.getWsClient()
-	if ws != nil {
-		ws.OnConnect = f
-	}
 }
 
 func (this *WSClient) GetOnClose() func(address string) {
@@ -235,10 +245,6 @@ func (this *WSClient) SetOnClose(f func(address string)) {
 	this.lock.Lock()
 	defer this.lock.Unlock()
 	this.onClose = f
-	ws := this.getWsClient()
-	if ws != nil {
-		ws.OnClose = f
-	}
 }
 
 func (this *WSClient) GetOnError() func(address string, er error) {
@@ -254,10 +260,6 @@ func (this *WSClient) SetOnError(f func(address string, err error)) {
 	this.lock.Lock()
 	defer this.lock.Unlock()
 	this.onError = f
-	ws := this.getWsClient()
-	if ws != nil {
-		ws.OnError = f
-	}
 }
 
 func (this *WSClient) onMessage(data []byte) {
@@ -293,12 +295,17 @@ func (this *WSClient) start() {
 			wsResp := &WSResponse{}
 			err := json.Unmarshal(data, wsResp)
 			if err != nil {
-				this.GetOnError()(this.addr, fmt.Errorf("json.Unmarshal WSResponse error:%s", err))
+				this.GetOnError()(this.getAddr(), fmt.Errorf("json.Unmarshal WSResponse error:%s", err))
+			} else if wsResp.Id == "" {
+				this.pushQueue.put(wsResp)
 			} else {
 				go this.onAction(wsResp)
 			}
 		case <-heartbeatTimer.C:
 			now := time.Now()
+			if this.GetConnState() != WS_STATE_CONNECTED {
+				continue
+			}
 			if int(now.Sub(this.getLastRecvTime()).Seconds()) >= this.GetHeartbeatTimeout() {
 				go this.reconnect()
 				this.updateLastRecvTime()
@@ -310,32 +317,13 @@ func (this *WSClient) start() {
 	}
 }
 
-func (this *WSClient) reconnect() {
-	ws := this.getWsClient()
-	if ws != nil {
-		this.setWsClient(nil)
-		err := ws.Close()
-		if err != nil {
-			this.GetOnError()(this.addr, fmt.Errorf("close error:%s", err))
-		}
-		ws.OnMessage = nil
-	}
-	err := this.Connect(this.addr)
-	if err != nil {
-		this.GetOnError()(this.addr, fmt.Errorf("connect error:%s", err))
-		return
-	}
-	err = this.reSubscribe()
-	if err != nil {
-		this.GetOnError()(this.addr, fmt.Errorf("reSubscribe:%v error:%s", this.subStatus, err))
-	}
-}
-
 func (this *WSClient) onAction(resp *WSResponse) {
 	if resp.Id == "" {
 		switch resp.Action {
 		case WS_SUB_ACTION_RAW_BLOCK:
 			this.onRawBlockAction(resp)
+		case WS_SUB_ACTION_JSON_BLOCK:
+			this.onJsonBlockAction(resp)
 		case WS_SUB_ACTION_BLOCK_TX_HASH:
 			this.onBlockTxHashesAction(resp)
 		case WS_SUB_ACTION_NOTIFY:
@@ -343,7 +331,7 @@ func (this *WSClient) onAction(resp *WSResponse) {
 		case WS_SUB_ACTION_LOG:
 			this.onSmartContractEventLogAction(resp)
 		default:
-			this.GetOnError()(this.addr, fmt.Errorf("unknown subscribe action:%s", resp.Action))
+			this.GetOnError()(this.getAddr(), fmt.Errorf("unknown subscribe action:%s", resp.Action))
 		}
 		return
 	}
@@ -362,79 +350,56 @@ func (this *WSClient) onAction(resp *WSResponse) {
 func (this *WSClient) onRawBlockAction(resp *WSResponse) {
 	block, err := utils.GetBlock(resp.Result)
 	if err != nil {
-		this.GetOnError()(this.addr, fmt.Errorf("onRawBlockAction error:%s", err))
+		this.GetOnError()(this.getAddr(), fmt.Errorf("onRawBlockAction error:%s", err))
 		return
 	}
-	select {
-	case this.actionCh <- &WSAction{
-		Action: sdkcom.WS_SUBSCRIBE_ACTION_BLOCK,
-		Result: block,
-	}:
-	case <-this.exitCh:
+	this.deliverBlock(block)
+}
+
+func (this *WSClient) onJsonBlockAction(resp *WSResponse) {
+	block, err := utils.GetBlockInfo(resp.Result)
+	if err != nil {
+		this.GetOnError()(this.getAddr(), fmt.Errorf("onJsonBlockAction error:%s", err))
 		return
 	}
+	this.deliverJsonBlock(block)
 }
 
 func (this *WSClient) onBlockTxHashesAction(resp *WSResponse) {
 	blockTxHashes, err := utils.GetBlockTxHashes(resp.Result)
 	if err != nil {
-		this.GetOnError()(this.addr, fmt.Errorf("onBlockTxHashesAction error:%s", err))
-		return
-	}
-	select {
-	case this.actionCh <- &WSAction{
-		Action: sdkcom.WS_SUBSCRIBE_ACTION_BLOCK_TX_HASH,
-		Result: blockTxHashes,
-	}:
-	case <-this.exitCh:
+		this.GetOnError()(this.getAddr(), fmt.Errorf("onBlockTxHashesAction error:%s", err))
 		return
 	}
+	this.deliverBlockTxHashes(blockTxHashes)
 }
 
 func (this *WSClient) onSmartContractEventAction(resp *WSResponse) {
 	event, err := utils.GetSmartContractEvent(resp.Result)
 	if err != nil {
-		this.GetOnError()(this.addr, fmt.Errorf("onSmartContractEventAction error:%s", err))
-		return
-	}
-	select {
-	case this.actionCh <- &WSAction{
-		Action: sdkcom.WS_SUBSCRIBE_ACTION_EVENT_NOTIFY,
-		Result: event,
-	}:
-	case <-this.exitCh:
+		this.GetOnError()(this.getAddr(), fmt.Errorf("onSmartContractEventAction error:%s", err))
 		return
 	}
+	this.deliverEvent(event)
 }
 
 func (this *WSClient) onSmartContractEventLogAction(resp *WSResponse) {
 	log, err := utils.GetSmartContractEventLog(resp.Result)
 	if err != nil {
-		this.GetOnError()(this.addr, fmt.Errorf("onSmartContractEventLogAction error:%s", err))
-		return
-	}
-	select {
-	case this.actionCh <- &WSAction{
-		Action: sdkcom.WS_SUBSCRIBE_ACTION_EVENT_LOG,
-		Result: log,
-	}:
-	case <-this.exitCh:
+		this.GetOnError()(this.getAddr(), fmt.Errorf("onSmartContractEventLogAction error:%s", err))
 		return
 	}
+	this.dispatchAction(sdkcom.WS_SUBSCRIBE_ACTION_EVENT_LOG, log)
 }
 
 func (this *WSClient) AddContractFilter(contractAddress string) error {
+	this.subStatusLock.Lock()
+	defer this.subStatusLock.Unlock()
 	if this.subStatus.HasContractFilter(contractAddress) {
 		return nil
 	}
 	this.subStatus.AddContractFilter(contractAddress)
-	_, err := this.sendSyncWSRequest("", WS_ACTION_SUBSCRIBE, map[string]interface{}{
-		WS_SUB_CONTRACT_FILTER: this.subStatus.GetContractFilter(),
-		WS_SUB_EVENT:           this.subStatus.SubscribeEvent,
-		WS_SUB_JSON_BLOCK:      this.subStatus.SubscribeJsonBlock,
-		WS_SUB_RAW_BLOCK:       this.subStatus.SubscribeRawBlock,
-		WS_SUB_BLOCK_TX_HASH:   this.subStatus.SubscribeBlockTxHashes,
-	})
+	err := this.sendSubscribe(this.subStatus)
 	if err != nil {
 		this.subStatus.DelContractFilter(contractAddress)
 		return err
@@ -443,17 +408,13 @@ func (this *WSClient) AddContractFilter(contractAddress string) error {
 }
 
 func (this *WSClient) DelContractFilter(contractAddress string) error {
+	this.subStatusLock.Lock()
+	defer this.subStatusLock.Unlock()
 	if !this.subStatus.HasContractFilter(contractAddress) {
 		return nil
 	}
 	this.subStatus.DelContractFilter(contractAddress)
-	_, err := this.sendSyncWSRequest("", WS_ACTION_SUBSCRIBE, map[string]interface{}{
-		WS_SUB_CONTRACT_FILTER: this.subStatus.GetContractFilter(),
-		WS_SUB_EVENT:           this.subStatus.SubscribeEvent,
-		WS_SUB_JSON_BLOCK:      this.subStatus.SubscribeJsonBlock,
-		WS_SUB_RAW_BLOCK:       this.subStatus.SubscribeRawBlock,
-		WS_SUB_BLOCK_TX_HASH:   this.subStatus.SubscribeBlockTxHashes,
-	})
+	err := this.sendSubscribe(this.subStatus)
 	if err != nil {
 		this.subStatus.AddContractFilter(contractAddress)
 		return err
@@ -461,121 +422,19 @@ func (this *WSClient) DelContractFilter(contractAddress string) error {
 	return nil
 }
 
-func (this *WSClient) SubscribeBlock() error {
-	if this.subStatus.SubscribeRawBlock {
-		return nil
-	}
-	_, err := this.sendSyncWSRequest("", WS_ACTION_SUBSCRIBE, map[string]interface{}{
-		WS_SUB_CONTRACT_FILTER: this.subStatus.GetContractFilter(),
-		WS_SUB_EVENT:           this.subStatus.SubscribeEvent,
-		WS_SUB_JSON_BLOCK:      this.subStatus.SubscribeJsonBlock,
-		WS_SUB_RAW_BLOCK:       true,
-		WS_SUB_BLOCK_TX_HASH:   this.subStatus.SubscribeBlockTxHashes,
-	})
-	if err != nil {
-		return err
-	}
-	this.subStatus.SubscribeRawBlock = true
-	return nil
-}
-
-func (this *WSClient) UnsubscribeBlock() error {
-	if !this.subStatus.SubscribeRawBlock {
-		return nil
-	}
-	_, err := this.sendSyncWSRequest("", WS_ACTION_SUBSCRIBE, map[string]interface{}{
-		WS_SUB_CONTRACT_FILTER: this.subStatus.GetContractFilter(),
-		WS_SUB_EVENT:           this.subStatus.SubscribeEvent,
-		WS_SUB_JSON_BLOCK:      this.subStatus.SubscribeJsonBlock,
-		WS_SUB_RAW_BLOCK:       false,
-		WS_SUB_BLOCK_TX_HASH:   this.subStatus.SubscribeBlockTxHashes,
-	})
-	if err != nil {
-		return err
-	}
-	this.subStatus.SubscribeRawBlock = false
-	return nil
-}
-
-func (this *WSClient) SubscribeEvent() error {
-	if this.subStatus.SubscribeEvent {
-		return nil
-	}
-	_, err := this.sendSyncWSRequest("", WS_ACTION_SUBSCRIBE, map[string]interface{}{
-		WS_SUB_CONTRACT_FILTER: this.subStatus.GetContractFilter(),
-		WS_SUB_EVENT:           true,
-		WS_SUB_JSON_BLOCK:      this.subStatus.SubscribeJsonBlock,
-		WS_SUB_RAW_BLOCK:       this.subStatus.SubscribeRawBlock,
-		WS_SUB_BLOCK_TX_HASH:   this.subStatus.SubscribeBlockTxHashes,
-	})
-	if err != nil {
-		return err
-	}
-	this.subStatus.SubscribeEvent = true
-	return nil
-}
-
-func (this *WSClient) UnsubscribeEvent() error {
-	if !this.subStatus.SubscribeEvent {
-		return nil
-	}
-	_, err := this.sendSyncWSRequest("", WS_ACTION_SUBSCRIBE, map[string]interface{}{
-		WS_SUB_CONTRACT_FILTER: this.subStatus.GetContractFilter(),
-		WS_SUB_EVENT:           false,
-		WS_SUB_JSON_BLOCK:      this.subStatus.SubscribeJsonBlock,
-		WS_SUB_RAW_BLOCK:       this.subStatus.SubscribeRawBlock,
-		WS_SUB_BLOCK_TX_HASH:   this.subStatus.SubscribeBlockTxHashes,
-	})
-	if err != nil {
-		return err
-	}
-	this.subStatus.SubscribeEvent = false
-	return nil
-}
-
-func (this *WSClient) SubscribeTxHash() error {
-	if this.subStatus.SubscribeBlockTxHashes {
-		return nil
-	}
-	_, err := this.sendSyncWSRequest("", WS_ACTION_SUBSCRIBE, map[string]interface{}{
-		WS_SUB_CONTRACT_FILTER: this.subStatus.GetContractFilter(),
-		WS_SUB_EVENT:           this.subStatus.SubscribeEvent,
-		WS_SUB_JSON_BLOCK:      this.subStatus.SubscribeJsonBlock,
-		WS_SUB_RAW_BLOCK:       this.subStatus.SubscribeRawBlock,
-		WS_SUB_BLOCK_TX_HASH:   true,
-	})
-	if err != nil {
-		return err
-	}
-	this.subStatus.SubscribeBlockTxHashes = true
-	return nil
-}
-
-func (this *WSClient) UnsubscribeTxHash() error {
-	if !this.subStatus.SubscribeBlockTxHashes {
-		return nil
-	}
-	_, err := this.sendSyncWSRequest("", WS_ACTION_SUBSCRIBE, map[string]interface{}{
-		WS_SUB_CONTRACT_FILTER: this.subStatus.GetContractFilter(),
-		WS_SUB_EVENT:           this.subStatus.SubscribeEvent,
-		WS_SUB_JSON_BLOCK:      this.subStatus.SubscribeJsonBlock,
-		WS_SUB_RAW_BLOCK:       this.subStatus.SubscribeRawBlock,
-		WS_SUB_BLOCK_TX_HASH:   false,
-	})
-	if err != nil {
-		return err
-	}
-	this.subStatus.SubscribeBlockTxHashes = false
-	return nil
+func (this *WSClient) reSubscribe() error {
+	this.subStatusLock.Lock()
+	defer this.subStatusLock.Unlock()
+	return this.sendSubscribe(this.subStatus)
 }
 
-func (this *WSClient) reSubscribe() error {
+func (this *WSClient) sendSubscribe(status *WSSubscribeStatus) error {
 	_, err := this.sendSyncWSRequest("", WS_ACTION_SUBSCRIBE, map[string]interface{}{
-		WS_SUB_CONTRACT_FILTER: this.subStatus.GetContractFilter(),
-		WS_SUB_EVENT:           this.subStatus.SubscribeEvent,
-		WS_SUB_JSON_BLOCK:      this.subStatus.SubscribeJsonBlock,
-		WS_SUB_RAW_BLOCK:       this.subStatus.SubscribeRawBlock,
-		WS_SUB_BLOCK_TX_HASH:   this.subStatus.SubscribeBlockTxHashes,
+		WS_SUB_CONTRACT_FILTER: status.GetContractFilter(),
+		WS_SUB_EVENT:           status.SubscribeEvent,
+		WS_SUB_JSON_BLOCK:      status.SubscribeJsonBlock,
+		WS_SUB_RAW_BLOCK:       status.SubscribeRawBlock,
+		WS_SUB_BLOCK_TX_HASH:   status.SubscribeBlockTxHashes,
 	})
 	return err
 }
@@ -671,10 +530,47 @@ func (this *WSClient) getSmartContractEventByBlock(qid string, blockHeight uint3
 	return this.sendSyncWSRequest(qid, WS_ACTION_GET_SMARTCONTRACT_BY_HEIGHT, map[string]interface{}{"Height": blockHeight})
 }
 
+func (this *WSClient) getBalance(qid, address string) ([]byte, error) {
+	return this.sendSyncWSRequest(qid, WS_ACTION_GET_BALANCE, map[string]interface{}{"Addr": address})
+}
+
+func (this *WSClient) getGenerateBlockTime(qid string) ([]byte, error) {
+	return this.sendSyncWSRequest(qid, WS_ACTION_GET_GENERATE_BLOCK_TIME, nil)
+}
+
+//sendEmergencyGovReq is only supported by rpc
+func (this *WSClient) sendEmergencyGovReq(qid string, data []byte) ([]byte, error) {
+	return nil, &TransportError{Transport: TRANSPORT_WS, Method: SEND_EMERGENCY_GOV_REQ, Qid: qid, Err: ERR_METHOD_NOT_SUPPORTED}
+}
+
+//getBlockRootWithNewTxRoot is only supported by rpc
+func (this *WSClient) getBlockRootWithNewTxRoot(qid string, txRoot common.Uint256) ([]byte, error) {
+	return nil, &TransportError{Transport: TRANSPORT_WS, Method: GET_BLOCK_ROOT_WITH_NEW_TX_ROOT, Qid: qid, Err: ERR_METHOD_NOT_SUPPORTED}
+}
+
+//EnableActionCh start sending all pushed actions to the channel returned by GetActionCh.
+//It is disabled by default, so that the dispatch of typed subscriptions is not blocked by a channel which is never read.
+//Call it before subscribing, pushes received before it are not sent to the channel.
+func (this *WSClient) EnableActionCh() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.legacyAction = true
+}
+
+//GetActionCh return the channel of all pushed actions, and enable it as EnableActionCh if not enabled yet.
+//Pushes received before the channel is enabled are not sent to it, so call it or EnableActionCh before subscribing.
+//Deprecated: use the typed subscriptions returned by SubscribeXXX instead.
 func (this *WSClient) GetActionCh() chan *WSAction {
+	this.EnableActionCh()
 	return this.actionCh
 }
 
+func (this *WSClient) isLegacyActionEnabled() bool {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.legacyAction
+}
+
 func (this *WSClient) sendAsyncRawTransaction(qid string, tx *types.Transaction, isPreExec bool) (*WSRequest, error) {
 	sink := common.NewZeroCopySink(nil)
 	tx.Serialization(sink)
@@ -686,10 +582,22 @@ func (this *WSClient) sendAsyncRawTransaction(qid string, tx *types.Transaction,
 	return this.sendAsyncWSRequest(qid, WS_ACTION_SEND_TRANSACTION, params)
 }
 
+//sendSyncWSRequest send web socket request to dna through interceptors, and wait for response
 func (this *WSClient) sendSyncWSRequest(qid, action string, params map[string]interface{}) ([]byte, error) {
 	if qid == "" {
 		qid = strconv.Itoa(int(rand.Int31()))
 	}
+	req := &ClientRequest{Transport: TRANSPORT_WS, Method: action, Params: params, Qid: qid}
+	return this.getInterceptors().invoke(req, func(req *ClientRequest) ([]byte, error) {
+		params, ok := req.Params.(map[string]interface{})
+		if !ok {
+			return nil, invalidParamsType(req)
+		}
+		return this.doSyncWSRequest(req.Qid, req.Method, params)
+	})
+}
+
+func (this *WSClient) doSyncWSRequest(qid, action string, params map[string]interface{}) ([]byte, error) {
 	wsReq, err := this.sendAsyncWSRequest(qid, action, params)
 	if err != nil {
 		return nil, err
@@ -699,12 +607,23 @@ func (this *WSClient) sendSyncWSRequest(qid, action string, params map[string]in
 	select {
 	case wsRsp = <-wsReq.ResCh:
 		reqTimer.Stop()
+	case err = <-wsReq.ErrCh:
+		reqTimer.Stop()
+		return nil, &TransportError{Transport: TRANSPORT_WS, Method: action, Qid: wsReq.Id, Err: err}
 	case <-reqTimer.C:
-		return nil, fmt.Errorf("sendSyncWSRequest action:%s id:%s timeout", action, wsReq.Id)
+		this.delReq(wsReq.Id)
+		return nil, &TransportError{Transport: TRANSPORT_WS, Method: action, Qid: wsReq.Id, Err: ERR_REQUEST_TIMEOUT}
 	}
 
 	if wsRsp.Error != WS_ERROR_SUCCESS {
-		return nil, fmt.Errorf("WSResponse error code:%d desc:%s result:%s", wsRsp.Error, wsRsp.Desc, wsRsp.Result)
+		return nil, &NodeError{
+			Code:      int64(wsRsp.Error),
+			Desc:      wsRsp.Desc,
+			Result:    string(wsRsp.Result),
+			Transport: TRANSPORT_WS,
+			Method:    action,
+			Qid:       wsReq.Id,
+		}
 	}
 	return wsRsp.Result, nil
 }
@@ -726,16 +645,17 @@ func (this *WSClient) sendAsyncWSRequest(qid, action string, params map[string]i
 		Id:     qid,
 		Params: reqParams,
 		ResCh:  make(chan *WSResponse, 1),
+		ErrCh:  make(chan error, 1),
 	}
-	this.addReq(wsReq)
 	ws := this.getWsClient()
 	if ws == nil {
-		return nil, fmt.Errorf("ws client is nil")
+		return nil, &TransportError{Transport: TRANSPORT_WS, Method: action, Qid: qid, Err: ERR_WS_DISCONNECTED}
 	}
+	this.addReq(wsReq)
 	err = ws.Send(data)
 	if err != nil {
 		this.delReq(wsReq.Id)
-		return nil, fmt.Errorf("send error:%s", err)
+		return nil, &TransportError{Transport: TRANSPORT_WS, Method: action, Qid: qid, Err: fmt.Errorf("send error:%s", err)}
 	}
 	return wsReq, nil
 }
@@ -777,6 +697,18 @@ func (this *WSClient) setWsClient(ws *utils.WebSocketClient) {
 	this.ws = ws
 }
 
+func (this *WSClient) getInterceptors() *InterceptorChain {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.interceptors
+}
+
+func (this *WSClient) setInterceptors(interceptors *InterceptorChain) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.interceptors = interceptors
+}
+
 func (this *WSClient) getWsClient() *utils.WebSocketClient {
 	this.lock.RLock()
 	defer this.lock.RUnlock()
@@ -784,10 +716,16 @@ func (this *WSClient) getWsClient() *utils.WebSocketClient {
 }
 
 func (this *WSClient) Close() error {
-	close(this.exitCh)
-	ws := this.getWsClient()
-	if ws != nil {
-		return ws.Close()
-	}
-	return nil
+	var err error
+	this.closeOnce.Do(func() {
+		this.setConnState(WS_STATE_CLOSED)
+		close(this.exitCh)
+		ws := this.getWsClient()
+		if ws != nil {
+			this.setWsClient(nil)
+			err = ws.Close()
+		}
+		this.failPendingReqs(ERR_WS_DISCONNECTED)
+	})
+	return err
 }
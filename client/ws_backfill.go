@@ -0,0 +1,358 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"fmt"
+	"sync"
+
+	sdkcom "github.com/DNAProject/DNA-go-sdk/common"
+	"github.com/DNAProject/DNA-go-sdk/utils"
+	"github.com/DNAProject/DNA/common"
+	"github.com/DNAProject/DNA/core/types"
+)
+
+var (
+	DEFAULT_WS_BACKFILL_ON_RECONNECT = true
+	DEFAULT_WS_EVENT_DEDUP_SIZE      = 10000
+)
+
+//WS_ACTION_BACKFILL is an internal push action, enqueued after reconnect to backfill the missed blocks and events
+const WS_ACTION_BACKFILL = "backfill"
+
+//wsPushQueue is an unbounded queue of pushed actions, so that pushes are handled in order
+//and slow subscribers never block responses of requests
+type wsPushQueue struct {
+	items    []*WSResponse
+	lock     sync.Mutex
+	signalCh chan struct{}
+}
+
+func newWSPushQueue() *wsPushQueue {
+	return &wsPushQueue{signalCh: make(chan struct{}, 1)}
+}
+
+func (this *wsPushQueue) put(resp *WSResponse) {
+	this.lock.Lock()
+	this.items = append(this.items, resp)
+	this.lock.Unlock()
+	select {
+	case this.signalCh <- struct{}{}:
+	default:
+	}
+}
+
+func (this *wsPushQueue) take() *WSResponse {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if len(this.items) == 0 {
+		return nil
+	}
+	resp := this.items[0]
+	this.items[0] = nil
+	this.items = this.items[1:]
+	return resp
+}
+
+//wsDeliveryState record the last delivered heights and event tx hashes, to suppress duplicate pushes
+type wsDeliveryState struct {
+	blockHeight      uint32
+	blockKnown       bool
+	jsonBlockHeight  uint32
+	jsonBlockKnown   bool
+	txHashHeight     uint32
+	txHashKnown      bool
+	eventTxs         map[string]struct{}
+	eventTxList      []string
+	backfillDisabled bool
+	lock             sync.Mutex
+}
+
+func newWSDeliveryState() *wsDeliveryState {
+	return &wsDeliveryState{
+		eventTxs:         make(map[string]struct{}),
+		backfillDisabled: !DEFAULT_WS_BACKFILL_ON_RECONNECT,
+	}
+}
+
+//SetBackfillOnReconnect set whether to backfill blocks and events missed while disconnected.
+//If enabled, tx hashes of blocks are subscribed as well as events to track the height of events.
+func (this *WSClient) SetBackfillOnReconnect(enable bool) error {
+	this.delivery.lock.Lock()
+	this.delivery.backfillDisabled = !enable
+	this.delivery.lock.Unlock()
+	return this.syncSubscribeStatus()
+}
+
+func (this *WSClient) IsBackfillOnReconnect() bool {
+	this.delivery.lock.Lock()
+	defer this.delivery.lock.Unlock()
+	return !this.delivery.backfillDisabled
+}
+
+func (this *WSClient) pushLoop() {
+	for {
+		resp := this.pushQueue.take()
+		if resp == nil {
+			select {
+			case <-this.pushQueue.signalCh:
+				continue
+			case <-this.exitCh:
+				return
+			}
+		}
+		if resp.Action == WS_ACTION_BACKFILL {
+			err := this.backfill()
+			if err != nil {
+				this.GetOnError()(this.getAddr(), fmt.Errorf("backfill error:%s", err))
+			}
+			continue
+		}
+		this.onAction(resp)
+	}
+}
+
+//initDeliveryHeight set the start height of delivery to current block height, if nothing has been delivered
+func (this *WSClient) initDeliveryHeight(action string) {
+	if !this.IsBackfillOnReconnect() {
+		return
+	}
+	state := this.delivery
+	state.lock.Lock()
+	known := state.txHashKnown
+	switch action {
+	case sdkcom.WS_SUBSCRIBE_ACTION_BLOCK:
+		known = state.blockKnown
+	case sdkcom.WS_SUBSCRIBE_ACTION_JSON_BLOCK:
+		known = state.jsonBlockKnown
+	}
+	state.lock.Unlock()
+	if known {
+		return
+	}
+	data, err := this.getCurrentBlockHeight("")
+	if err != nil {
+		return
+	}
+	height, err := utils.GetUint32(data)
+	if err != nil {
+		return
+	}
+	state.lock.Lock()
+	defer state.lock.Unlock()
+	switch action {
+	case sdkcom.WS_SUBSCRIBE_ACTION_BLOCK:
+		if !state.blockKnown {
+			state.blockHeight, state.blockKnown = height, true
+		}
+	case sdkcom.WS_SUBSCRIBE_ACTION_JSON_BLOCK:
+		if !state.jsonBlockKnown {
+			state.jsonBlockHeight, state.jsonBlockKnown = height, true
+		}
+	default:
+		if !state.txHashKnown {
+			state.txHashHeight, state.txHashKnown = height, true
+		}
+	}
+}
+
+func (this *WSClient) deliverBlock(block *types.Block) {
+	state := this.delivery
+	state.lock.Lock()
+	if state.blockKnown && block.Header.Height <= state.blockHeight {
+		state.lock.Unlock()
+		return
+	}
+	state.blockHeight, state.blockKnown = block.Header.Height, true
+	state.lock.Unlock()
+	this.dispatchAction(sdkcom.WS_SUBSCRIBE_ACTION_BLOCK, block)
+}
+
+func (this *WSClient) deliverJsonBlock(block *sdkcom.BlockInfo) {
+	state := this.delivery
+	state.lock.Lock()
+	if state.jsonBlockKnown && block.Header.Height <= state.jsonBlockHeight {
+		state.lock.Unlock()
+		return
+	}
+	state.jsonBlockHeight, state.jsonBlockKnown = block.Header.Height, true
+	state.lock.Unlock()
+	this.dispatchAction(sdkcom.WS_SUBSCRIBE_ACTION_JSON_BLOCK, block)
+}
+
+func (this *WSClient) deliverBlockTxHashes(blockTxHashes *sdkcom.BlockTxHashes) {
+	state := this.delivery
+	state.lock.Lock()
+	if state.txHashKnown && blockTxHashes.Height <= state.txHashHeight {
+		state.lock.Unlock()
+		return
+	}
+	state.txHashHeight, state.txHashKnown = blockTxHashes.Height, true
+	state.lock.Unlock()
+	this.dispatchAction(sdkcom.WS_SUBSCRIBE_ACTION_BLOCK_TX_HASH, blockTxHashes)
+}
+
+func (this *WSClient) deliverEvent(event *sdkcom.SmartContactEvent) {
+	state := this.delivery
+	state.lock.Lock()
+	if _, ok := state.eventTxs[event.TxHash]; ok {
+		state.lock.Unlock()
+		return
+	}
+	state.eventTxs[event.TxHash] = struct{}{}
+	state.eventTxList = append(state.eventTxList, event.TxHash)
+	if len(state.eventTxList) > DEFAULT_WS_EVENT_DEDUP_SIZE {
+		delete(state.eventTxs, state.eventTxList[0])
+		state.eventTxList = state.eventTxList[1:]
+	}
+	state.lock.Unlock()
+	this.dispatchAction(sdkcom.WS_SUBSCRIBE_ACTION_EVENT_NOTIFY, event)
+}
+
+//backfill fetch blocks and events from the last delivered height to current block height, and deliver them in order.
+//Events of the last delivered height of tx hashes are fetched again, because they may be pushed later than tx hashes.
+//Logs cannot be backfilled.
+func (this *WSClient) backfill() error {
+	if !this.IsBackfillOnReconnect() {
+		return nil
+	}
+	needBlock := this.hasSubscription(sdkcom.WS_SUBSCRIBE_ACTION_BLOCK)
+	needJsonBlock := this.hasSubscription(sdkcom.WS_SUBSCRIBE_ACTION_JSON_BLOCK)
+	needTxHash := this.hasSubscription(sdkcom.WS_SUBSCRIBE_ACTION_BLOCK_TX_HASH)
+	needEvent := this.hasSubscription(sdkcom.WS_SUBSCRIBE_ACTION_EVENT_NOTIFY)
+	state := this.delivery
+	state.lock.Lock()
+	needBlock = needBlock && state.blockKnown
+	needJsonBlock = needJsonBlock && state.jsonBlockKnown
+	needTxHash = needTxHash && state.txHashKnown
+	needEvent = needEvent && state.txHashKnown
+	blockStart := uint64(state.blockHeight) + 1
+	jsonBlockStart := uint64(state.jsonBlockHeight) + 1
+	txHashStart := uint64(state.txHashHeight) + 1
+	eventStart := uint64(state.txHashHeight)
+	state.lock.Unlock()
+	this.subStatusLock.Lock()
+	contracts := this.subStatus.GetContractFilter()
+	this.subStatusLock.Unlock()
+	if !needBlock && !needJsonBlock && !needTxHash && !needEvent {
+		return nil
+	}
+	data, err := this.getCurrentBlockHeight("")
+	if err != nil {
+		return err
+	}
+	curHeight, err := utils.GetUint32(data)
+	if err != nil {
+		return err
+	}
+	start := uint64(curHeight) + 1
+	if needBlock && blockStart < start {
+		start = blockStart
+	}
+	if needJsonBlock && jsonBlockStart < start {
+		start = jsonBlockStart
+	}
+	if needTxHash && txHashStart < start {
+		start = txHashStart
+	}
+	if needEvent && eventStart < start {
+		start = eventStart
+	}
+	for height := start; height <= uint64(curHeight); height++ {
+		select {
+		case <-this.exitCh:
+			return nil
+		default:
+		}
+		withBlock := needBlock && height >= blockStart
+		withTxHash := needTxHash && height >= txHashStart
+		if withBlock || withTxHash {
+			data, err := this.getBlockByHeight("", uint32(height))
+			if err != nil {
+				return fmt.Errorf("get block:%d error:%s", height, err)
+			}
+			block, err := utils.GetBlock(data)
+			if err != nil {
+				return fmt.Errorf("get block:%d error:%s", height, err)
+			}
+			if withBlock {
+				this.deliverBlock(block)
+			}
+			if withTxHash {
+				this.deliverBlockTxHashes(getBlockTxHashes(block))
+			}
+		}
+		if needJsonBlock && height >= jsonBlockStart {
+			data, err := this.getBlockInfoByHeight("", uint32(height))
+			if err != nil {
+				return fmt.Errorf("get json block:%d error:%s", height, err)
+			}
+			block, err := utils.GetBlockInfo(data)
+			if err != nil {
+				return fmt.Errorf("get json block:%d error:%s", height, err)
+			}
+			this.deliverJsonBlock(block)
+		}
+		if needEvent && height >= eventStart {
+			data, err := this.getSmartContractEventByBlock("", uint32(height))
+			if err != nil {
+				return fmt.Errorf("get events of block:%d error:%s", height, err)
+			}
+			events, err := utils.GetSmartContactEvents(data)
+			if err != nil {
+				return fmt.Errorf("get events of block:%d error:%s", height, err)
+			}
+			for _, event := range events {
+				event = filterEventNotify(event, contracts)
+				if event != nil {
+					this.deliverEvent(event)
+				}
+			}
+			if !needTxHash {
+				//Track height of events when there is no tx hash subscription
+				state.lock.Lock()
+				if uint64(state.txHashHeight) < height {
+					state.txHashHeight = uint32(height)
+				}
+				state.lock.Unlock()
+			}
+		}
+	}
+	return nil
+}
+
+//filterEventNotify keep notifies of contracts, as websocket server does for pushed events.
+//Return nil if there is no notify left. Event is not filtered if contracts is empty.
+func filterEventNotify(event *sdkcom.SmartContactEvent, contracts []string) *sdkcom.SmartContactEvent {
+	if len(contracts) == 0 {
+		return event
+	}
+	notifies := make([]*sdkcom.NotifyEventInfo, 0, len(event.Notify))
+	for _, notify := range event.Notify {
+		for _, contract := range contracts {
+			if notify.ContractAddress == contract {
+				notifies = append(notifies, notify)
+				break
+			}
+		}
+	}
+	if len(notifies) == 0 {
+		return nil
+	}
+	filtered := *event
+	filtered.Notify = notifies
+	return &filtered
+}
+
+func getBlockTxHashes(block *types.Block) *sdkcom.BlockTxHashes {
+	txHashes := make([]common.Uint256, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		txHashes = append(txHashes, tx.Hash())
+	}
+	return &sdkcom.BlockTxHashes{
+		Hash:         block.Hash(),
+		Height:       block.Header.Height,
+		Transactions: txHashes,
+	}
+}
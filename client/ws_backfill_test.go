@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sdkcom "github.com/DNAProject/DNA-go-sdk/common"
+	"github.com/DNAProject/DNA/core/types"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWSClient_DeliverDedup(t *testing.T) {
+	wsClient := NewWSClient()
+	defer wsClient.Close()
+
+	blockSub := wsClient.newSubscription(sdkcom.WS_SUBSCRIBE_ACTION_BLOCK, nil)
+	eventSub := wsClient.newSubscription(sdkcom.WS_SUBSCRIBE_ACTION_EVENT_NOTIFY, nil)
+	wsClient.subs[blockSub.action] = []*wsSubscription{blockSub}
+	wsClient.subs[eventSub.action] = []*wsSubscription{eventSub}
+
+	for _, height := range []uint32{10, 11, 11, 10, 12} {
+		wsClient.deliverBlock(&types.Block{Header: &types.Header{Height: height}})
+	}
+	assert.Equal(t, 3, len(blockSub.queue))
+	for _, height := range []uint32{10, 11, 12} {
+		block := (<-blockSub.queue).(*types.Block)
+		assert.Equal(t, height, block.Header.Height)
+	}
+
+	for _, txHash := range []string{"a", "b", "a"} {
+		wsClient.deliverEvent(&sdkcom.SmartContactEvent{TxHash: txHash})
+	}
+	assert.Equal(t, 2, len(eventSub.queue))
+}
+
+//newBackfillTestServer serve websocket requests of block height and events of block
+func newBackfillTestServer(height uint32, events map[uint32][]*sdkcom.SmartContactEvent) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			req := make(map[string]interface{})
+			if conn.ReadJSON(&req) != nil {
+				return
+			}
+			rsp := &WSResponse{Id: req["Id"].(string), Action: req["Action"].(string), Result: json.RawMessage(`null`)}
+			switch rsp.Action {
+			case WS_ACTION_GET_BLOCK_HEIGHT:
+				rsp.Result, _ = json.Marshal(height)
+			case WS_ACTION_GET_SMARTCONTRACT_BY_HEIGHT:
+				rsp.Result, _ = json.Marshal(events[uint32(req["Height"].(float64))])
+			}
+			if conn.WriteJSON(rsp) != nil {
+				return
+			}
+		}
+	}))
+}
+
+func TestWSClient_BackfillContractFilter(t *testing.T) {
+	newNotify := func(contract string) *sdkcom.NotifyEventInfo {
+		return &sdkcom.NotifyEventInfo{ContractAddress: contract, States: "state"}
+	}
+	server := newBackfillTestServer(2, map[uint32][]*sdkcom.SmartContactEvent{
+		1: {
+			{TxHash: "a", Notify: []*sdkcom.NotifyEventInfo{newNotify("A"), newNotify("B")}},
+			{TxHash: "b", Notify: []*sdkcom.NotifyEventInfo{newNotify("B")}},
+		},
+		2: {
+			{TxHash: "c", Notify: []*sdkcom.NotifyEventInfo{}},
+			{TxHash: "d", Notify: []*sdkcom.NotifyEventInfo{newNotify("A")}},
+		},
+	})
+	defer server.Close()
+
+	wsClient := NewWSClient()
+	defer wsClient.Close()
+	assert.Nil(t, wsClient.Connect("ws"+strings.TrimPrefix(server.URL, "http")))
+	eventSub := wsClient.newSubscription(sdkcom.WS_SUBSCRIBE_ACTION_EVENT_NOTIFY, nil)
+	wsClient.subs[eventSub.action] = []*wsSubscription{eventSub}
+	wsClient.subStatus.AddContractFilter("A")
+	wsClient.delivery.txHashHeight, wsClient.delivery.txHashKnown = 1, true
+
+	//only notifies of subscribed contract are backfilled, as they are pushed by server
+	assert.Nil(t, wsClient.backfill())
+	assert.Equal(t, 2, len(eventSub.queue))
+	for _, txHash := range []string{"a", "d"} {
+		event := (<-eventSub.queue).(*sdkcom.SmartContactEvent)
+		assert.Equal(t, txHash, event.TxHash)
+		assert.Equal(t, 1, len(event.Notify))
+		assert.Equal(t, "A", event.Notify[0].ContractAddress)
+	}
+}
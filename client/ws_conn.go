@@ -0,0 +1,245 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/DNAProject/DNA-go-sdk/utils"
+)
+
+//WSConnState is the state of websocket connection
+type WSConnState int32
+
+const (
+	WS_STATE_IDLE          WSConnState = iota //Never connected
+	WS_STATE_CONNECTING                       //Dialing to server
+	WS_STATE_CONNECTED                        //Connected, requests and pushes are available
+	WS_STATE_RESUBSCRIBING                    //Reconnected, restoring subscriptions
+	WS_STATE_BACKING_OFF                      //Waiting for next reconnect attempt
+	WS_STATE_CLOSED                           //Closed by Close(), or reconnect attempts exhausted
+)
+
+func (this WSConnState) String() string {
+	switch this {
+	case WS_STATE_IDLE:
+		return "idle"
+	case WS_STATE_CONNECTING:
+		return "connecting"
+	case WS_STATE_CONNECTED:
+		return "connected"
+	case WS_STATE_RESUBSCRIBING:
+		return "resubscribing"
+	case WS_STATE_BACKING_OFF:
+		return "backing-off"
+	case WS_STATE_CLOSED:
+		return "closed"
+	default:
+		return fmt.Sprintf("unknown(%d)", int32(this))
+	}
+}
+
+var (
+	DEFAULT_WS_RECONNECT_INIT_BACKOFF = time.Second
+	DEFAULT_WS_RECONNECT_MAX_BACKOFF  = time.Minute
+	DEFAULT_WS_RECONNECT_MULTIPLIER   = 2.0
+	DEFAULT_WS_RECONNECT_JITTER       = 0.2
+	DEFAULT_WS_RECONNECT_MAX_ATTEMPTS = 0
+)
+
+//WSReconnectConfig config of reconnecting when websocket connection dropped
+type WSReconnectConfig struct {
+	InitBackoff time.Duration //Backoff before the second attempt, the first attempt is made immediately
+	MaxBackoff  time.Duration
+	Multiplier  float64  //Backoff is multiplied after each failed attempt
+	Jitter      float64  //Backoff is randomized in range [backoff*(1-Jitter), backoff*(1+Jitter)]
+	MaxAttempts int      //Max attempts of one reconnect, 0 means no limit
+	Fallbacks   []string //Fallback addresses, tried in turn after the current address
+}
+
+func NewWSReconnectConfig() *WSReconnectConfig {
+	return &WSReconnectConfig{
+		InitBackoff: DEFAULT_WS_RECONNECT_INIT_BACKOFF,
+		MaxBackoff:  DEFAULT_WS_RECONNECT_MAX_BACKOFF,
+		Multiplier:  DEFAULT_WS_RECONNECT_MULTIPLIER,
+		Jitter:      DEFAULT_WS_RECONNECT_JITTER,
+		MaxAttempts: DEFAULT_WS_RECONNECT_MAX_ATTEMPTS,
+	}
+}
+
+//backoff return the waiting time before attempt, attempt start from 0
+func (this *WSReconnectConfig) backoff(attempt int) time.Duration {
+	if attempt <= 0 {
+		return 0
+	}
+	backoff := float64(this.InitBackoff) * math.Pow(this.Multiplier, float64(attempt-1))
+	if this.MaxBackoff > 0 && backoff > float64(this.MaxBackoff) {
+		backoff = float64(this.MaxBackoff)
+	}
+	if this.Jitter > 0 {
+		backoff = backoff * (1 - this.Jitter + 2*this.Jitter*rand.Float64())
+	}
+	return time.Duration(backoff)
+}
+
+func (this *WSClient) GetReconnectConfig() *WSReconnectConfig {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.reconnectConfig
+}
+
+func (this *WSClient) SetReconnectConfig(config *WSReconnectConfig) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if config == nil {
+		config = NewWSReconnectConfig()
+	}
+	this.reconnectConfig = config
+}
+
+//GetConnState return the current state of connection
+func (this *WSClient) GetConnState() WSConnState {
+	return WSConnState(atomic.LoadInt32(&this.connState))
+}
+
+//SetOnStateChange set the callback called when state of connection changed
+func (this *WSClient) SetOnStateChange(f func(address string, state WSConnState)) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.onStateChange = f
+}
+
+func (this *WSClient) setConnState(state WSConnState) {
+	old := WSConnState(atomic.SwapInt32(&this.connState, int32(state)))
+	if old == state {
+		return
+	}
+	this.lock.RLock()
+	f := this.onStateChange
+	this.lock.RUnlock()
+	if f != nil {
+		f(this.getAddr(), state)
+	}
+}
+
+func (this *WSClient) getAddr() string {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.addr
+}
+
+func (this *WSClient) setAddr(address string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.addr = address
+}
+
+//dial connect to address, and set it as the current connection if success
+func (this *WSClient) dial(address string) error {
+	ws := utils.NewWebSocketClient()
+	ws.OnMessage = this.onMessage
+	ws.OnError = func(address string, err error) {
+		this.GetOnError()(address, err)
+	}
+	ws.OnConnect = func(address string) {
+		this.GetOnConnect()(address)
+	}
+	ws.OnClose = func(address string) {
+		this.GetOnClose()(address)
+		this.onWsClosed(ws)
+	}
+	err := ws.Connect(address)
+	if err != nil {
+		return err
+	}
+	this.setAddr(address)
+	this.setWsClient(ws)
+	return nil
+}
+
+//onWsClosed start reconnecting if connection is dropped by server or network
+func (this *WSClient) onWsClosed(ws *utils.WebSocketClient) {
+	if this.getWsClient() != ws || this.GetConnState() != WS_STATE_CONNECTED {
+		return
+	}
+	go this.reconnect()
+}
+
+//dropConnection close the current connection, and fail all of pending requests
+func (this *WSClient) dropConnection() {
+	ws := this.getWsClient()
+	if ws != nil {
+		this.setWsClient(nil)
+		ws.OnMessage = nil
+		err := ws.Close()
+		if err != nil {
+			this.GetOnError()(this.getAddr(), fmt.Errorf("close error:%s", err))
+		}
+	}
+	this.failPendingReqs(ERR_WS_DISCONNECTED)
+}
+
+func (this *WSClient) failPendingReqs(err error) {
+	this.lock.Lock()
+	reqs := this.reqMap
+	this.reqMap = make(map[string]*WSRequest)
+	this.lock.Unlock()
+	for _, req := range reqs {
+		select {
+		case req.ErrCh <- err:
+		default:
+		}
+	}
+}
+
+//reconnect try the current address and fallback addresses in turn with backoff, until connected or attempts exhausted
+func (this *WSClient) reconnect() {
+	if !atomic.CompareAndSwapInt32(&this.reconnecting, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&this.reconnecting, 0)
+
+	this.dropConnection()
+	config := this.GetReconnectConfig()
+	addrs := []string{this.getAddr()}
+	for _, address := range config.Fallbacks {
+		if address != addrs[0] {
+			addrs = append(addrs, address)
+		}
+	}
+	for attempt := 0; config.MaxAttempts <= 0 || attempt < config.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			this.setConnState(WS_STATE_BACKING_OFF)
+			select {
+			case <-time.After(config.backoff(attempt)):
+			case <-this.exitCh:
+				return
+			}
+		}
+		address := addrs[attempt%len(addrs)]
+		this.setConnState(WS_STATE_CONNECTING)
+		err := this.dial(address)
+		if err != nil {
+			this.GetOnError()(address, fmt.Errorf("connect error:%s", err))
+			continue
+		}
+		this.setConnState(WS_STATE_RESUBSCRIBING)
+		err = this.reSubscribe()
+		if err != nil {
+			this.GetOnError()(address, fmt.Errorf("reSubscribe:%v error:%s", this.subStatus, err))
+			this.dropConnection()
+			continue
+		}
+		this.updateLastRecvTime()
+		this.setConnState(WS_STATE_CONNECTED)
+		this.pushQueue.put(&WSResponse{Action: WS_ACTION_BACKFILL})
+		return
+	}
+	this.setConnState(WS_STATE_CLOSED)
+	this.GetOnError()(this.getAddr(), fmt.Errorf("reconnect failed after %d attempts", config.MaxAttempts))
+}
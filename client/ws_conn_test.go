@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWSReconnectConfig_Backoff(t *testing.T) {
+	config := &WSReconnectConfig{
+		InitBackoff: time.Second,
+		MaxBackoff:  5 * time.Second,
+		Multiplier:  2,
+		Jitter:      0.2,
+	}
+	assert.Equal(t, time.Duration(0), config.backoff(0))
+	for i := 0; i < 10; i++ {
+		backoff := config.backoff(2)
+		assert.True(t, backoff >= 1600*time.Millisecond && backoff <= 2400*time.Millisecond)
+		backoff = config.backoff(10)
+		assert.True(t, backoff >= 4*time.Second && backoff <= 6*time.Second)
+	}
+}
+
+func TestWSClient_FailPendingReqs(t *testing.T) {
+	wsClient := NewWSClient()
+	defer wsClient.Close()
+	assert.Equal(t, WS_STATE_IDLE, wsClient.GetConnState())
+
+	_, err := wsClient.sendSyncWSRequest("", WS_ACTION_GET_BLOCK_HEIGHT, nil)
+	assert.True(t, errors.Is(err, ERR_WS_DISCONNECTED))
+	assert.True(t, IsTransportError(err))
+
+	req := &WSRequest{Id: "1", ResCh: make(chan *WSResponse, 1), ErrCh: make(chan error, 1)}
+	wsClient.addReq(req)
+	wsClient.failPendingReqs(ERR_WS_DISCONNECTED)
+	assert.Equal(t, ERR_WS_DISCONNECTED, <-req.ErrCh)
+	assert.Nil(t, wsClient.getReq("1"))
+}
@@ -0,0 +1,519 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sdkcom "github.com/DNAProject/DNA-go-sdk/common"
+	"github.com/DNAProject/DNA-go-sdk/utils"
+	"github.com/DNAProject/DNA/core/types"
+)
+
+//BackpressurePolicy decide what to do when buffer of subscription is full
+type BackpressurePolicy int
+
+const (
+	BACKPRESSURE_BLOCK       BackpressurePolicy = iota //Wait for subscriber, slow subscriber delays other subscribers of the same action
+	BACKPRESSURE_DROP_NEWEST                           //Drop the pushed one
+	BACKPRESSURE_DROP_OLDEST                           //Drop the oldest one in buffer
+)
+
+var DEFAULT_WS_SUBSCRIPTION_BUFFER_SIZE = 64
+
+//WSSubscriptionConfig config of websocket subscription
+type WSSubscriptionConfig struct {
+	BufferSize int
+	Policy     BackpressurePolicy
+}
+
+func NewWSSubscriptionConfig() *WSSubscriptionConfig {
+	return &WSSubscriptionConfig{
+		BufferSize: DEFAULT_WS_SUBSCRIPTION_BUFFER_SIZE,
+		Policy:     BACKPRESSURE_BLOCK,
+	}
+}
+
+type wsSubscription struct {
+	id        uint64
+	action    string
+	client    *WSClient
+	policy    BackpressurePolicy
+	queue     chan interface{}
+	dropped   uint64
+	exitCh    chan interface{}
+	closeOnce sync.Once
+}
+
+func (this *WSClient) newSubscription(action string, config *WSSubscriptionConfig) *wsSubscription {
+	if config == nil {
+		config = NewWSSubscriptionConfig()
+	}
+	bufferSize := config.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+	return &wsSubscription{
+		id:     atomic.AddUint64(&this.nextSubId, 1),
+		action: action,
+		client: this,
+		policy: config.Policy,
+		queue:  make(chan interface{}, bufferSize),
+		exitCh: make(chan interface{}),
+	}
+}
+
+func (this *wsSubscription) push(item interface{}) {
+	switch this.policy {
+	case BACKPRESSURE_DROP_NEWEST:
+		select {
+		case this.queue <- item:
+		case <-this.exitCh:
+		default:
+			atomic.AddUint64(&this.dropped, 1)
+		}
+	case BACKPRESSURE_DROP_OLDEST:
+		for {
+			select {
+			case this.queue <- item:
+				return
+			case <-this.exitCh:
+				return
+			default:
+			}
+			select {
+			case <-this.queue:
+				atomic.AddUint64(&this.dropped, 1)
+			default:
+			}
+		}
+	default:
+		select {
+		case this.queue <- item:
+		case <-this.exitCh:
+		case <-this.client.exitCh:
+		}
+	}
+}
+
+//run forward items in queue to typed channel by send, and call closeCh after subscription closed
+func (this *wsSubscription) run(send func(item interface{}), closeCh func()) {
+	defer closeCh()
+	for {
+		select {
+		case item := <-this.queue:
+			send(item)
+		case <-this.exitCh:
+			return
+		case <-this.client.exitCh:
+			return
+		}
+	}
+}
+
+//Dropped return the count of items dropped by backpressure policy
+func (this *wsSubscription) Dropped() uint64 {
+	return atomic.LoadUint64(&this.dropped)
+}
+
+//Close stop the subscription and close its channel.
+//Server subscription is cancelled when the last subscription of the same action closed.
+func (this *wsSubscription) Close() error {
+	closed := false
+	this.closeOnce.Do(func() {
+		close(this.exitCh)
+		closed = true
+	})
+	if !closed {
+		return nil
+	}
+	this.client.delSubscription(this)
+	return this.client.syncSubscribeStatus()
+}
+
+//BlockSubscription receive blocks pushed by websocket server
+type BlockSubscription struct {
+	*wsSubscription
+	ch chan *types.Block
+}
+
+func (this *BlockSubscription) Blocks() <-chan *types.Block {
+	return this.ch
+}
+
+//JsonBlockSubscription receive json blocks pushed by websocket server
+type JsonBlockSubscription struct {
+	*wsSubscription
+	ch chan *sdkcom.BlockInfo
+}
+
+func (this *JsonBlockSubscription) Blocks() <-chan *sdkcom.BlockInfo {
+	return this.ch
+}
+
+//BlockWithEventsSubscription receive blocks pushed by websocket server, paired with smart contract events of block
+type BlockWithEventsSubscription struct {
+	*wsSubscription
+	ch chan *BlockWithEvents
+}
+
+func (this *BlockWithEventsSubscription) Blocks() <-chan *BlockWithEvents {
+	return this.ch
+}
+
+//BlockTxHashSubscription receive tx hashes of blocks pushed by websocket server
+type BlockTxHashSubscription struct {
+	*wsSubscription
+	ch chan *sdkcom.BlockTxHashes
+}
+
+func (this *BlockTxHashSubscription) BlockTxHashes() <-chan *sdkcom.BlockTxHashes {
+	return this.ch
+}
+
+//EventSubscription receive smart contract notify events pushed by websocket server
+type EventSubscription struct {
+	*wsSubscription
+	ch chan *sdkcom.SmartContactEvent
+}
+
+func (this *EventSubscription) Events() <-chan *sdkcom.SmartContactEvent {
+	return this.ch
+}
+
+//EventLogSubscription receive smart contract logs pushed by websocket server
+type EventLogSubscription struct {
+	*wsSubscription
+	ch chan *sdkcom.SmartContractEventLog
+}
+
+func (this *EventLogSubscription) Logs() <-chan *sdkcom.SmartContractEventLog {
+	return this.ch
+}
+
+//SubscribeBlock return a new subscription of block. If config is nil, NewWSSubscriptionConfig() is used.
+func (this *WSClient) SubscribeBlock(config *WSSubscriptionConfig) (*BlockSubscription, error) {
+	sub := &BlockSubscription{
+		wsSubscription: this.newSubscription(sdkcom.WS_SUBSCRIBE_ACTION_BLOCK, config),
+		ch:             make(chan *types.Block),
+	}
+	err := this.addSubscription(sub.wsSubscription)
+	if err != nil {
+		return nil, err
+	}
+	go sub.run(func(item interface{}) {
+		select {
+		case sub.ch <- item.(*types.Block):
+		case <-sub.exitCh:
+		case <-this.exitCh:
+		}
+	}, func() { close(sub.ch) })
+	return sub, nil
+}
+
+//SubscribeJsonBlock return a new subscription of json block. If config is nil, NewWSSubscriptionConfig() is used.
+func (this *WSClient) SubscribeJsonBlock(config *WSSubscriptionConfig) (*JsonBlockSubscription, error) {
+	sub := &JsonBlockSubscription{
+		wsSubscription: this.newSubscription(sdkcom.WS_SUBSCRIBE_ACTION_JSON_BLOCK, config),
+		ch:             make(chan *sdkcom.BlockInfo),
+	}
+	err := this.addSubscription(sub.wsSubscription)
+	if err != nil {
+		return nil, err
+	}
+	go sub.run(func(item interface{}) {
+		select {
+		case sub.ch <- item.(*sdkcom.BlockInfo):
+		case <-sub.exitCh:
+		case <-this.exitCh:
+		}
+	}, func() { close(sub.ch) })
+	return sub, nil
+}
+
+//SubscribeBlockWithEvents return a new subscription of block, each block is paired with its smart contract events,
+//which are fetched by websocket request. If events cannot be fetched after retry, the block is dropped and error is
+//reported by OnError, so subscriber should check continuity of height.
+func (this *WSClient) SubscribeBlockWithEvents(config *WSSubscriptionConfig) (*BlockWithEventsSubscription, error) {
+	sub := &BlockWithEventsSubscription{
+		wsSubscription: this.newSubscription(sdkcom.WS_SUBSCRIBE_ACTION_BLOCK, config),
+		ch:             make(chan *BlockWithEvents),
+	}
+	err := this.addSubscription(sub.wsSubscription)
+	if err != nil {
+		return nil, err
+	}
+	go sub.run(func(item interface{}) {
+		block := item.(*types.Block)
+		events, err := this.getBlockEvents(block.Header.Height, sub.exitCh)
+		if err != nil {
+			this.GetOnError()(this.getAddr(), fmt.Errorf("get events of block:%d error:%s", block.Header.Height, err))
+			return
+		}
+		select {
+		case sub.ch <- &BlockWithEvents{Height: block.Header.Height, Block: block, Events: events}:
+		case <-sub.exitCh:
+		case <-this.exitCh:
+		}
+	}, func() { close(sub.ch) })
+	return sub, nil
+}
+
+func (this *WSClient) getBlockEvents(height uint32, exitCh chan interface{}) ([]*sdkcom.SmartContactEvent, error) {
+	var err error
+	for i := 0; i <= DEFAULT_BLOCK_ITERATOR_MAX_RETRY; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(DEFAULT_BLOCK_ITERATOR_RETRY_INTERVAL):
+			case <-exitCh:
+				return nil, fmt.Errorf("subscription closed")
+			}
+		}
+		var data []byte
+		data, err = this.getSmartContractEventByBlock("", height)
+		if err != nil {
+			continue
+		}
+		var events []*sdkcom.SmartContactEvent
+		events, err = utils.GetSmartContactEvents(data)
+		if err == nil {
+			return events, nil
+		}
+	}
+	return nil, err
+}
+
+//SubscribeTxHash return a new subscription of block tx hashes. If config is nil, NewWSSubscriptionConfig() is used.
+func (this *WSClient) SubscribeTxHash(config *WSSubscriptionConfig) (*BlockTxHashSubscription, error) {
+	sub := &BlockTxHashSubscription{
+		wsSubscription: this.newSubscription(sdkcom.WS_SUBSCRIBE_ACTION_BLOCK_TX_HASH, config),
+		ch:             make(chan *sdkcom.BlockTxHashes),
+	}
+	err := this.addSubscription(sub.wsSubscription)
+	if err != nil {
+		return nil, err
+	}
+	go sub.run(func(item interface{}) {
+		select {
+		case sub.ch <- item.(*sdkcom.BlockTxHashes):
+		case <-sub.exitCh:
+		case <-this.exitCh:
+		}
+	}, func() { close(sub.ch) })
+	return sub, nil
+}
+
+//SubscribeEvent return a new subscription of smart contract notify event. If config is nil, NewWSSubscriptionConfig() is used.
+func (this *WSClient) SubscribeEvent(config *WSSubscriptionConfig) (*EventSubscription, error) {
+	sub := &EventSubscription{
+		wsSubscription: this.newSubscription(sdkcom.WS_SUBSCRIBE_ACTION_EVENT_NOTIFY, config),
+		ch:             make(chan *sdkcom.SmartContactEvent),
+	}
+	err := this.addSubscription(sub.wsSubscription)
+	if err != nil {
+		return nil, err
+	}
+	go sub.run(func(item interface{}) {
+		select {
+		case sub.ch <- item.(*sdkcom.SmartContactEvent):
+		case <-sub.exitCh:
+		case <-this.exitCh:
+		}
+	}, func() { close(sub.ch) })
+	return sub, nil
+}
+
+//SubscribeEventLog return a new subscription of smart contract log. If config is nil, NewWSSubscriptionConfig() is used.
+func (this *WSClient) SubscribeEventLog(config *WSSubscriptionConfig) (*EventLogSubscription, error) {
+	sub := &EventLogSubscription{
+		wsSubscription: this.newSubscription(sdkcom.WS_SUBSCRIBE_ACTION_EVENT_LOG, config),
+		ch:             make(chan *sdkcom.SmartContractEventLog),
+	}
+	err := this.addSubscription(sub.wsSubscription)
+	if err != nil {
+		return nil, err
+	}
+	go sub.run(func(item interface{}) {
+		select {
+		case sub.ch <- item.(*sdkcom.SmartContractEventLog):
+		case <-sub.exitCh:
+		case <-this.exitCh:
+		}
+	}, func() { close(sub.ch) })
+	return sub, nil
+}
+
+//FilteredEventSubscription receive notifies matched by EventFilter pushed by websocket server
+type FilteredEventSubscription struct {
+	*wsSubscription
+	filter *EventFilter
+	ch     chan *FilteredNotify
+}
+
+func (this *FilteredEventSubscription) Notifies() <-chan *FilteredNotify {
+	return this.ch
+}
+
+//SubscribeFilteredEvent return a new subscription of smart contract notify matched by filter.
+//Height of matched notify is queried by tx hash, because it's not included in pushed event.
+func (this *WSClient) SubscribeFilteredEvent(filter *EventFilter, config *WSSubscriptionConfig) (*FilteredEventSubscription, error) {
+	sub := &FilteredEventSubscription{
+		wsSubscription: this.newSubscription(sdkcom.WS_SUBSCRIBE_ACTION_EVENT_NOTIFY, config),
+		filter:         filter,
+		ch:             make(chan *FilteredNotify),
+	}
+	err := this.addSubscription(sub.wsSubscription)
+	if err != nil {
+		return nil, err
+	}
+	go sub.run(func(item interface{}) {
+		event := item.(*sdkcom.SmartContactEvent)
+		notifies := sub.filter.FilterEvent(event, 0)
+		if len(notifies) == 0 {
+			return
+		}
+		height, err := this.getEventHeight(event.TxHash)
+		if err != nil {
+			this.GetOnError()(this.getAddr(), fmt.Errorf("get height of tx:%s error:%s", event.TxHash, err))
+		}
+		for _, notify := range notifies {
+			notify.Height = height
+			select {
+			case sub.ch <- notify:
+			case <-sub.exitCh:
+				return
+			case <-this.exitCh:
+				return
+			}
+		}
+	}, func() { close(sub.ch) })
+	return sub, nil
+}
+
+func (this *WSClient) getEventHeight(txHash string) (uint32, error) {
+	data, err := this.getBlockHeightByTxHash("", txHash)
+	if err != nil {
+		return 0, err
+	}
+	return utils.GetUint32(data)
+}
+
+//UnsubscribeBlock close all of block subscriptions, including block with events subscriptions
+func (this *WSClient) UnsubscribeBlock() error {
+	return this.closeSubscriptions(sdkcom.WS_SUBSCRIBE_ACTION_BLOCK)
+}
+
+//UnsubscribeJsonBlock close all of json block subscriptions
+func (this *WSClient) UnsubscribeJsonBlock() error {
+	return this.closeSubscriptions(sdkcom.WS_SUBSCRIBE_ACTION_JSON_BLOCK)
+}
+
+//UnsubscribeTxHash close all of block tx hashes subscriptions
+func (this *WSClient) UnsubscribeTxHash() error {
+	return this.closeSubscriptions(sdkcom.WS_SUBSCRIBE_ACTION_BLOCK_TX_HASH)
+}
+
+//UnsubscribeEvent close all of notify event and log subscriptions
+func (this *WSClient) UnsubscribeEvent() error {
+	err := this.closeSubscriptions(sdkcom.WS_SUBSCRIBE_ACTION_EVENT_NOTIFY)
+	if err != nil {
+		return err
+	}
+	return this.closeSubscriptions(sdkcom.WS_SUBSCRIBE_ACTION_EVENT_LOG)
+}
+
+func (this *WSClient) addSubscription(sub *wsSubscription) error {
+	this.subLock.Lock()
+	this.subs[sub.action] = append(this.subs[sub.action], sub)
+	this.subLock.Unlock()
+	err := this.syncSubscribeStatus()
+	if err != nil {
+		sub.closeOnce.Do(func() { close(sub.exitCh) })
+		this.delSubscription(sub)
+		return err
+	}
+	this.initDeliveryHeight(sub.action)
+	return nil
+}
+
+func (this *WSClient) delSubscription(sub *wsSubscription) {
+	this.subLock.Lock()
+	defer this.subLock.Unlock()
+	subs := this.subs[sub.action]
+	for i, s := range subs {
+		if s.id == sub.id {
+			this.subs[sub.action] = append(subs[:i:i], subs[i+1:]...)
+			break
+		}
+	}
+}
+
+func (this *WSClient) getSubscriptions(action string) []*wsSubscription {
+	this.subLock.RLock()
+	defer this.subLock.RUnlock()
+	subs := make([]*wsSubscription, len(this.subs[action]))
+	copy(subs, this.subs[action])
+	return subs
+}
+
+func (this *WSClient) hasSubscription(actions ...string) bool {
+	this.subLock.RLock()
+	defer this.subLock.RUnlock()
+	for _, action := range actions {
+		if len(this.subs[action]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (this *WSClient) closeSubscriptions(action string) error {
+	for _, sub := range this.getSubscriptions(action) {
+		err := sub.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//dispatchAction push result to all of subscriptions of action
+func (this *WSClient) dispatchAction(action string, result interface{}) {
+	for _, sub := range this.getSubscriptions(action) {
+		sub.push(result)
+	}
+	if this.isLegacyActionEnabled() {
+		select {
+		case this.actionCh <- &WSAction{Action: action, Result: result}:
+		case <-this.exitCh:
+		}
+	}
+}
+
+//syncSubscribeStatus update subscribe status of websocket server according to current subscriptions
+func (this *WSClient) syncSubscribeStatus() error {
+	this.subStatusLock.Lock()
+	defer this.subStatusLock.Unlock()
+	status := *this.subStatus
+	status.SubscribeRawBlock = this.hasSubscription(sdkcom.WS_SUBSCRIBE_ACTION_BLOCK)
+	status.SubscribeJsonBlock = this.hasSubscription(sdkcom.WS_SUBSCRIBE_ACTION_JSON_BLOCK)
+	status.SubscribeBlockTxHashes = this.hasSubscription(sdkcom.WS_SUBSCRIBE_ACTION_BLOCK_TX_HASH) ||
+		(this.IsBackfillOnReconnect() && this.hasSubscription(sdkcom.WS_SUBSCRIBE_ACTION_EVENT_NOTIFY))
+	status.SubscribeEvent = this.hasSubscription(sdkcom.WS_SUBSCRIBE_ACTION_EVENT_NOTIFY, sdkcom.WS_SUBSCRIBE_ACTION_EVENT_LOG)
+	if status.SubscribeRawBlock == this.subStatus.SubscribeRawBlock &&
+		status.SubscribeJsonBlock == this.subStatus.SubscribeJsonBlock &&
+		status.SubscribeBlockTxHashes == this.subStatus.SubscribeBlockTxHashes &&
+		status.SubscribeEvent == this.subStatus.SubscribeEvent {
+		return nil
+	}
+	err := this.sendSubscribe(&status)
+	if err != nil {
+		return err
+	}
+	*this.subStatus = status
+	return nil
+}
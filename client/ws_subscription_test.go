@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWSSubscription_Backpressure(t *testing.T) {
+	wsClient := NewWSClient()
+	defer wsClient.Close()
+
+	dropNewest := wsClient.newSubscription("test", &WSSubscriptionConfig{BufferSize: 2, Policy: BACKPRESSURE_DROP_NEWEST})
+	dropOldest := wsClient.newSubscription("test", &WSSubscriptionConfig{BufferSize: 2, Policy: BACKPRESSURE_DROP_OLDEST})
+	for i := 0; i < 5; i++ {
+		dropNewest.push(i)
+		dropOldest.push(i)
+	}
+	assert.Equal(t, uint64(3), dropNewest.Dropped())
+	assert.Equal(t, uint64(3), dropOldest.Dropped())
+	assert.Equal(t, 0, <-dropNewest.queue)
+	assert.Equal(t, 1, <-dropNewest.queue)
+	assert.Equal(t, 3, <-dropOldest.queue)
+	assert.Equal(t, 4, <-dropOldest.queue)
+}
+
+func TestWSClient_EnableActionCh(t *testing.T) {
+	wsClient := NewWSClient()
+	defer wsClient.Close()
+
+	wsClient.dispatchAction("test", 1)
+	assert.Equal(t, 0, len(wsClient.actionCh))
+	wsClient.EnableActionCh()
+	wsClient.dispatchAction("test", 2)
+	action := <-wsClient.GetActionCh()
+	assert.Equal(t, "test", action.Action)
+	assert.Equal(t, 2, action.Result)
+}
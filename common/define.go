@@ -38,6 +38,7 @@ var (
 
 const (
 	WS_SUBSCRIBE_ACTION_BLOCK         = "Block"
+	WS_SUBSCRIBE_ACTION_JSON_BLOCK    = "JsonBlock"
 	WS_SUBSCRIBE_ACTION_EVENT_NOTIFY  = "Notify"
 	WS_SUBSCRIBE_ACTION_EVENT_LOG     = "Log"
 	WS_SUBSCRIBE_ACTION_BLOCK_TX_HASH = "BlockTxHash"
@@ -266,6 +267,49 @@ type BlockTxHashesStr struct {
 	Transactions []string
 }
 
+//BlockInfo is block in json format, which is pushed by websocket server when subscribe json block
+type BlockInfo struct {
+	Hash         string
+	Size         int
+	Header       *BlockHeaderInfo
+	Transactions []*TransactionInfo
+}
+
+type BlockHeaderInfo struct {
+	Version          uint32
+	PrevBlockHash    string
+	TransactionsRoot string
+	BlockRoot        string
+	Timestamp        uint32
+	Height           uint32
+	ConsensusData    uint64
+	ConsensusPayload string
+	NextBookkeeper   string
+	Bookkeepers      []string
+	SigData          []string
+	Hash             string
+}
+
+type TransactionInfo struct {
+	Version    byte
+	Nonce      uint32
+	GasPrice   uint64
+	GasLimit   uint64
+	Payer      string
+	TxType     byte
+	Payload    json.RawMessage //InvokeCode is {"Code":"hex string"}
+	Attributes []json.RawMessage
+	Sigs       []*SigInfo
+	Hash       string
+	Height     uint32
+}
+
+type SigInfo struct {
+	PubKeys []string
+	M       uint16
+	SigData []string
+}
+
 type MemPoolTxState struct {
 	State []*MemPoolTxStateItem
 }
@@ -285,3 +329,9 @@ type GlobalParam struct {
 	Key   string
 	Value string
 }
+
+//BalanceInfo is the balance of native assets of account
+type BalanceInfo struct {
+	Height   uint32            //Block height of the balance
+	Balances map[string]uint64 //Balance by asset name, such as "gas"
+}
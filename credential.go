@@ -0,0 +1,560 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DNAProject/DNA/common"
+	"github.com/ontio/ontology-crypto/keypair"
+	s "github.com/ontio/ontology-crypto/signature"
+)
+
+const (
+	CREDENTIAL_CONTEXT    = "https://www.w3.org/2018/credentials/v1"
+	CREDENTIAL_TYPE       = "VerifiableCredential"
+	CREDENTIAL_PROOF_TYPE = "JsonWebSignature2020"
+
+	PROOF_PURPOSE_ASSERTION      = "assertionMethod"
+	PROOF_PURPOSE_AUTHENTICATION = "authentication"
+)
+
+//CREDENTIAL_REGISTRY_METHOD_IS_REVOKED is the method of revocation registry contract,
+//which takes credential id as param and returns bool.
+const CREDENTIAL_REGISTRY_METHOD_IS_REVOKED = "isRevoked"
+
+//JWS algorithm by signature scheme. SM2 is not registered in JOSE, so a private name is used.
+var JWS_ALGORITHMS = map[s.SignatureScheme]string{
+	s.SHA256withECDSA: "ES256",
+	s.SHA384withECDSA: "ES384",
+	s.SHA512withECDSA: "ES512",
+	s.SHA512withEdDSA: "EdDSA",
+	s.SM3withSM2:      "SM2SM3",
+}
+
+//ERR_INVALID_CREDENTIAL is returned when credential or its encoding is malformed
+var ERR_INVALID_CREDENTIAL = errors.New("invalid credential")
+
+//ERR_CREDENTIAL_SIGNATURE is returned when signature of credential is invalid
+var ERR_CREDENTIAL_SIGNATURE = errors.New("invalid credential signature")
+
+//ERR_CREDENTIAL_EXPIRED is returned when credential is expired
+var ERR_CREDENTIAL_EXPIRED = errors.New("credential expired")
+
+//ERR_CREDENTIAL_NOT_YET_VALID is returned when issuance date of credential is in future
+var ERR_CREDENTIAL_NOT_YET_VALID = errors.New("credential not yet valid")
+
+//ERR_CREDENTIAL_REVOKED is returned when credential is revoked in revocation registry
+var ERR_CREDENTIAL_REVOKED = errors.New("credential revoked")
+
+//ERR_KEY_NOT_FOUND is returned when verification key is not a public key of identity
+var ERR_KEY_NOT_FOUND = errors.New("key not found")
+
+//ERR_KEY_REVOKED is returned when verification key has been revoked from identity
+var ERR_KEY_REVOKED = errors.New("key revoked")
+
+//CredentialStatus is the information of revocation status of credential
+type CredentialStatus struct {
+	Id   string `json:"id"`
+	Type string `json:"type"`
+}
+
+//CredentialProof is the JSON-LD proof of credential or presentation. Jws is a detached JWS with unencoded
+//payload (RFC 7797), and the payload is the canonical JSON of credential or presentation with Jws of proof empty.
+type CredentialProof struct {
+	Type               string `json:"type"`
+	Created            string `json:"created"`
+	VerificationMethod string `json:"verificationMethod"`
+	ProofPurpose       string `json:"proofPurpose"`
+	Challenge          string `json:"challenge,omitempty"` //Nonce of verifier, only in proof of presentation
+	Domain             string `json:"domain,omitempty"`    //Audience of presentation
+	Jws                string `json:"jws"`
+}
+
+//VerifiableCredential is W3C verifiable credential issued by identity
+type VerifiableCredential struct {
+	Context           []string               `json:"@context"`
+	Id                string                 `json:"id,omitempty"`
+	Type              []string               `json:"type"`
+	Issuer            string                 `json:"issuer"`
+	IssuanceDate      string                 `json:"issuanceDate"`
+	ExpirationDate    string                 `json:"expirationDate,omitempty"`
+	CredentialSubject map[string]interface{} `json:"credentialSubject"`
+	CredentialStatus  *CredentialStatus      `json:"credentialStatus,omitempty"`
+	Proof             *CredentialProof       `json:"proof,omitempty"`
+}
+
+//NewVerifiableCredential return unsigned credential with random id. Credential never expires if expiration is zero.
+func NewVerifiableCredential(issuer string, types []string, subject map[string]interface{}, expiration time.Time) (*VerifiableCredential, error) {
+	id, err := newUrnUUID()
+	if err != nil {
+		return nil, err
+	}
+	vc := &VerifiableCredential{
+		Context:           []string{CREDENTIAL_CONTEXT},
+		Id:                id,
+		Type:              append([]string{CREDENTIAL_TYPE}, types...),
+		Issuer:            issuer,
+		IssuanceDate:      formatCredentialTime(time.Now()),
+		CredentialSubject: subject,
+	}
+	if !expiration.IsZero() {
+		vc.ExpirationDate = formatCredentialTime(expiration)
+	}
+	return vc, nil
+}
+
+//GetSubjectId return id of credential subject, empty if absent
+func (this *VerifiableCredential) GetSubjectId() string {
+	id, _ := this.CredentialSubject["id"].(string)
+	return id
+}
+
+func newUrnUUID() (string, error) {
+	var buf [16]byte
+	_, err := rand.Read(buf[:])
+	if err != nil {
+		return "", fmt.Errorf("generate uuid error:%s", err)
+	}
+	buf[6] = (buf[6] & 0x0f) | 0x40
+	buf[8] = (buf[8] & 0x3f) | 0x80
+	h := hex.EncodeToString(buf[:])
+	return fmt.Sprintf("urn:uuid:%s-%s-%s-%s-%s", h[0:8], h[8:12], h[12:16], h[16:20], h[20:]), nil
+}
+
+func formatCredentialTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+type jwsHeader struct {
+	Alg  string   `json:"alg"`
+	Typ  string   `json:"typ,omitempty"`
+	Kid  string   `json:"kid,omitempty"`
+	B64  *bool    `json:"b64,omitempty"`
+	Crit []string `json:"crit,omitempty"`
+}
+
+//credentialJWTClaims is the JWT claims of credential, see https://www.w3.org/TR/vc-data-model/#jwt-encoding
+type credentialJWTClaims struct {
+	Issuer     string                `json:"iss"`
+	Subject    string                `json:"sub,omitempty"`
+	Id         string                `json:"jti,omitempty"`
+	NotBefore  int64                 `json:"nbf"`
+	Expiry     int64                 `json:"exp,omitempty"`
+	Credential *VerifiableCredential `json:"vc"`
+}
+
+//SignCredentialJWT encode credential as JWT signed by key of issuer.
+//keyId is the id of public key in DID document of issuer, such as did:ont:xxx#keys-1.
+func SignCredentialJWT(vc *VerifiableCredential, signer Signer, keyId string) (string, error) {
+	err := checkKeyIdOfIssuer(vc, keyId)
+	if err != nil {
+		return "", err
+	}
+	claims := &credentialJWTClaims{
+		Issuer:  vc.Issuer,
+		Subject: vc.GetSubjectId(),
+		Id:      vc.Id,
+	}
+	claims.NotBefore, claims.Expiry, err = getCredentialValidity(vc)
+	if err != nil {
+		return "", err
+	}
+	unsigned := *vc
+	unsigned.Proof = nil
+	claims.Credential = &unsigned
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("marshal claims error:%s", err)
+	}
+	return signJWS(signer, &jwsHeader{Typ: "JWT", Kid: keyId}, payload)
+}
+
+//SignCredential add JSON-LD proof to credential, signed by key of issuer
+func SignCredential(vc *VerifiableCredential, signer Signer, keyId string) error {
+	err := checkKeyIdOfIssuer(vc, keyId)
+	if err != nil {
+		return err
+	}
+	proof := &CredentialProof{
+		Type:               CREDENTIAL_PROOF_TYPE,
+		Created:            formatCredentialTime(time.Now()),
+		VerificationMethod: keyId,
+		ProofPurpose:       PROOF_PURPOSE_ASSERTION,
+	}
+	vc.Proof = proof
+	payload, err := canonicalJSON(vc)
+	if err != nil {
+		vc.Proof = nil
+		return err
+	}
+	jws, err := signDetachedJWS(signer, payload)
+	if err != nil {
+		vc.Proof = nil
+		return err
+	}
+	proof.Jws = jws
+	return nil
+}
+
+func checkKeyIdOfIssuer(vc *VerifiableCredential, keyId string) error {
+	if getDIDOfKeyId(keyId) != vc.Issuer {
+		return fmt.Errorf("%w, key:%s is not a key of issuer:%s", ERR_INVALID_CREDENTIAL, keyId, vc.Issuer)
+	}
+	return nil
+}
+
+//getDIDOfKeyId return DID part of key id
+func getDIDOfKeyId(keyId string) string {
+	pos := strings.Index(keyId, "#")
+	if pos < 0 {
+		return keyId
+	}
+	return keyId[:pos]
+}
+
+//getCredentialValidity return unix time of issuance and expiration of credential, expiration is 0 if absent
+func getCredentialValidity(vc *VerifiableCredential) (int64, int64, error) {
+	issuance, err := time.Parse(time.RFC3339, vc.IssuanceDate)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w, issuanceDate:%s error:%s", ERR_INVALID_CREDENTIAL, vc.IssuanceDate, err)
+	}
+	if vc.ExpirationDate == "" {
+		return issuance.Unix(), 0, nil
+	}
+	expiration, err := time.Parse(time.RFC3339, vc.ExpirationDate)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w, expirationDate:%s error:%s", ERR_INVALID_CREDENTIAL, vc.ExpirationDate, err)
+	}
+	return issuance.Unix(), expiration.Unix(), nil
+}
+
+//canonicalJSON encode v as JSON with sorted object keys, so that signer and verifier get the same bytes
+func canonicalJSON(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal error:%s", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	err = decoder.Decode(&value)
+	if err != nil {
+		return nil, fmt.Errorf("json unmarshal error:%s", err)
+	}
+	return json.Marshal(value)
+}
+
+func getJWSAlgorithm(scheme s.SignatureScheme) (string, error) {
+	alg, ok := JWS_ALGORITHMS[scheme]
+	if !ok {
+		return "", fmt.Errorf("unsupported signature scheme:%s of JWS", scheme.Name())
+	}
+	return alg, nil
+}
+
+func getJWSScheme(alg string) (s.SignatureScheme, error) {
+	for scheme, name := range JWS_ALGORITHMS {
+		if name == alg {
+			return scheme, nil
+		}
+	}
+	return 0, fmt.Errorf("%w, unsupported JWS alg:%s", ERR_INVALID_CREDENTIAL, alg)
+}
+
+//signJWS return compact JWS of payload
+func signJWS(signer Signer, header *jwsHeader, payload []byte) (string, error) {
+	alg, err := getJWSAlgorithm(signer.GetSigScheme())
+	if err != nil {
+		return "", err
+	}
+	header.Alg = alg
+	headerData, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("marshal JWS header error:%s", err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(headerData) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sig, err := signJWSInput(signer, []byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+//signDetachedJWS return detached JWS with unencoded payload
+func signDetachedJWS(signer Signer, payload []byte) (string, error) {
+	alg, err := getJWSAlgorithm(signer.GetSigScheme())
+	if err != nil {
+		return "", err
+	}
+	b64 := false
+	headerData, err := json.Marshal(&jwsHeader{Alg: alg, B64: &b64, Crit: []string{"b64"}})
+	if err != nil {
+		return "", fmt.Errorf("marshal JWS header error:%s", err)
+	}
+	header := base64.RawURLEncoding.EncodeToString(headerData)
+	sig, err := signJWSInput(signer, append([]byte(header+"."), payload...))
+	if err != nil {
+		return "", err
+	}
+	return header + ".." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+//signJWSInput return signature without leading scheme byte of serialized signature,
+//which is r||s of ECDSA and SM2, or raw signature of Ed25519
+func signJWSInput(signer Signer, input []byte) ([]byte, error) {
+	sig, err := signer.Sign(input)
+	if err != nil {
+		return nil, fmt.Errorf("sign error:%s", err)
+	}
+	if len(sig) < 2 {
+		return nil, fmt.Errorf("invalid signature length:%d", len(sig))
+	}
+	return sig[1:], nil
+}
+
+//verifyJWSSignature verify signature of JWS signing input
+func verifyJWSSignature(pubKey keypair.PublicKey, alg string, input, sigData []byte) error {
+	scheme, err := getJWSScheme(alg)
+	if err != nil {
+		return err
+	}
+	sig, err := s.Deserialize(append([]byte{byte(scheme)}, sigData...))
+	if err != nil {
+		return fmt.Errorf("%w, deserialize signature error:%s", ERR_CREDENTIAL_SIGNATURE, err)
+	}
+	if !s.Verify(pubKey, input, sig) {
+		return ERR_CREDENTIAL_SIGNATURE
+	}
+	return nil
+}
+
+//parseJWS return header, payload and signature of compact JWS. Payload is nil if detached.
+func parseJWS(jws string) (*jwsHeader, string, []byte, []byte, error) {
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 {
+		return nil, "", nil, nil, fmt.Errorf("%w, JWS should have 3 parts", ERR_INVALID_CREDENTIAL)
+	}
+	headerData, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, "", nil, nil, fmt.Errorf("%w, decode JWS header error:%s", ERR_INVALID_CREDENTIAL, err)
+	}
+	header := &jwsHeader{}
+	err = json.Unmarshal(headerData, header)
+	if err != nil {
+		return nil, "", nil, nil, fmt.Errorf("%w, unmarshal JWS header error:%s", ERR_INVALID_CREDENTIAL, err)
+	}
+	var payload []byte
+	if parts[1] != "" {
+		payload, err = base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, "", nil, nil, fmt.Errorf("%w, decode JWS payload error:%s", ERR_INVALID_CREDENTIAL, err)
+		}
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, "", nil, nil, fmt.Errorf("%w, decode JWS signature error:%s", ERR_INVALID_CREDENTIAL, err)
+	}
+	return header, parts[0], payload, sig, nil
+}
+
+//RevocationRegistry is the registry of revoked credentials
+type RevocationRegistry interface {
+	IsRevoked(vc *VerifiableCredential) (bool, error)
+}
+
+//ContractRevocationRegistry is a RevocationRegistry of NeoVM contract,
+//whose CREDENTIAL_REGISTRY_METHOD_IS_REVOKED method takes credential id and returns bool.
+type ContractRevocationRegistry struct {
+	sdk             *DNASdk
+	ContractAddress common.Address
+}
+
+func NewContractRevocationRegistry(sdk *DNASdk, contractAddress common.Address) *ContractRevocationRegistry {
+	return &ContractRevocationRegistry{
+		sdk:             sdk,
+		ContractAddress: contractAddress,
+	}
+}
+
+func (this *ContractRevocationRegistry) IsRevoked(vc *VerifiableCredential) (bool, error) {
+	if vc.Id == "" {
+		return false, fmt.Errorf("%w, credential without id cannot be checked in revocation registry", ERR_INVALID_CREDENTIAL)
+	}
+	preResult, err := this.sdk.NeoVM.PreExecInvokeNeoVMContract(this.ContractAddress,
+		[]interface{}{CREDENTIAL_REGISTRY_METHOD_IS_REVOKED, []interface{}{vc.Id}})
+	if err != nil {
+		return false, err
+	}
+	return preResult.Result.ToBool()
+}
+
+//CredentialVerifyConfig config of credential verification
+type CredentialVerifyConfig struct {
+	RevocationRegistry RevocationRegistry //Optional, revocation is not checked if nil
+	ClockSkew          time.Duration      //Tolerance of issuance and expiration time
+}
+
+//VerifyCredentialJWT verify JWT of credential, and return the credential
+func (this *OntId) VerifyCredentialJWT(jwt string, config *CredentialVerifyConfig) (*VerifiableCredential, error) {
+	header, encodedHeader, payload, sig, err := parseJWS(jwt)
+	if err != nil {
+		return nil, err
+	}
+	if payload == nil {
+		return nil, fmt.Errorf("%w, JWT without payload", ERR_INVALID_CREDENTIAL)
+	}
+	claims := &credentialJWTClaims{}
+	err = json.Unmarshal(payload, claims)
+	if err != nil {
+		return nil, fmt.Errorf("%w, unmarshal JWT claims error:%s", ERR_INVALID_CREDENTIAL, err)
+	}
+	vc := claims.Credential
+	if vc == nil {
+		return nil, fmt.Errorf("%w, JWT without vc claim", ERR_INVALID_CREDENTIAL)
+	}
+	if claims.Issuer != vc.Issuer {
+		return nil, fmt.Errorf("%w, iss:%s mismatch issuer:%s", ERR_INVALID_CREDENTIAL, claims.Issuer, vc.Issuer)
+	}
+	notBefore, expiry, err := getCredentialValidity(vc)
+	if err != nil {
+		return nil, err
+	}
+	if notBefore != claims.NotBefore || expiry != claims.Expiry {
+		return nil, fmt.Errorf("%w, nbf or exp mismatch credential", ERR_INVALID_CREDENTIAL)
+	}
+	err = checkKeyIdOfIssuer(vc, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, err := this.GetActivePublicKey(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signingInput := encodedHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	err = verifyJWSSignature(pubKey, header.Alg, []byte(signingInput), sig)
+	if err != nil {
+		return nil, err
+	}
+	err = checkCredentialStatus(vc, config)
+	if err != nil {
+		return nil, err
+	}
+	return vc, nil
+}
+
+//VerifyCredential verify JSON-LD proof of credential
+func (this *OntId) VerifyCredential(vc *VerifiableCredential, config *CredentialVerifyConfig) error {
+	if vc.Proof == nil {
+		return fmt.Errorf("%w, credential without proof", ERR_INVALID_CREDENTIAL)
+	}
+	if vc.Proof.Type != CREDENTIAL_PROOF_TYPE {
+		return fmt.Errorf("%w, unsupported proof type:%s", ERR_INVALID_CREDENTIAL, vc.Proof.Type)
+	}
+	err := checkKeyIdOfIssuer(vc, vc.Proof.VerificationMethod)
+	if err != nil {
+		return err
+	}
+	pubKey, err := this.GetActivePublicKey(vc.Proof.VerificationMethod)
+	if err != nil {
+		return err
+	}
+	err = verifyDetachedJWS(pubKey, vc, vc.Proof)
+	if err != nil {
+		return err
+	}
+	return checkCredentialStatus(vc, config)
+}
+
+//verifyDetachedJWS verify jws of proof, whose payload is canonical JSON of doc with empty jws of proof
+func verifyDetachedJWS(pubKey keypair.PublicKey, doc interface{}, proof *CredentialProof) error {
+	header, encodedHeader, payload, sig, err := parseJWS(proof.Jws)
+	if err != nil {
+		return err
+	}
+	if payload != nil || header.B64 == nil || *header.B64 {
+		return fmt.Errorf("%w, proof should be detached JWS with unencoded payload", ERR_INVALID_CREDENTIAL)
+	}
+	jws := proof.Jws
+	proof.Jws = ""
+	payload, err = canonicalJSON(doc)
+	proof.Jws = jws
+	if err != nil {
+		return err
+	}
+	return verifyJWSSignature(pubKey, header.Alg, append([]byte(encodedHeader+"."), payload...), sig)
+}
+
+//checkCredentialStatus check validity period and revocation of credential
+func checkCredentialStatus(vc *VerifiableCredential, config *CredentialVerifyConfig) error {
+	if config == nil {
+		config = &CredentialVerifyConfig{}
+	}
+	notBefore, expiry, err := getCredentialValidity(vc)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if now.Add(config.ClockSkew).Unix() < notBefore {
+		return fmt.Errorf("%w, issuanceDate:%s", ERR_CREDENTIAL_NOT_YET_VALID, vc.IssuanceDate)
+	}
+	if expiry != 0 && now.Add(-config.ClockSkew).Unix() > expiry {
+		return fmt.Errorf("%w, expirationDate:%s", ERR_CREDENTIAL_EXPIRED, vc.ExpirationDate)
+	}
+	if config.RevocationRegistry == nil {
+		return nil
+	}
+	revoked, err := config.RevocationRegistry.IsRevoked(vc)
+	if err != nil {
+		return fmt.Errorf("check revocation of credential:%s error:%s", vc.Id, err)
+	}
+	if revoked {
+		return fmt.Errorf("%w, id:%s", ERR_CREDENTIAL_REVOKED, vc.Id)
+	}
+	return nil
+}
+
+//GetActivePublicKey return public key by key id such as did:ont:xxx#keys-1, which must not be revoked
+func (this *OntId) GetActivePublicKey(keyId string) (keypair.PublicKey, error) {
+	ontId := getDIDOfKeyId(keyId)
+	err := this.dnaSkd.GetDIDMethod().CheckID(ontId)
+	if err != nil {
+		return nil, err
+	}
+	owners, err := this.GetPublicKeys(ontId)
+	if err != nil {
+		return nil, fmt.Errorf("GetPublicKeys of:%s error:%s", ontId, err)
+	}
+	return this.getActivePublicKey(ontId, keyId, owners)
+}
+
+//getActivePublicKey find public key of keyId in owners, and check it's not revoked
+func (this *OntId) getActivePublicKey(ontId, keyId string, owners []*DDOOwner) (keypair.PublicKey, error) {
+	for _, owner := range owners {
+		if owner.PubKeyId != keyId {
+			continue
+		}
+		_, revokedKeys, err := this.splitRevokedKeys(ontId, []*DDOOwner{owner})
+		if err != nil {
+			return nil, err
+		}
+		if len(revokedKeys) > 0 {
+			return nil, fmt.Errorf("%w, key:%s", ERR_KEY_REVOKED, keyId)
+		}
+		pkData, err := hex.DecodeString(owner.Value)
+		if err != nil {
+			return nil, fmt.Errorf("public key:%s hex decode error:%s", keyId, err)
+		}
+		return keypair.DeserializePublicKey(pkData)
+	}
+	return nil, fmt.Errorf("%w, key:%s", ERR_KEY_NOT_FOUND, keyId)
+}
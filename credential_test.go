@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	s "github.com/ontio/ontology-crypto/signature"
+	"github.com/stretchr/testify/assert"
+)
+
+type testRevocationRegistry map[string]bool
+
+func (this testRevocationRegistry) IsRevoked(vc *VerifiableCredential) (bool, error) {
+	return this[vc.Id], nil
+}
+
+func newTestController(t *testing.T, keyType keypair.KeyType, curve byte, scheme s.SignatureScheme) *Controller {
+	privateKey, publicKey, err := keypair.GenerateKeyPair(keyType, curve)
+	assert.Nil(t, err)
+	return &Controller{ID: "1", PrivateKey: privateKey, PublicKey: publicKey, SigScheme: scheme}
+}
+
+func TestCredentialSignature(t *testing.T) {
+	issuer, err := GenerateID()
+	assert.Nil(t, err)
+	keyId := issuer + "#keys-1"
+	controllers := []*Controller{
+		newTestController(t, keypair.PK_ECDSA, keypair.P256, s.SHA256withECDSA),
+		newTestController(t, keypair.PK_EDDSA, keypair.ED25519, s.SHA512withEdDSA),
+		newTestController(t, keypair.PK_SM2, keypair.SM2P256V1, s.SM3withSM2),
+	}
+	for _, controller := range controllers {
+		vc, err := NewVerifiableCredential(issuer, []string{"KYCCredential"},
+			map[string]interface{}{"id": "did:ont:subject", "level": 2}, time.Now().Add(time.Hour))
+		assert.Nil(t, err)
+
+		jwt, err := SignCredentialJWT(vc, controller, keyId)
+		assert.Nil(t, err)
+		header, encodedHeader, payload, sig, err := parseJWS(jwt)
+		assert.Nil(t, err)
+		assert.Equal(t, keyId, header.Kid)
+		input := encodedHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+		assert.Nil(t, verifyJWSSignature(controller.PublicKey, header.Alg, []byte(input), sig))
+		assert.True(t, errors.Is(verifyJWSSignature(controller.PublicKey, header.Alg, []byte(input+"x"), sig), ERR_CREDENTIAL_SIGNATURE))
+
+		assert.Nil(t, SignCredential(vc, controller, keyId))
+		data, err := json.Marshal(vc)
+		assert.Nil(t, err)
+		parsed := &VerifiableCredential{}
+		assert.Nil(t, json.Unmarshal(data, parsed))
+		assert.True(t, strings.Contains(parsed.Proof.Jws, ".."))
+		assert.Nil(t, verifyDetachedJWS(controller.PublicKey, parsed, parsed.Proof))
+		parsed.CredentialSubject["level"] = 3
+		assert.True(t, errors.Is(verifyDetachedJWS(controller.PublicKey, parsed, parsed.Proof), ERR_CREDENTIAL_SIGNATURE))
+	}
+	vc, err := NewVerifiableCredential(issuer, nil, nil, time.Time{})
+	assert.Nil(t, err)
+	_, err = SignCredentialJWT(vc, controllers[0], "did:ont:other#keys-1")
+	assert.True(t, errors.Is(err, ERR_INVALID_CREDENTIAL))
+}
+
+func TestCheckCredentialStatus(t *testing.T) {
+	vc, err := NewVerifiableCredential("did:ont:issuer", nil, nil, time.Now().Add(-time.Minute))
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(checkCredentialStatus(vc, nil), ERR_CREDENTIAL_EXPIRED))
+	assert.Nil(t, checkCredentialStatus(vc, &CredentialVerifyConfig{ClockSkew: 2 * time.Minute}))
+
+	vc.ExpirationDate = ""
+	vc.IssuanceDate = formatCredentialTime(time.Now().Add(time.Hour))
+	assert.True(t, errors.Is(checkCredentialStatus(vc, nil), ERR_CREDENTIAL_NOT_YET_VALID))
+
+	vc.IssuanceDate = formatCredentialTime(time.Now())
+	registry := testRevocationRegistry{vc.Id: true}
+	assert.True(t, errors.Is(checkCredentialStatus(vc, &CredentialVerifyConfig{RevocationRegistry: registry}), ERR_CREDENTIAL_REVOKED))
+}
@@ -0,0 +1,235 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+)
+
+const (
+	DID_CORE_CONTEXT            = "https://www.w3.org/ns/did/v1"
+	DID_RESOLUTION_CONTENT_TYPE = "application/did+ld+json"
+)
+
+//Type of verification method by key type and curve
+const (
+	VERIFICATION_KEY_TYPE_P224    = "EcdsaSecp224r1VerificationKey2019"
+	VERIFICATION_KEY_TYPE_P256    = "EcdsaSecp256r1VerificationKey2019"
+	VERIFICATION_KEY_TYPE_P384    = "EcdsaSecp384r1VerificationKey2019"
+	VERIFICATION_KEY_TYPE_P521    = "EcdsaSecp521r1VerificationKey2019"
+	VERIFICATION_KEY_TYPE_SM2     = "SM2VerificationKey2019"
+	VERIFICATION_KEY_TYPE_ED25519 = "Ed25519VerificationKey2018"
+)
+
+//Error of DID resolution, see https://www.w3.org/TR/did-core/#did-resolution-metadata
+const (
+	DID_RESOLUTION_ERROR_INVALID_DID = "invalidDid"
+	DID_RESOLUTION_ERROR_NOT_FOUND   = "notFound"
+)
+
+//DID_SERVICE_ATTRIBUTE_PREFIX is the key prefix of attributes which are service endpoints.
+//An attribute with key "service:<name>" is service "<did>#<name>", its value type is the type of service,
+//and its value is the service endpoint.
+const DID_SERVICE_ATTRIBUTE_PREFIX = "service:"
+
+//VerificationMethod is a public key of DID document
+type VerificationMethod struct {
+	Id           string `json:"id"`
+	Type         string `json:"type"`
+	Controller   string `json:"controller"`
+	PublicKeyHex string `json:"publicKeyHex"` //Compressed point of ECDSA and SM2 key, or raw Ed25519 key
+}
+
+//DIDService is a service endpoint of DID document
+type DIDService struct {
+	Id              string `json:"id"`
+	Type            string `json:"type"`
+	ServiceEndpoint string `json:"serviceEndpoint"`
+}
+
+//DIDDocument is the W3C DID Core JSON-LD representation of on-chain identity
+type DIDDocument struct {
+	Context            []string              `json:"@context"`
+	Id                 string                `json:"id"`
+	VerificationMethod []*VerificationMethod `json:"verificationMethod"`
+	Authentication     []string              `json:"authentication"`
+	AssertionMethod    []string              `json:"assertionMethod"`
+	Service            []*DIDService         `json:"service,omitempty"`
+}
+
+//GetVerificationMethod return verification method by id, nil if not found
+func (this *DIDDocument) GetVerificationMethod(id string) *VerificationMethod {
+	for _, method := range this.VerificationMethod {
+		if method.Id == id {
+			return method
+		}
+	}
+	return nil
+}
+
+//DIDResolutionMetadata is metadata of resolution process
+type DIDResolutionMetadata struct {
+	ContentType string `json:"contentType,omitempty"`
+	Error       string `json:"error,omitempty"`
+	ErrorDetail string `json:"errorDetail,omitempty"`
+}
+
+//DIDDocumentMetadata is metadata of resolved DID document
+type DIDDocumentMetadata struct {
+	Deactivated bool     `json:"deactivated,omitempty"` //All of keys of identity have been revoked
+	Recovery    string   `json:"recovery,omitempty"`    //Base58 address of recovery
+	RevokedKeys []string `json:"revokedKeys,omitempty"`
+	Retrieved   string   `json:"retrieved"` //RFC3339 time of resolution
+}
+
+//DIDResolutionResult is the result of ResolveDID
+type DIDResolutionResult struct {
+	DIDDocument           *DIDDocument           `json:"didDocument"`
+	DIDResolutionMetadata *DIDResolutionMetadata `json:"didResolutionMetadata"`
+	DIDDocumentMetadata   *DIDDocumentMetadata   `json:"didDocumentMetadata"`
+}
+
+//NewDIDDocument convert DDO to DID document. All owners of ddo are treated as active keys.
+func NewDIDDocument(ddo *DDO) (*DIDDocument, error) {
+	doc := &DIDDocument{
+		Context:            []string{DID_CORE_CONTEXT},
+		Id:                 ddo.OntId,
+		VerificationMethod: make([]*VerificationMethod, 0, len(ddo.Owners)),
+		Authentication:     make([]string, 0, len(ddo.Owners)),
+		AssertionMethod:    make([]string, 0, len(ddo.Owners)),
+	}
+	for _, owner := range ddo.Owners {
+		method, err := NewVerificationMethod(ddo.OntId, owner)
+		if err != nil {
+			return nil, err
+		}
+		doc.VerificationMethod = append(doc.VerificationMethod, method)
+		doc.Authentication = append(doc.Authentication, method.Id)
+		doc.AssertionMethod = append(doc.AssertionMethod, method.Id)
+	}
+	for _, attr := range ddo.Attributes {
+		if !attr.IsService() {
+			continue
+		}
+		service, err := attr.AsService(ddo.OntId)
+		if err != nil {
+			return nil, err
+		}
+		doc.Service = append(doc.Service, service)
+	}
+	return doc, nil
+}
+
+//NewVerificationMethod convert public key of DDO owner to verification method
+func NewVerificationMethod(ontId string, owner *DDOOwner) (*VerificationMethod, error) {
+	pkData, err := hex.DecodeString(owner.Value)
+	if err != nil {
+		return nil, fmt.Errorf("public key:%s hex decode error:%s", owner.PubKeyId, err)
+	}
+	pubKey, err := keypair.DeserializePublicKey(pkData)
+	if err != nil {
+		return nil, fmt.Errorf("public key:%s DeserializePublicKey error:%s", owner.PubKeyId, err)
+	}
+	methodType, rawKey, err := getVerificationKey(pubKey, pkData)
+	if err != nil {
+		return nil, fmt.Errorf("public key:%s error:%s", owner.PubKeyId, err)
+	}
+	return &VerificationMethod{
+		Id:           owner.PubKeyId,
+		Type:         methodType,
+		Controller:   ontId,
+		PublicKeyHex: hex.EncodeToString(rawKey),
+	}, nil
+}
+
+//getVerificationKey return type of verification method and raw public key without key type and curve prefix
+func getVerificationKey(pubKey keypair.PublicKey, pkData []byte) (string, []byte, error) {
+	keyType := keypair.GetKeyType(pubKey)
+	//ECDSA P-256 key is serialized as compressed point without prefix
+	if keyType == keypair.PK_ECDSA && len(pkData) == 33 {
+		return VERIFICATION_KEY_TYPE_P256, pkData, nil
+	}
+	if len(pkData) < 3 {
+		return "", nil, fmt.Errorf("invalid public key length:%d", len(pkData))
+	}
+	curve, rawKey := pkData[1], pkData[2:]
+	switch keyType {
+	case keypair.PK_ECDSA:
+		switch curve {
+		case keypair.P224:
+			return VERIFICATION_KEY_TYPE_P224, rawKey, nil
+		case keypair.P256:
+			return VERIFICATION_KEY_TYPE_P256, rawKey, nil
+		case keypair.P384:
+			return VERIFICATION_KEY_TYPE_P384, rawKey, nil
+		case keypair.P521:
+			return VERIFICATION_KEY_TYPE_P521, rawKey, nil
+		}
+	case keypair.PK_SM2:
+		return VERIFICATION_KEY_TYPE_SM2, rawKey, nil
+	case keypair.PK_EDDSA:
+		if curve == keypair.ED25519 {
+			return VERIFICATION_KEY_TYPE_ED25519, rawKey, nil
+		}
+	}
+	return "", nil, fmt.Errorf("unsupported key type:%s curve:%d", GetKeyTypeString(keyType), curve)
+}
+
+//ResolveDID resolve on-chain identity to W3C DID document. Revoked keys are excluded from document.
+//Invalid or unregistered DID is reported by Error of DIDResolutionMetadata with nil document,
+//and error is only returned when failed to query node.
+func (this *OntId) ResolveDID(did string) (*DIDResolutionResult, error) {
+	result := &DIDResolutionResult{
+		DIDResolutionMetadata: &DIDResolutionMetadata{},
+		DIDDocumentMetadata:   &DIDDocumentMetadata{Retrieved: time.Now().UTC().Format(time.RFC3339)},
+	}
+	err := this.dnaSkd.GetDIDMethod().CheckID(did)
+	if err != nil {
+		result.DIDResolutionMetadata.Error = DID_RESOLUTION_ERROR_INVALID_DID
+		result.DIDResolutionMetadata.ErrorDetail = err.Error()
+		return result, nil
+	}
+	preResult, err := this.native.PreExecInvokeNativeContract(
+		ONT_ID_CONTRACT_ADDRESS,
+		ONT_ID_CONTRACT_VERSION,
+		"getDDO",
+		[]interface{}{did},
+	)
+	if err != nil {
+		return nil, err
+	}
+	data, err := preResult.Result.ToByteArray()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		result.DIDResolutionMetadata.Error = DID_RESOLUTION_ERROR_NOT_FOUND
+		return result, nil
+	}
+	ddo, err := this.getDDO(did, data)
+	if err != nil {
+		return nil, err
+	}
+	activeOwners, revokedOwners, err := this.splitRevokedKeys(did, ddo.Owners)
+	if err != nil {
+		return nil, err
+	}
+	for _, owner := range revokedOwners {
+		result.DIDDocumentMetadata.RevokedKeys = append(result.DIDDocumentMetadata.RevokedKeys, owner.PubKeyId)
+	}
+	ddo.Owners = activeOwners
+	doc, err := NewDIDDocument(ddo)
+	if err != nil {
+		return nil, err
+	}
+	result.DIDDocument = doc
+	result.DIDResolutionMetadata.ContentType = DID_RESOLUTION_CONTENT_TYPE
+	result.DIDDocumentMetadata.Deactivated = len(activeOwners) == 0
+	result.DIDDocumentMetadata.Recovery = ddo.Recovery
+	return result, nil
+}
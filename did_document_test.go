@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckID(t *testing.T) {
+	id, err := GenerateID()
+	assert.Nil(t, err)
+	assert.Nil(t, CheckID(id))
+	assert.True(t, VerifyID(id))
+
+	assert.True(t, errors.Is(CheckID("did:foo:"+id[8:]), ERR_DID_METHOD_MISMATCH))
+	assert.True(t, errors.Is(CheckID("dna:ont:"+id[8:]), ERR_INVALID_DID_PREFIX))
+	assert.True(t, errors.Is(CheckID("did:ont:"), ERR_INVALID_DID_ENCODING))
+	assert.True(t, errors.Is(CheckID("did:ont:0OIl"), ERR_INVALID_DID_ENCODING))
+	assert.False(t, VerifyID(id[:len(id)-1]))
+}
+
+func TestNewDIDDocument(t *testing.T) {
+	id, err := GenerateID()
+	assert.Nil(t, err)
+	_, p256Key, err := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.P256)
+	assert.Nil(t, err)
+	_, edKey, err := keypair.GenerateKeyPair(keypair.PK_EDDSA, keypair.ED25519)
+	assert.Nil(t, err)
+	p256Data := keypair.SerializePublicKey(p256Key)
+	edData := keypair.SerializePublicKey(edKey)
+	ddo := &DDO{
+		OntId: id,
+		Owners: []*DDOOwner{
+			{pubKeyIndex: 1, PubKeyId: id + "#keys-1", Value: hex.EncodeToString(p256Data)},
+			{pubKeyIndex: 2, PubKeyId: id + "#keys-2", Value: hex.EncodeToString(edData)},
+		},
+		Attributes: []*DDOAttribute{
+			{Key: []byte("service:hub"), ValueType: []byte("IdentityHub"), Value: []byte("https://hub.example.com")},
+			{Key: []byte("name"), ValueType: []byte("string"), Value: []byte("alice")},
+		},
+	}
+	doc, err := NewDIDDocument(ddo)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(doc.VerificationMethod))
+	assert.Equal(t, VERIFICATION_KEY_TYPE_P256, doc.VerificationMethod[0].Type)
+	assert.Equal(t, hex.EncodeToString(p256Data), doc.VerificationMethod[0].PublicKeyHex)
+	method := doc.GetVerificationMethod(id + "#keys-2")
+	assert.NotNil(t, method)
+	assert.Equal(t, VERIFICATION_KEY_TYPE_ED25519, method.Type)
+	assert.Equal(t, hex.EncodeToString(edData[2:]), method.PublicKeyHex)
+	assert.Equal(t, []string{id + "#keys-1", id + "#keys-2"}, doc.Authentication)
+	assert.Equal(t, []*DIDService{{Id: id + "#hub", Type: "IdentityHub", ServiceEndpoint: "https://hub.example.com"}}, doc.Service)
+
+	data, err := json.Marshal(doc)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"@context":["https://www.w3.org/ns/did/v1"]`)
+}
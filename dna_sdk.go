@@ -51,8 +51,9 @@ func init() {
 //DNASdk is the main struct for user
 type DNASdk struct {
 	client.ClientMgr
-	Native *NativeContract
-	NeoVM  *NeoVMContract
+	Native    *NativeContract
+	NeoVM     *NeoVMContract
+	didMethod *DIDMethod
 }
 
 //NewDNASdk return DNASdk.
@@ -65,17 +66,39 @@ func NewDNASdk() *DNASdk {
 	return dnaSdk
 }
 
-//CreateWallet return a new wallet
+//SetDIDMethod set DID method of identities, which is used to verify identities and create wallets
+func (this *DNASdk) SetDIDMethod(method *DIDMethod) {
+	this.didMethod = method
+}
+
+//GetDIDMethod return DID method of identities, default DID method if not set
+func (this *DNASdk) GetDIDMethod() *DIDMethod {
+	if this.didMethod == nil {
+		return DefaultDIDMethod()
+	}
+	return this.didMethod
+}
+
+//CreateWallet return a new wallet, whose DID method is the DID method of DNASdk
 func (this *DNASdk) CreateWallet(walletFile string) (*Wallet, error) {
 	if utils.IsFileExist(walletFile) {
 		return nil, fmt.Errorf("wallet:%s has already exist", walletFile)
 	}
-	return NewWallet(walletFile), nil
+	wallet := NewWallet(walletFile)
+	wallet.SetDIDMethod(this.didMethod)
+	return wallet, nil
 }
 
-//OpenWallet return a wallet instance
+//OpenWallet return a wallet instance. If DID method is not saved in wallet, the DID method of DNASdk is used.
 func (this *DNASdk) OpenWallet(walletFile string) (*Wallet, error) {
-	return OpenWallet(walletFile)
+	wallet, err := OpenWallet(walletFile)
+	if err != nil {
+		return nil, err
+	}
+	if wallet.didMethod == nil {
+		wallet.SetDIDMethod(this.didMethod)
+	}
+	return wallet, nil
 }
 
 func ParseNativeTxPayload(raw []byte) (map[string]interface{}, error) {
@@ -405,6 +428,27 @@ func (this *DNASdk) SignToTransaction(tx *types.MutableTransaction, signer Signe
 	return nil
 }
 
+//signAndSubmit sign transaction by payer and signer, submit it by TxSubmitter and wait for it confirmed.
+//Error is returned if transaction is not confirmed or failed to execute.
+func (this *DNASdk) signAndSubmit(tx *types.MutableTransaction, payer, signer Signer, config *client.TxSubmitConfig) error {
+	err := this.SignToTransaction(tx, payer)
+	if err != nil {
+		return err
+	}
+	err = this.SignToTransaction(tx, signer)
+	if err != nil {
+		return err
+	}
+	result := this.NewTxSubmitter(config).Submit(tx)
+	if result.State != client.TX_SUBMIT_CONFIRMED {
+		return fmt.Errorf("tx:%s %s error:%s", result.TxHash.ToHexString(), result.State, result.Err)
+	}
+	if result.Event != nil && result.Event.State == 0 {
+		return fmt.Errorf("tx:%s execute failed", result.TxHash.ToHexString())
+	}
+	return nil
+}
+
 func (this *DNASdk) MultiSignToTransaction(tx *types.MutableTransaction, m uint16, pubKeys []keypair.PublicKey, signer Signer) error {
 	pkSize := len(pubKeys)
 	if m == 0 || int(m) > pkSize || pkSize > constants.MULTI_SIG_MAX_PUBKEY_SIZE {
@@ -497,22 +497,21 @@ func TestGlobalParam_SetGlobalParams(t *testing.T) {
 func TestWsScribeEvent(t *testing.T) {
 	Init()
 	wsClient := testDnaSdk.ClientMgr.GetWebSocketClient()
-	err := wsClient.SubscribeEvent()
+	sub, err := wsClient.SubscribeEvent(nil)
 	if err != nil {
-		t.Errorf("SubscribeTxHash error:%s", err)
+		t.Errorf("SubscribeEvent error:%s", err)
 		return
 	}
-	defer wsClient.UnsubscribeTxHash()
+	defer sub.Close()
 
-	actionCh := wsClient.GetActionCh()
 	timer := time.NewTimer(time.Minute * 3)
 	for {
 		select {
 		case <-timer.C:
 			return
-		case action := <-actionCh:
-			fmt.Printf("Action:%s\n", action.Action)
-			fmt.Printf("Result:%s\n", action.Result)
+		case event := <-sub.Events():
+			fmt.Printf("TxHash:%s\n", event.TxHash)
+			fmt.Printf("Notify:%v\n", event.Notify)
 		}
 	}
 }
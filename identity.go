@@ -9,6 +9,7 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/DNAProject/DNA/core/types"
 	base58 "github.com/itchyny/base58-go"
@@ -16,6 +17,7 @@ import (
 	s "github.com/ontio/ontology-crypto/signature"
 	"golang.org/x/crypto/ripemd160"
 	"math/big"
+	"strings"
 )
 
 const (
@@ -24,6 +26,18 @@ const (
 	VER    = 0x41
 )
 
+//ERR_INVALID_DID_PREFIX is returned when DID does not start with did:<method>:
+var ERR_INVALID_DID_PREFIX = errors.New("invalid DID prefix")
+
+//ERR_DID_METHOD_MISMATCH is returned when DID is not of the expected DID method
+var ERR_DID_METHOD_MISMATCH = errors.New("DID method mismatch")
+
+//ERR_INVALID_DID_ENCODING is returned when method specific id of DID cannot be decoded
+var ERR_INVALID_DID_ENCODING = errors.New("invalid DID encoding")
+
+//ERR_INVALID_DID_CHECKSUM is returned when checksum of DID mismatch
+var ERR_INVALID_DID_CHECKSUM = errors.New("invalid DID checksum")
+
 type Controller struct {
 	ID         string
 	PrivateKey keypair.PrivateKey
@@ -181,7 +195,12 @@ type Identity struct {
 }
 
 func NewIdentity(scrypt *keypair.ScryptParam) (*Identity, error) {
-	id, err := GenerateID()
+	return NewIdentityWithDIDMethod(scrypt, DefaultDIDMethod())
+}
+
+//NewIdentityWithDIDMethod return identity with a new random id of method
+func NewIdentityWithDIDMethod(scrypt *keypair.ScryptParam, method *DIDMethod) (*Identity, error) {
+	id, err := method.GenerateID()
 	if err != nil {
 		return nil, err
 	}
@@ -355,61 +374,147 @@ type IdentityData struct {
 	scrypt    *keypair.ScryptParam
 }
 
-func GenerateID() (string, error) {
+//DIDMethod generate and verify identities of a DID method, such as did:ont
+type DIDMethod struct {
+	Name    string `json:"name"`
+	Version byte   `json:"version"` //Version byte of new identities
+}
+
+//DefaultDIDMethod return a new did:ont method, which is used if DID method of DNASdk or Wallet is not set
+func DefaultDIDMethod() *DIDMethod {
+	return NewDIDMethod(METHOD, VER)
+}
+
+func NewDIDMethod(name string, version byte) *DIDMethod {
+	return &DIDMethod{Name: name, Version: version}
+}
+
+//GenerateID return a new random identity of method
+func (this *DIDMethod) GenerateID() (string, error) {
 	var buf [32]byte
 	_, err := rand.Read(buf[:])
 	if err != nil {
 		return "", fmt.Errorf("generate ID error, %s", err)
 	}
-	return CreateID(buf[:])
+	return this.CreateID(buf[:])
 }
 
-func CreateID(nonce []byte) (string, error) {
+//CreateID return identity of method from nonce
+func (this *DIDMethod) CreateID(nonce []byte) (string, error) {
 	hasher := ripemd160.New()
 	_, err := hasher.Write(nonce)
 	if err != nil {
 		return "", fmt.Errorf("create ID error, %s", err)
 	}
-	data := hasher.Sum([]byte{VER})
-	data = append(data, checksum(data)...)
+	did := &DID{
+		Method:  this.Name,
+		Version: this.Version,
+		Hash:    hasher.Sum(nil),
+	}
+	return did.String(), nil
+}
 
-	bi := new(big.Int).SetBytes(data).String()
-	idstring, err := base58.BitcoinEncoding.Encode([]byte(bi))
+func (this *DIDMethod) VerifyID(id string) bool {
+	return this.CheckID(id) == nil
+}
+
+//CheckID return the reason why id is not a well-formed DID of method.
+//Version byte is not checked, so that identities created with other versions are still valid.
+func (this *DIDMethod) CheckID(id string) error {
+	_, err := this.ParseDID(id)
+	return err
+}
+
+//ParseDID parse id, which must be a DID of method
+func (this *DIDMethod) ParseDID(id string) (*DID, error) {
+	did, err := ParseDID(id)
 	if err != nil {
-		return "", fmt.Errorf("create ID error, %s", err)
+		return nil, err
 	}
+	if did.Method != this.Name {
+		return nil, fmt.Errorf("%w, method:%s expect:%s", ERR_DID_METHOD_MISMATCH, did.Method, this.Name)
+	}
+	return did, nil
+}
 
-	return SCHEME + ":" + METHOD + ":" + string(idstring), nil
+//DID is a parsed identity
+type DID struct {
+	Method  string
+	Version byte
+	Hash    []byte //RIPEMD160 hash of nonce
 }
 
-func VerifyID(id string) bool {
-	if len(id) < 9 {
-		return false
+//ParseDID parse id of any DID method
+func ParseDID(id string) (*DID, error) {
+	parts := strings.SplitN(id, ":", 3)
+	if len(parts) != 3 || parts[0] != SCHEME || parts[1] == "" {
+		return nil, fmt.Errorf("%w, expect:%s:<method>:", ERR_INVALID_DID_PREFIX, SCHEME)
 	}
-	if id[0:8] != "did:ont:" {
-		return false
+	if parts[2] == "" {
+		return nil, fmt.Errorf("%w, empty method specific id", ERR_INVALID_DID_ENCODING)
 	}
-	buf, err := base58.BitcoinEncoding.Decode([]byte(id[8:]))
+	buf, err := base58.BitcoinEncoding.Decode([]byte(parts[2]))
 	if err != nil {
-		return false
+		return nil, fmt.Errorf("%w, base58 decode error:%s", ERR_INVALID_DID_ENCODING, err)
 	}
 	bi, ok := new(big.Int).SetString(string(buf), 10)
 	if !ok || bi == nil {
-		return false
+		return nil, fmt.Errorf("%w, not a decimal number", ERR_INVALID_DID_ENCODING)
 	}
 	buf = bi.Bytes()
 	// 1 byte version + 20 byte hash + 4 byte checksum
 	if len(buf) != 25 {
-		return false
+		return nil, fmt.Errorf("%w, invalid length:%d", ERR_INVALID_DID_ENCODING, len(buf))
 	}
 	pos := len(buf) - 4
 	data := buf[:pos]
 	check := buf[pos:]
 	sum := checksum(data)
 	if !bytes.Equal(sum, check) {
-		return false
+		return nil, ERR_INVALID_DID_CHECKSUM
 	}
-	return true
+	return &DID{
+		Method:  parts[1],
+		Version: data[0],
+		Hash:    data[1:],
+	}, nil
+}
+
+func (this *DID) String() string {
+	data := append([]byte{this.Version}, this.Hash...)
+	data = append(data, checksum(data)...)
+	bi := new(big.Int).SetBytes(data).String()
+	idstring, _ := base58.BitcoinEncoding.Encode([]byte(bi))
+	return SCHEME + ":" + this.Method + ":" + string(idstring)
+}
+
+//KeyId return id of public key at index, such as did:ont:xxx#keys-1
+func (this *DID) KeyId(index uint32) string {
+	return GetKeyId(this.String(), index)
+}
+
+//GetKeyId return id of public key of identity at index
+func GetKeyId(ontId string, index uint32) string {
+	return fmt.Sprintf("%s#keys-%d", ontId, index)
+}
+
+//GenerateID return a new random identity of default DID method
+func GenerateID() (string, error) {
+	return DefaultDIDMethod().GenerateID()
+}
+
+//CreateID return identity of default DID method from nonce
+func CreateID(nonce []byte) (string, error) {
+	return DefaultDIDMethod().CreateID(nonce)
+}
+
+func VerifyID(id string) bool {
+	return DefaultDIDMethod().VerifyID(id)
+}
+
+//CheckID return the reason why id is not a well-formed DID of default DID method
+func CheckID(id string) error {
+	return DefaultDIDMethod().CheckID(id)
 }
 
 func checksum(data []byte) []byte {
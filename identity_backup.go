@@ -0,0 +1,302 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/DNAProject/DNA/core/types"
+	"github.com/ontio/ontology-crypto/keypair"
+)
+
+const (
+	IDENTITY_BACKUP_VERSION = "1.0"
+	//IDENTITY_BACKUP_PREFIX is prefix of identity backup encoded as string, such as in QR code
+	IDENTITY_BACKUP_PREFIX = "dnaid:"
+)
+
+//Policy of importing identity which already exists in wallet
+const (
+	IDENTITY_CONFLICT_FAIL  = iota //Return ERR_IDENTITY_EXISTS
+	IDENTITY_CONFLICT_SKIP         //Keep identity in wallet unchanged
+	IDENTITY_CONFLICT_MERGE        //Add controllers of backup which are not in wallet
+)
+
+//ERR_IDENTITY_EXISTS is returned when imported identity already exists in wallet
+var ERR_IDENTITY_EXISTS = errors.New("identity already exists")
+
+//ERR_CONTROLLER_MISMATCH is returned when controllers of identity don't match its public keys on chain
+var ERR_CONTROLLER_MISMATCH = errors.New("controller mismatch")
+
+//IdentityBackup is a standalone backup of identity, whose controllers are encrypted by the scrypt of backup
+type IdentityBackup struct {
+	Version   string               `json:"version"`
+	Scrypt    *keypair.ScryptParam `json:"scrypt"`
+	DIDMethod *DIDMethod           `json:"didMethod,omitempty"`
+	Identity  *IdentityData        `json:"identity"`
+}
+
+//ExportIdentity return backup of identity with label, extra and controllers, which are decrypted by passwd
+//and encrypted again by newScrypt. Scrypt of wallet is used if newScrypt is absent.
+func (this *Wallet) ExportIdentity(id string, passwd []byte, newScrypts ...*keypair.ScryptParam) (*IdentityBackup, error) {
+	var newScrypt keypair.ScryptParam
+	if len(newScrypts) == 0 {
+		newScrypt = *this.Scrypt
+	} else {
+		newScrypt = *newScrypts[0]
+	}
+	identity, err := this.GetIdentityById(id)
+	if err != nil {
+		return nil, err
+	}
+	identityData := identity.ToIdentityData()
+	controllers := make([]*ControllerData, 0, len(identityData.Control))
+	for _, ctrData := range identityData.Control {
+		newCtrData, err := reencryptControllerData(ctrData, passwd, this.Scrypt, &newScrypt)
+		if err != nil {
+			return nil, err
+		}
+		controllers = append(controllers, newCtrData)
+	}
+	identityData.Control = controllers
+	return &IdentityBackup{
+		Version:   IDENTITY_BACKUP_VERSION,
+		Scrypt:    &newScrypt,
+		DIDMethod: this.didMethod,
+		Identity:  identityData,
+	}, nil
+}
+
+//ImportIdentity add identity of backup to wallet, whose controllers are decrypted by passwd and encrypted again
+//by scrypt of wallet. conflict is the policy if identity already exists, such as IDENTITY_CONFLICT_FAIL.
+//If label of identity is used by another identity, imported identity is renamed to <label>_1, <label>_2 and so on.
+//Controllers are not checked against keys on chain, use OntId.ImportIdentity to check them before importing.
+func (this *Wallet) ImportIdentity(backup *IdentityBackup, passwd []byte, conflict int) (*Identity, error) {
+	err := backup.check()
+	if err != nil {
+		return nil, err
+	}
+	identityData := backup.Identity
+	controllers := make([]*ControllerData, 0, len(identityData.Control))
+	for _, ctrData := range identityData.Control {
+		newCtrData, err := reencryptControllerData(ctrData, passwd, backup.Scrypt, this.Scrypt)
+		if err != nil {
+			return nil, err
+		}
+		controllers = append(controllers, newCtrData)
+	}
+	//controllers are checked by building the identity, before anything of wallet is changed
+	identity := &Identity{
+		ID:          identityData.ID,
+		Lock:        identityData.Lock,
+		Extra:       identityData.Extra,
+		scrypt:      this.Scrypt,
+		controllers: make([]*ControllerData, 0, len(controllers)),
+		ctrsIdMap:   make(map[string]*ControllerData),
+		ctrsPubMap:  make(map[string]*ControllerData),
+	}
+	for _, ctrData := range controllers {
+		err = identity.AddControllerData(ctrData)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	existing, ok := this.identityMap[identityData.ID]
+	if !ok {
+		identity.Label = this.freeIdentityLabel(identityData.Label)
+		err = this.addIdentity(identity)
+		if err != nil {
+			return nil, err
+		}
+		return identity, nil
+	}
+	switch conflict {
+	case IDENTITY_CONFLICT_SKIP:
+		return existing, nil
+	case IDENTITY_CONFLICT_MERGE:
+		err = mergeControllers(existing, controllers)
+		if err != nil {
+			return nil, err
+		}
+		return existing, nil
+	default:
+		return nil, fmt.Errorf("%w, identity:%s", ERR_IDENTITY_EXISTS, identityData.ID)
+	}
+}
+
+//mergeControllers add controllers whose public key is not in identity. All of them are checked before adding,
+//so identity is unchanged if any of them can't be added.
+func mergeControllers(identity *Identity, controllers []*ControllerData) error {
+	merged := make([]*ControllerData, 0, len(controllers))
+	for _, ctrData := range controllers {
+		if _, ok := identity.ctrsPubMap[ctrData.Public]; ok {
+			continue
+		}
+		if _, ok := identity.ctrsIdMap[ctrData.ID]; ok {
+			return fmt.Errorf("merge controller:%s error:duplicate controller id", ctrData.ID)
+		}
+		if !ScryptEqual(ctrData.scrypt, identity.scrypt) {
+			return fmt.Errorf("merge controller:%s error:scrypt unmatch", ctrData.ID)
+		}
+		merged = append(merged, ctrData)
+	}
+	for _, ctrData := range merged {
+		err := identity.AddControllerData(ctrData)
+		if err != nil {
+			return fmt.Errorf("merge controller:%s error:%s", ctrData.ID, err)
+		}
+	}
+	return nil
+}
+
+//freeIdentityLabel return label if it's not used, otherwise <label>_<n> with the smallest n not used.
+//Lock of wallet should be held.
+func (this *Wallet) freeIdentityLabel(label string) string {
+	if label == "" {
+		return ""
+	}
+	newLabel := label
+	for i := 1; ; i++ {
+		if _, ok := this.identityLabelMap[newLabel]; !ok {
+			return newLabel
+		}
+		newLabel = fmt.Sprintf("%s_%d", label, i)
+	}
+}
+
+func reencryptControllerData(ctrData *ControllerData, passwd []byte, oldScrypt, newScrypt *keypair.ScryptParam) (*ControllerData, error) {
+	protectedKey, err := keypair.ReencryptPrivateKey(&ctrData.ProtectedKey, passwd, passwd, oldScrypt, newScrypt)
+	if err != nil {
+		return nil, fmt.Errorf("ReencryptPrivateKey controller:%s error:%s", ctrData.ID, err)
+	}
+	return NewControllerDataFromProtectedKey(ctrData.ID, ctrData.Public, protectedKey, ctrData.SigSch, newScrypt), nil
+}
+
+func (this *IdentityBackup) check() error {
+	if this.Version != IDENTITY_BACKUP_VERSION {
+		return fmt.Errorf("unsupported identity backup version:%s", this.Version)
+	}
+	if this.Scrypt == nil || this.Identity == nil {
+		return fmt.Errorf("identity backup without scrypt or identity")
+	}
+	method := this.DIDMethod
+	if method == nil {
+		method = DefaultDIDMethod()
+	}
+	err := method.CheckID(this.Identity.ID)
+	if err != nil {
+		return fmt.Errorf("identity:%s error:%s", this.Identity.ID, err)
+	}
+	for _, ctrData := range this.Identity.Control {
+		pkData, err := hex.DecodeString(ctrData.Public)
+		if err != nil {
+			return fmt.Errorf("public key of controller:%s hex decode error:%s", ctrData.ID, err)
+		}
+		pubKey, err := keypair.DeserializePublicKey(pkData)
+		if err != nil {
+			return fmt.Errorf("DeserializePublicKey of controller:%s error:%s", ctrData.ID, err)
+		}
+		if types.AddressFromPubKey(pubKey).ToBase58() != ctrData.Address {
+			return fmt.Errorf("%w, public key of controller:%s doesn't match address:%s", ERR_CONTROLLER_MISMATCH, ctrData.ID, ctrData.Address)
+		}
+	}
+	return nil
+}
+
+//Save write backup to a standalone file
+func (this *IdentityBackup) Save(path string) error {
+	data, err := json.Marshal(this)
+	if err != nil {
+		return fmt.Errorf("json marshal identity backup error:%s", err)
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+func LoadIdentityBackup(path string) (*IdentityBackup, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	backup := &IdentityBackup{}
+	err = json.Unmarshal(data, backup)
+	if err != nil {
+		return nil, fmt.Errorf("json unmarshal identity backup error:%s", err)
+	}
+	return backup, nil
+}
+
+//Encode return backup as IDENTITY_BACKUP_PREFIX + base64url of JSON, which is suitable for QR code
+func (this *IdentityBackup) Encode() (string, error) {
+	data, err := json.Marshal(this)
+	if err != nil {
+		return "", fmt.Errorf("json marshal identity backup error:%s", err)
+	}
+	return IDENTITY_BACKUP_PREFIX + base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func DecodeIdentityBackup(str string) (*IdentityBackup, error) {
+	if !strings.HasPrefix(str, IDENTITY_BACKUP_PREFIX) {
+		return nil, fmt.Errorf("identity backup without prefix:%s", IDENTITY_BACKUP_PREFIX)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(str[len(IDENTITY_BACKUP_PREFIX):])
+	if err != nil {
+		return nil, fmt.Errorf("base64 decode identity backup error:%s", err)
+	}
+	backup := &IdentityBackup{}
+	err = json.Unmarshal(data, backup)
+	if err != nil {
+		return nil, fmt.Errorf("json unmarshal identity backup error:%s", err)
+	}
+	return backup, nil
+}
+
+//ImportIdentity check controllers of backup by CheckIdentityControllers, then add identity to wallet as Wallet.ImportIdentity.
+//Nothing is imported if any controller is not an active key of identity on chain.
+func (this *OntId) ImportIdentity(wallet *Wallet, backup *IdentityBackup, passwd []byte, conflict int) (*Identity, error) {
+	err := backup.check()
+	if err != nil {
+		return nil, err
+	}
+	err = this.CheckIdentityControllers(backup.Identity)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.ImportIdentity(backup, passwd, conflict)
+}
+
+//CheckIdentityControllers check that each controller of identity is an active key on chain, and the id of controller
+//is index of the key. ERR_CONTROLLER_MISMATCH is returned for the first mismatched controller.
+func (this *OntId) CheckIdentityControllers(identityData *IdentityData) error {
+	owners, err := this.getActiveKeys(identityData.ID)
+	if err != nil {
+		return err
+	}
+	for _, ctrData := range identityData.Control {
+		var owner *DDOOwner
+		for _, o := range owners {
+			if o.Value == ctrData.Public {
+				owner = o
+				break
+			}
+		}
+		if owner == nil {
+			return fmt.Errorf("%w, public key of controller:%s is not an active key of:%s", ERR_CONTROLLER_MISMATCH, ctrData.ID, identityData.ID)
+		}
+		index := strconv.Itoa(int(owner.GetIndex()))
+		if ctrData.ID != index {
+			return fmt.Errorf("%w, controller:%s is key:%s", ERR_CONTROLLER_MISMATCH, ctrData.ID, owner.PubKeyId)
+		}
+	}
+	return nil
+}
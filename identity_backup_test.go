@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityBackup(t *testing.T) {
+	passwd := []byte("passwd")
+	wallet := NewWallet("")
+	identity, err := wallet.NewDefaultSettingIdentity(passwd)
+	assert.Nil(t, err)
+	assert.Nil(t, wallet.SetIdentityLabel(identity.ID, "alice"))
+	identity.Extra = "extra"
+
+	_, err = wallet.ExportIdentity(identity.ID, []byte("wrong"))
+	assert.NotNil(t, err)
+	newScrypt := &keypair.ScryptParam{N: 4096, R: 8, P: 8, DKLen: 64}
+	backup, err := wallet.ExportIdentity(identity.ID, passwd, newScrypt)
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", backup.Identity.Label)
+
+	str, err := backup.Encode()
+	assert.Nil(t, err)
+	decoded, err := DecodeIdentityBackup(str)
+	assert.Nil(t, err)
+	path := "./identity_backup.json"
+	assert.Nil(t, decoded.Save(path))
+	defer os.Remove(path)
+	loaded, err := LoadIdentityBackup(path)
+	assert.Nil(t, err)
+
+	other := NewWallet("")
+	_, err = other.NewDefaultSettingIdentity(passwd)
+	assert.Nil(t, err)
+	imported, err := other.ImportIdentity(loaded, passwd, IDENTITY_CONFLICT_FAIL)
+	assert.Nil(t, err)
+	assert.Equal(t, identity.ID, imported.ID)
+	assert.Equal(t, "alice", imported.Label)
+	assert.False(t, imported.IsDefault)
+	controller, err := imported.GetControllerById("1", passwd)
+	assert.Nil(t, err)
+	origin, err := identity.GetControllerById("1", passwd)
+	assert.Nil(t, err)
+	assert.Equal(t, origin.PublicKey, controller.PublicKey)
+
+	_, err = other.ImportIdentity(loaded, passwd, IDENTITY_CONFLICT_FAIL)
+	assert.True(t, errors.Is(err, ERR_IDENTITY_EXISTS))
+	skipped, err := other.ImportIdentity(loaded, passwd, IDENTITY_CONFLICT_SKIP)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, skipped.ControllerCount())
+	assert.True(t, errors.Is(other.AddIdentity(identity), ERR_IDENTITY_EXISTS))
+
+	//controllers not in identity are merged
+	_, err = identity.NewDefaultSettingController("2", passwd)
+	assert.Nil(t, err)
+	backup, err = wallet.ExportIdentity(identity.ID, passwd)
+	assert.Nil(t, err)
+	merged, err := other.ImportIdentity(backup, passwd, IDENTITY_CONFLICT_MERGE)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, merged.ControllerCount())
+	//nothing is merged if any of controllers conflicts
+	_, err = merged.NewDefaultSettingController("4", passwd)
+	assert.Nil(t, err)
+	for _, id := range []string{"3", "4"} {
+		_, err = identity.NewDefaultSettingController(id, passwd)
+		assert.Nil(t, err)
+	}
+	backup, err = wallet.ExportIdentity(identity.ID, passwd)
+	assert.Nil(t, err)
+	_, err = other.ImportIdentity(backup, passwd, IDENTITY_CONFLICT_MERGE)
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, merged.ControllerCount())
+	_, err = merged.GetControllerDataById("3")
+	assert.Equal(t, ERR_CONTROLLER_NOT_FOUND, err)
+
+	//label is renamed to the first one not used
+	renamed := NewWallet("")
+	for _, label := range []string{"alice", "alice_1"} {
+		existing, err := renamed.NewDefaultSettingIdentity(passwd)
+		assert.Nil(t, err)
+		assert.Nil(t, renamed.SetIdentityLabel(existing.ID, label))
+	}
+	imported, err = renamed.ImportIdentity(loaded, passwd, IDENTITY_CONFLICT_FAIL)
+	assert.Nil(t, err)
+	assert.Equal(t, "alice_2", imported.Label)
+	byLabel, err := renamed.GetIdentityByLabel("alice_2")
+	assert.Nil(t, err)
+	assert.Equal(t, identity.ID, byLabel.ID)
+
+	//controllers can't be checked without node, so nothing is imported
+	unchecked := NewWallet("")
+	_, err = NewDNASdk().Native.OntId.ImportIdentity(unchecked, loaded, passwd, IDENTITY_CONFLICT_FAIL)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, unchecked.GetIdentityCount())
+
+	loaded.Identity.Control[0].Address = NewAccount().Address.ToBase58()
+	_, err = NewWallet("").ImportIdentity(loaded, passwd, IDENTITY_CONFLICT_FAIL)
+	assert.True(t, errors.Is(err, ERR_CONTROLLER_MISMATCH))
+
+	_, err = DecodeIdentityBackup(str[len(IDENTITY_BACKUP_PREFIX):])
+	assert.NotNil(t, err)
+}
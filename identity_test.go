@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDIDMethod(t *testing.T) {
+	method := NewDIDMethod("dna", 0x42)
+	id, err := method.CreateID([]byte("nonce"))
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(id, "did:dna:"))
+	assert.True(t, method.VerifyID(id))
+	assert.False(t, VerifyID(id))
+
+	did, err := ParseDID(id)
+	assert.Nil(t, err)
+	assert.Equal(t, "dna", did.Method)
+	assert.Equal(t, byte(0x42), did.Version)
+	assert.Equal(t, 20, len(did.Hash))
+	assert.Equal(t, id, did.String())
+	assert.Equal(t, id+"#keys-1", did.KeyId(1))
+
+	ontId, err := CreateID([]byte("nonce"))
+	assert.Nil(t, err)
+	_, err = method.ParseDID(ontId)
+	assert.True(t, errors.Is(err, ERR_DID_METHOD_MISMATCH))
+	ontDID, err := ParseDID(ontId)
+	assert.Nil(t, err)
+	assert.Equal(t, did.Hash, ontDID.Hash)
+}
+
+func TestWalletDIDMethod(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dna_wallet")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "wallet.dat")
+
+	sdk := NewDNASdk()
+	sdk.SetDIDMethod(NewDIDMethod("dna", VER))
+	wallet, err := sdk.CreateWallet(path)
+	assert.Nil(t, err)
+	identity, err := wallet.NewDefaultSettingIdentity([]byte("passwd"))
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(identity.ID, "did:dna:"))
+	ontIdentity, err := NewIdentity(wallet.Scrypt)
+	assert.Nil(t, err)
+	assert.NotNil(t, wallet.AddIdentity(ontIdentity))
+	assert.Nil(t, wallet.Save())
+
+	wallet, err = OpenWallet(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "dna", wallet.GetDIDMethod().Name)
+
+	//default method returned can be changed without affecting others
+	NewDNASdk().GetDIDMethod().Name = "dna"
+	assert.Equal(t, METHOD, NewDNASdk().GetDIDMethod().Name)
+}
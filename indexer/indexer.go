@@ -0,0 +1,353 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package indexer
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	dnaSdk "github.com/DNAProject/DNA-go-sdk"
+	"github.com/DNAProject/DNA-go-sdk/client"
+	sdkcom "github.com/DNAProject/DNA-go-sdk/common"
+	"github.com/DNAProject/DNA-go-sdk/oep4"
+	"github.com/DNAProject/DNA-go-sdk/utils"
+	"github.com/DNAProject/DNA/core/payload"
+	"github.com/DNAProject/DNA/smartcontract/event"
+)
+
+var (
+	DEFAULT_INDEXER_POLL_INTERVAL = 3 * time.Second
+	DEFAULT_INDEXER_BATCH_SIZE    = uint32(1000)
+)
+
+//IndexerConfig config of Indexer
+type IndexerConfig struct {
+	StartHeight    uint32                      //Height to start indexing if store is empty
+	PollInterval   time.Duration               //Interval of polling current block height of chain
+	BatchSize      uint32                      //Max count of blocks fetched by one BlockIterator
+	UseWebSocket   bool                        //Index blocks with events pushed by websocket client, polling is kept as fallback
+	IteratorConfig *client.BlockIteratorConfig //Config of BlockIterator used to fetch blocks
+	OnError        func(err error)             //Called when sync failed, indexer will retry at next round
+}
+
+func NewIndexerConfig() *IndexerConfig {
+	return &IndexerConfig{
+		PollInterval: DEFAULT_INDEXER_POLL_INTERVAL,
+		BatchSize:    DEFAULT_INDEXER_BATCH_SIZE,
+	}
+}
+
+//Indexer follow the chain tip, decode transactions, transfers and events of each block, and persist them into Store
+type Indexer struct {
+	sdk       *dnaSdk.DNASdk
+	store     Store
+	config    *IndexerConfig
+	blockCh   chan *client.BlockWithEvents
+	syncLock  sync.Mutex
+	exitCh    chan interface{}
+	exitOnce  sync.Once
+	startOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func NewIndexer(sdk *dnaSdk.DNASdk, store Store, config *IndexerConfig) *Indexer {
+	if config == nil {
+		config = NewIndexerConfig()
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DEFAULT_INDEXER_POLL_INTERVAL
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = DEFAULT_INDEXER_BATCH_SIZE
+	}
+	if config.IteratorConfig == nil {
+		config.IteratorConfig = client.NewBlockIteratorConfig()
+	}
+	config.IteratorConfig.WithoutEvents = false
+	return &Indexer{
+		sdk:     sdk,
+		store:   store,
+		config:  config,
+		blockCh: make(chan *client.BlockWithEvents),
+		exitCh:  make(chan interface{}),
+	}
+}
+
+//Start begin to index in background
+func (this *Indexer) Start() error {
+	var err error
+	this.startOnce.Do(func() {
+		if this.config.UseWebSocket {
+			ws := this.sdk.GetWebSocketClient()
+			if ws == nil {
+				err = fmt.Errorf("websocket client of dna is not set")
+				return
+			}
+			var sub *client.BlockWithEventsSubscription
+			//Dropped blocks are indexed by Sync, so never block the websocket client
+			sub, err = ws.SubscribeBlockWithEvents(&client.WSSubscriptionConfig{
+				BufferSize: client.DEFAULT_WS_SUBSCRIPTION_BUFFER_SIZE,
+				Policy:     client.BACKPRESSURE_DROP_OLDEST,
+			})
+			if err != nil {
+				err = fmt.Errorf("SubscribeBlockWithEvents error:%s", err)
+				return
+			}
+			this.wg.Add(1)
+			go this.watchWebSocket(sub)
+		}
+		this.wg.Add(1)
+		go this.run()
+	})
+	return err
+}
+
+//Close stop indexing and wait for background routines exit. Store is not closed.
+func (this *Indexer) Close() {
+	this.exitOnce.Do(func() {
+		close(this.exitCh)
+	})
+	this.wg.Wait()
+}
+
+func (this *Indexer) watchWebSocket(sub *client.BlockWithEventsSubscription) {
+	defer this.wg.Done()
+	defer sub.Close()
+	for {
+		select {
+		case block, ok := <-sub.Blocks():
+			if !ok {
+				return
+			}
+			select {
+			case this.blockCh <- block:
+			case <-this.exitCh:
+				return
+			}
+		case <-this.exitCh:
+			return
+		}
+	}
+}
+
+func (this *Indexer) run() {
+	defer this.wg.Done()
+	err := this.Sync()
+	for {
+		select {
+		case <-this.exitCh:
+			return
+		default:
+		}
+		if err != nil && this.config.OnError != nil {
+			this.config.OnError(err)
+		}
+		select {
+		case block := <-this.blockCh:
+			err = this.syncBlock(block)
+		case <-time.After(this.config.PollInterval):
+			err = this.Sync()
+		case <-this.exitCh:
+			return
+		}
+	}
+}
+
+//syncBlock save block pushed by websocket if it's the next height, otherwise sync to current block height
+func (this *Indexer) syncBlock(block *client.BlockWithEvents) error {
+	this.syncLock.Lock()
+	next, err := this.nextHeight()
+	if err != nil {
+		this.syncLock.Unlock()
+		return err
+	}
+	if block.Height < next {
+		this.syncLock.Unlock()
+		return nil
+	}
+	if block.Height == next {
+		err = this.store.SaveBlock(this.DecodeBlock(block))
+		this.syncLock.Unlock()
+		if err != nil {
+			return fmt.Errorf("save block:%d error:%s", block.Height, err)
+		}
+		return nil
+	}
+	this.syncLock.Unlock()
+	return this.Sync()
+}
+
+//Sync index blocks from the next height of indexed height to current block height of chain
+func (this *Indexer) Sync() error {
+	this.syncLock.Lock()
+	defer this.syncLock.Unlock()
+	curHeight, err := this.sdk.GetCurrentBlockHeight()
+	if err != nil {
+		return fmt.Errorf("GetCurrentBlockHeight error:%s", err)
+	}
+	for {
+		next, err := this.nextHeight()
+		if err != nil {
+			return err
+		}
+		if next > curHeight {
+			return nil
+		}
+		end := curHeight
+		if end-next >= this.config.BatchSize {
+			end = next + this.config.BatchSize - 1
+		}
+		err = this.syncRange(next, end)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (this *Indexer) nextHeight() (uint32, error) {
+	height, ok, err := this.store.GetIndexedHeight()
+	if err != nil {
+		return 0, fmt.Errorf("GetIndexedHeight error:%s", err)
+	}
+	if !ok {
+		return this.config.StartHeight, nil
+	}
+	return height + 1, nil
+}
+
+func (this *Indexer) syncRange(start, end uint32) error {
+	iterator, err := this.sdk.NewBlockIterator(start, end, this.config.IteratorConfig)
+	if err != nil {
+		return err
+	}
+	iterator.Start()
+	defer iterator.Close()
+	for {
+		select {
+		case block, ok := <-iterator.Blocks():
+			if !ok {
+				if iterator.Err() != nil {
+					return fmt.Errorf("fetch block error:%s", iterator.Err())
+				}
+				return nil
+			}
+			err = this.store.SaveBlock(this.DecodeBlock(block))
+			if err != nil {
+				return fmt.Errorf("save block:%d error:%s", block.Height, err)
+			}
+		case <-this.exitCh:
+			return fmt.Errorf("indexer closed")
+		}
+	}
+}
+
+//DecodeBlock decode transactions, transfers and events of block to records
+func (this *Indexer) DecodeBlock(block *client.BlockWithEvents) *BlockRecords {
+	records := &BlockRecords{
+		Height:    block.Height,
+		Txs:       make([]*TxRecord, 0, len(block.Block.Transactions)),
+		Transfers: make([]*TransferRecord, 0),
+		Events:    make([]*EventRecord, 0),
+	}
+	events := make(map[string]*sdkcom.SmartContactEvent, len(block.Events))
+	for _, evt := range block.Events {
+		events[evt.TxHash] = evt
+	}
+	for i, tx := range block.Block.Transactions {
+		txHash := tx.Hash()
+		txRecord := &TxRecord{
+			TxHash:  txHash.ToHexString(),
+			Height:  block.Height,
+			TxIndex: i,
+			TxType:  byte(tx.TxType),
+			Payer:   tx.Payer.ToBase58(),
+		}
+		invokeCode, ok := tx.Payload.(*payload.InvokeCode)
+		if ok {
+			txRecord.Payload, _ = dnaSdk.ParsePayload(invokeCode.Code)
+		}
+		records.Txs = append(records.Txs, txRecord)
+		evt, ok := events[txRecord.TxHash]
+		if !ok {
+			continue
+		}
+		txRecord.State = evt.State
+		txRecord.GasConsumed = evt.GasConsumed
+		for index, notify := range evt.Notify {
+			records.Events = append(records.Events, &EventRecord{
+				TxHash:     txRecord.TxHash,
+				Height:     block.Height,
+				EventIndex: index,
+				Contract:   notify.ContractAddress,
+				States:     notify.States,
+			})
+			transfer := this.decodeTransfer(notify)
+			if transfer != nil {
+				transfer.TxHash = txRecord.TxHash
+				transfer.Height = block.Height
+				transfer.EventIndex = index
+				records.Transfers = append(records.Transfers, transfer)
+			}
+		}
+	}
+	return records
+}
+
+//decodeTransfer return nil if notify is neither native transfer nor oep4 transfer.
+//Only notify of native token contract is decoded as native transfer, as any contract can notify the same states.
+func (this *Indexer) decodeTransfer(notify *sdkcom.NotifyEventInfo) *TransferRecord {
+	if isNativeTokenContract(notify.ContractAddress) {
+		nativeEvt, err := this.sdk.ParseNaitveTransferEvent(&event.NotifyEventInfo{States: notify.States})
+		if err != nil {
+			return nil
+		}
+		return &TransferRecord{
+			Contract: notify.ContractAddress,
+			Asset:    TRANSFER_ASSET_NATIVE,
+			From:     nativeEvt.From,
+			To:       nativeEvt.To,
+			Amount:   new(big.Int).SetUint64(nativeEvt.Amount),
+		}
+	}
+	oep4Evt, err := oep4.ParseTransferEvent(notify)
+	if err != nil || oep4Evt.Name != "transfer" {
+		return nil
+	}
+	if _, err := utils.AddressFromHexString(notify.ContractAddress); err != nil {
+		return nil
+	}
+	return &TransferRecord{
+		Contract: notify.ContractAddress,
+		Asset:    TRANSFER_ASSET_OEP4,
+		From:     oep4Evt.From.ToBase58(),
+		To:       oep4Evt.To.ToBase58(),
+		Amount:   oep4Evt.Amount,
+	}
+}
+
+func isNativeTokenContract(contractAddress string) bool {
+	return contractAddress == dnaSdk.GAS_CONTRACT_ADDRESS.ToHexString()
+}
+
+//GetIndexedHeight return the last indexed height, return false if nothing is indexed
+func (this *Indexer) GetIndexedHeight() (uint32, bool, error) {
+	return this.store.GetIndexedHeight()
+}
+
+//GetTransaction return nil if transaction is not indexed
+func (this *Indexer) GetTransaction(txHash string) (*TxRecord, error) {
+	return this.store.GetTransaction(txHash)
+}
+
+//GetTransfers return transfers from or to address in height range [startHeight, endHeight]
+func (this *Indexer) GetTransfers(address string, startHeight, endHeight uint32) ([]*TransferRecord, error) {
+	return this.store.GetTransfers(address, startHeight, endHeight)
+}
+
+//GetEvents return notifies of contract in height range [startHeight, endHeight]
+func (this *Indexer) GetEvents(contract string, startHeight, endHeight uint32) ([]*EventRecord, error) {
+	return this.store.GetEvents(contract, startHeight, endHeight)
+}
@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package indexer
+
+import (
+	"math/big"
+	"testing"
+
+	dnaSdk "github.com/DNAProject/DNA-go-sdk"
+	sdkcom "github.com/DNAProject/DNA-go-sdk/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeTransfer(t *testing.T) {
+	indexer := NewIndexer(dnaSdk.NewDNASdk(), NewKVStore(NewMemKVBackend()), nil)
+	from := dnaSdk.NewAccount().Address.ToBase58()
+	to := dnaSdk.NewAccount().Address.ToBase58()
+	notify := &sdkcom.NotifyEventInfo{
+		ContractAddress: dnaSdk.GAS_CONTRACT_ADDRESS.ToHexString(),
+		States:          []interface{}{"transfer", from, to, uint64(100)},
+	}
+	transfer := indexer.decodeTransfer(notify)
+	assert.NotNil(t, transfer)
+	assert.Equal(t, TRANSFER_ASSET_NATIVE, transfer.Asset)
+	assert.Equal(t, from, transfer.From)
+	assert.Equal(t, to, transfer.To)
+	assert.Equal(t, big.NewInt(100), transfer.Amount)
+
+	//the same states notified by other contract is not a native transfer
+	notify.ContractAddress = "0900000000000000000000000000000000000000"
+	assert.Nil(t, indexer.decodeTransfer(notify))
+}
@@ -0,0 +1,306 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package indexer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+//KVBackend is an embedded key-value database, such as leveldb or boltdb.
+//Keys are iterated in bytewise order.
+type KVBackend interface {
+	//Get return nil value if key not exist
+	Get(key []byte) ([]byte, error)
+	//Iterate call f for each key in range [start, limit), stop if f return false
+	Iterate(start, limit []byte, f func(key, value []byte) bool) error
+	NewBatch() KVBatch
+	Close() error
+}
+
+//KVBatch write all of puts atomically when commit
+type KVBatch interface {
+	Put(key, value []byte)
+	Commit() error
+}
+
+const (
+	KV_PREFIX_INDEXED_HEIGHT = byte(0x01)
+	KV_PREFIX_TX             = byte(0x02)
+	KV_PREFIX_ADDR_TRANSFER  = byte(0x03)
+	KV_PREFIX_CONTRACT_EVENT = byte(0x04)
+)
+
+//KV_MAX_OWNER_LEN is max length of address or contract in record key, as its length is saved in one byte
+const KV_MAX_OWNER_LEN = 255
+
+//ERR_KV_OWNER_TOO_LONG is returned when address or contract of record is longer than KV_MAX_OWNER_LEN
+var ERR_KV_OWNER_TOO_LONG = errors.New("owner of record is too long")
+
+//KVStore implement Store on KVBackend.
+//Transfer record is saved under both of from and to address, key is prefix + address + height + tx hash + event index.
+type KVStore struct {
+	backend KVBackend
+}
+
+func NewKVStore(backend KVBackend) *KVStore {
+	return &KVStore{backend: backend}
+}
+
+func (this *KVStore) SaveBlock(block *BlockRecords) error {
+	batch := this.backend.NewBatch()
+	for _, tx := range block.Txs {
+		value, err := json.Marshal(tx)
+		if err != nil {
+			return fmt.Errorf("marshal tx:%s error:%s", tx.TxHash, err)
+		}
+		batch.Put(kvKey(KV_PREFIX_TX, tx.TxHash), value)
+	}
+	for _, transfer := range block.Transfers {
+		value, err := json.Marshal(transfer)
+		if err != nil {
+			return fmt.Errorf("marshal transfer of tx:%s error:%s", transfer.TxHash, err)
+		}
+		owners := []string{transfer.From}
+		if transfer.To != transfer.From {
+			owners = append(owners, transfer.To)
+		}
+		for _, owner := range owners {
+			key, err := kvRecordKey(KV_PREFIX_ADDR_TRANSFER, owner, transfer.Height, transfer.TxHash, transfer.EventIndex)
+			if err != nil {
+				return fmt.Errorf("transfer of tx:%s error:%w", transfer.TxHash, err)
+			}
+			batch.Put(key, value)
+		}
+	}
+	for _, evt := range block.Events {
+		value, err := json.Marshal(evt)
+		if err != nil {
+			return fmt.Errorf("marshal event of tx:%s error:%s", evt.TxHash, err)
+		}
+		key, err := kvRecordKey(KV_PREFIX_CONTRACT_EVENT, evt.Contract, evt.Height, evt.TxHash, evt.EventIndex)
+		if err != nil {
+			return fmt.Errorf("event of tx:%s error:%w", evt.TxHash, err)
+		}
+		batch.Put(key, value)
+	}
+	height := make([]byte, 4)
+	binary.BigEndian.PutUint32(height, block.Height)
+	batch.Put([]byte{KV_PREFIX_INDEXED_HEIGHT}, height)
+	return batch.Commit()
+}
+
+func (this *KVStore) GetIndexedHeight() (uint32, bool, error) {
+	value, err := this.backend.Get([]byte{KV_PREFIX_INDEXED_HEIGHT})
+	if err != nil {
+		return 0, false, err
+	}
+	if value == nil {
+		return 0, false, nil
+	}
+	if len(value) != 4 {
+		return 0, false, fmt.Errorf("invalid indexed height:%x", value)
+	}
+	return binary.BigEndian.Uint32(value), true, nil
+}
+
+func (this *KVStore) GetTransaction(txHash string) (*TxRecord, error) {
+	value, err := this.backend.Get(kvKey(KV_PREFIX_TX, txHash))
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+	tx := &TxRecord{}
+	err = decodeJson(value, tx)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal tx:%s error:%s", txHash, err)
+	}
+	return tx, nil
+}
+
+func (this *KVStore) GetTransfers(address string, startHeight, endHeight uint32) ([]*TransferRecord, error) {
+	transfers := make([]*TransferRecord, 0)
+	start, limit, err := kvRangeKey(KV_PREFIX_ADDR_TRANSFER, address, startHeight, endHeight)
+	if err != nil {
+		return nil, err
+	}
+	var decodeErr error
+	err = this.backend.Iterate(start, limit, func(key, value []byte) bool {
+		transfer := &TransferRecord{}
+		decodeErr = decodeJson(value, transfer)
+		if decodeErr != nil {
+			return false
+		}
+		transfers = append(transfers, transfer)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("unmarshal transfer error:%s", decodeErr)
+	}
+	return transfers, nil
+}
+
+func (this *KVStore) GetEvents(contract string, startHeight, endHeight uint32) ([]*EventRecord, error) {
+	events := make([]*EventRecord, 0)
+	start, limit, err := kvRangeKey(KV_PREFIX_CONTRACT_EVENT, contract, startHeight, endHeight)
+	if err != nil {
+		return nil, err
+	}
+	var decodeErr error
+	err = this.backend.Iterate(start, limit, func(key, value []byte) bool {
+		evt := &EventRecord{}
+		decodeErr = decodeJson(value, evt)
+		if decodeErr != nil {
+			return false
+		}
+		events = append(events, evt)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("unmarshal event error:%s", decodeErr)
+	}
+	return events, nil
+}
+
+func (this *KVStore) Close() error {
+	return this.backend.Close()
+}
+
+func kvKey(prefix byte, key string) []byte {
+	return append([]byte{prefix}, []byte(key)...)
+}
+
+//kvRecordKey is prefix + len(owner) + owner + height + tx hash + event index.
+//Length of owner is included to avoid one address being the prefix of another.
+func kvRecordKey(prefix byte, owner string, height uint32, txHash string, eventIndex int) ([]byte, error) {
+	key, err := kvOwnerKey(prefix, owner)
+	if err != nil {
+		return nil, err
+	}
+	key = appendUint32(key, height)
+	key = append(key, []byte(txHash)...)
+	return appendUint32(key, uint32(eventIndex)), nil
+}
+
+func kvOwnerKey(prefix byte, owner string) ([]byte, error) {
+	if len(owner) > KV_MAX_OWNER_LEN {
+		return nil, fmt.Errorf("%w, length of:%s is %d", ERR_KV_OWNER_TOO_LONG, owner, len(owner))
+	}
+	key := []byte{prefix, byte(len(owner))}
+	return append(key, []byte(owner)...), nil
+}
+
+func kvRangeKey(prefix byte, owner string, startHeight, endHeight uint32) ([]byte, []byte, error) {
+	ownerKey, err := kvOwnerKey(prefix, owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	start := appendUint32(append([]byte{}, ownerKey...), startHeight)
+	var limit []byte
+	if endHeight == ^uint32(0) {
+		limit = kvPrefixLimit(ownerKey)
+	} else {
+		limit = appendUint32(append([]byte{}, ownerKey...), endHeight+1)
+	}
+	return start, limit, nil
+}
+
+//kvPrefixLimit return the smallest key which is greater than all of keys starting with prefix.
+//Prefix of record always starts with one of KV_PREFIX_*, so it has a byte less than 0xff.
+func kvPrefixLimit(prefix []byte) []byte {
+	limit := append([]byte{}, prefix...)
+	i := len(limit) - 1
+	for i > 0 && limit[i] == 0xff {
+		i--
+	}
+	limit[i]++
+	return limit[:i+1]
+}
+
+func appendUint32(key []byte, value uint32) []byte {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, value)
+	return append(key, buf...)
+}
+
+//MemKVBackend is an in-memory KVBackend, useful for testing and short-lived indexing
+type MemKVBackend struct {
+	data map[string][]byte
+	lock sync.RWMutex
+}
+
+func NewMemKVBackend() *MemKVBackend {
+	return &MemKVBackend{data: make(map[string][]byte)}
+}
+
+func (this *MemKVBackend) Get(key []byte) ([]byte, error) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	value, ok := this.data[string(key)]
+	if !ok {
+		return nil, nil
+	}
+	return value, nil
+}
+
+func (this *MemKVBackend) Iterate(start, limit []byte, f func(key, value []byte) bool) error {
+	this.lock.RLock()
+	keys := make([]string, 0)
+	for key := range this.data {
+		if bytes.Compare([]byte(key), start) >= 0 && bytes.Compare([]byte(key), limit) < 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	values := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, this.data[key])
+	}
+	this.lock.RUnlock()
+	for i, key := range keys {
+		if !f([]byte(key), values[i]) {
+			break
+		}
+	}
+	return nil
+}
+
+func (this *MemKVBackend) NewBatch() KVBatch {
+	return &memKVBatch{backend: this, data: make(map[string][]byte)}
+}
+
+func (this *MemKVBackend) Close() error {
+	return nil
+}
+
+type memKVBatch struct {
+	backend *MemKVBackend
+	data    map[string][]byte
+}
+
+func (this *memKVBatch) Put(key, value []byte) {
+	this.data[string(key)] = value
+}
+
+func (this *memKVBatch) Commit() error {
+	this.backend.lock.Lock()
+	defer this.backend.lock.Unlock()
+	for key, value := range this.data {
+		this.backend.data[key] = value
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package indexer
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKVStore(t *testing.T) {
+	store := NewKVStore(NewMemKVBackend())
+	_, ok, err := store.GetIndexedHeight()
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	for height := uint32(1); height <= 3; height++ {
+		txHash := string(rune('a' + height))
+		err = store.SaveBlock(&BlockRecords{
+			Height: height,
+			Txs:    []*TxRecord{{TxHash: txHash, Height: height, Payer: "A"}},
+			Transfers: []*TransferRecord{
+				{TxHash: txHash, Height: height, Asset: TRANSFER_ASSET_NATIVE, From: "A", To: "B", Amount: new(big.Int).SetUint64(1 << 60)},
+				{TxHash: txHash, Height: height, EventIndex: 1, Asset: TRANSFER_ASSET_OEP4, From: "B", To: "AB", Amount: big.NewInt(2)},
+			},
+			Events: []*EventRecord{{TxHash: txHash, Height: height, Contract: "c", States: []interface{}{"transfer", uint64(1 << 60)}}},
+		})
+		assert.Nil(t, err)
+	}
+	height, ok, err := store.GetIndexedHeight()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(3), height)
+
+	tx, err := store.GetTransaction("c")
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(2), tx.Height)
+	tx, err = store.GetTransaction("z")
+	assert.Nil(t, err)
+	assert.Nil(t, tx)
+
+	transfers, err := store.GetTransfers("A", 2, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(transfers))
+	assert.Equal(t, uint32(2), transfers[0].Height)
+	assert.Equal(t, new(big.Int).SetUint64(1<<60), transfers[0].Amount)
+
+	transfers, err = store.GetTransfers("B", 0, ^uint32(0))
+	assert.Nil(t, err)
+	assert.Equal(t, 6, len(transfers))
+
+	events, err := store.GetEvents("c", 3, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "1152921504606846976", events[0].States.([]interface{})[1].(json.Number).String())
+
+	//open-ended query of one owner doesn't include records of another owner with the same length
+	transfers, err = store.GetTransfers("A", 0, ^uint32(0))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(transfers))
+	for _, transfer := range transfers {
+		assert.Equal(t, "A", transfer.From)
+	}
+
+	longOwner := strings.Repeat("a", KV_MAX_OWNER_LEN+1)
+	err = store.SaveBlock(&BlockRecords{
+		Height:    4,
+		Transfers: []*TransferRecord{{TxHash: "e", Height: 4, From: "A", To: longOwner, Amount: big.NewInt(1)}},
+	})
+	assert.True(t, errors.Is(err, ERR_KV_OWNER_TOO_LONG))
+	_, err = store.GetEvents(longOwner, 0, ^uint32(0))
+	assert.True(t, errors.Is(err, ERR_KV_OWNER_TOO_LONG))
+	height, _, err = store.GetIndexedHeight()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(3), height)
+}
+
+func TestKVPrefixLimit(t *testing.T) {
+	assert.Equal(t, []byte{3, 1, 'B'}, kvPrefixLimit([]byte{3, 1, 'A'}))
+	assert.Equal(t, []byte{3, 2}, kvPrefixLimit([]byte{3, 1, 0xff, 0xff}))
+}
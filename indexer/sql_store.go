@@ -0,0 +1,222 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package indexer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+//SQLDialect adapt sql statement to database
+type SQLDialect struct {
+	//Placeholder return placeholder of the i-th (start from 1) parameter
+	Placeholder func(i int) string
+}
+
+var (
+	//SQL_DIALECT_DEFAULT is for sqlite and mysql
+	SQL_DIALECT_DEFAULT = &SQLDialect{Placeholder: func(i int) string { return "?" }}
+	//SQL_DIALECT_POSTGRES is for postgres
+	SQL_DIALECT_POSTGRES = &SQLDialect{Placeholder: func(i int) string { return fmt.Sprintf("$%d", i) }}
+)
+
+var sqlSchema = []string{
+	`CREATE TABLE IF NOT EXISTS idx_height (id INTEGER PRIMARY KEY, height BIGINT NOT NULL)`,
+	`CREATE TABLE IF NOT EXISTS idx_tx (tx_hash VARCHAR(64) PRIMARY KEY, height BIGINT NOT NULL, tx_index INTEGER NOT NULL, tx_type INTEGER NOT NULL, payer VARCHAR(64) NOT NULL, state INTEGER NOT NULL, gas_consumed BIGINT NOT NULL, payload TEXT)`,
+	`CREATE TABLE IF NOT EXISTS idx_transfer (tx_hash VARCHAR(64) NOT NULL, height BIGINT NOT NULL, event_index INTEGER NOT NULL, contract VARCHAR(64) NOT NULL, asset VARCHAR(16) NOT NULL, from_addr VARCHAR(64) NOT NULL, to_addr VARCHAR(64) NOT NULL, amount TEXT NOT NULL)`,
+	`CREATE INDEX IF NOT EXISTS idx_transfer_from ON idx_transfer (from_addr, height)`,
+	`CREATE INDEX IF NOT EXISTS idx_transfer_to ON idx_transfer (to_addr, height)`,
+	`CREATE TABLE IF NOT EXISTS idx_event (tx_hash VARCHAR(64) NOT NULL, height BIGINT NOT NULL, event_index INTEGER NOT NULL, contract VARCHAR(64) NOT NULL, states TEXT)`,
+	`CREATE INDEX IF NOT EXISTS idx_event_contract ON idx_event (contract, height)`,
+}
+
+//SQLStore implement Store on database/sql, driver of database should be imported by caller
+type SQLStore struct {
+	db      *sql.DB
+	dialect *SQLDialect
+}
+
+//NewSQLStore create tables if not exist. If dialect is nil, SQL_DIALECT_DEFAULT is used.
+func NewSQLStore(db *sql.DB, dialect *SQLDialect) (*SQLStore, error) {
+	if dialect == nil {
+		dialect = SQL_DIALECT_DEFAULT
+	}
+	for _, stmt := range sqlSchema {
+		_, err := db.Exec(stmt)
+		if err != nil {
+			return nil, fmt.Errorf("create table error:%s", err)
+		}
+	}
+	return &SQLStore{db: db, dialect: dialect}, nil
+}
+
+//bind replace each "?" in query with placeholder of dialect
+func (this *SQLStore) bind(query string) string {
+	parts := strings.Split(query, "?")
+	builder := strings.Builder{}
+	for i, part := range parts {
+		builder.WriteString(part)
+		if i < len(parts)-1 {
+			builder.WriteString(this.dialect.Placeholder(i + 1))
+		}
+	}
+	return builder.String()
+}
+
+func (this *SQLStore) SaveBlock(block *BlockRecords) (err error) {
+	dbTx, err := this.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin db transaction error:%s", err)
+	}
+	defer func() {
+		if err != nil {
+			dbTx.Rollback()
+		}
+	}()
+	for _, tx := range block.Txs {
+		payload, err := json.Marshal(tx.Payload)
+		if err != nil {
+			return fmt.Errorf("marshal payload of tx:%s error:%s", tx.TxHash, err)
+		}
+		_, err = dbTx.Exec(this.bind(`INSERT INTO idx_tx (tx_hash, height, tx_index, tx_type, payer, state, gas_consumed, payload) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+			tx.TxHash, int64(tx.Height), tx.TxIndex, int(tx.TxType), tx.Payer, int(tx.State), int64(tx.GasConsumed), string(payload))
+		if err != nil {
+			return fmt.Errorf("insert tx:%s error:%s", tx.TxHash, err)
+		}
+	}
+	for _, transfer := range block.Transfers {
+		_, err = dbTx.Exec(this.bind(`INSERT INTO idx_transfer (tx_hash, height, event_index, contract, asset, from_addr, to_addr, amount) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+			transfer.TxHash, int64(transfer.Height), transfer.EventIndex, transfer.Contract, transfer.Asset, transfer.From, transfer.To, transfer.Amount.String())
+		if err != nil {
+			return fmt.Errorf("insert transfer of tx:%s error:%s", transfer.TxHash, err)
+		}
+	}
+	for _, evt := range block.Events {
+		states, err := json.Marshal(evt.States)
+		if err != nil {
+			return fmt.Errorf("marshal states of tx:%s error:%s", evt.TxHash, err)
+		}
+		_, err = dbTx.Exec(this.bind(`INSERT INTO idx_event (tx_hash, height, event_index, contract, states) VALUES (?, ?, ?, ?, ?)`),
+			evt.TxHash, int64(evt.Height), evt.EventIndex, evt.Contract, string(states))
+		if err != nil {
+			return fmt.Errorf("insert event of tx:%s error:%s", evt.TxHash, err)
+		}
+	}
+	_, err = dbTx.Exec(`DELETE FROM idx_height`)
+	if err != nil {
+		return fmt.Errorf("delete indexed height error:%s", err)
+	}
+	_, err = dbTx.Exec(this.bind(`INSERT INTO idx_height (id, height) VALUES (?, ?)`), 1, int64(block.Height))
+	if err != nil {
+		return fmt.Errorf("insert indexed height error:%s", err)
+	}
+	err = dbTx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit db transaction error:%s", err)
+	}
+	return nil
+}
+
+func (this *SQLStore) GetIndexedHeight() (uint32, bool, error) {
+	var height int64
+	err := this.db.QueryRow(`SELECT height FROM idx_height`).Scan(&height)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return uint32(height), true, nil
+}
+
+func (this *SQLStore) GetTransaction(txHash string) (*TxRecord, error) {
+	var height, gasConsumed int64
+	var txType, state int
+	var payload sql.NullString
+	tx := &TxRecord{TxHash: txHash}
+	err := this.db.QueryRow(this.bind(`SELECT height, tx_index, tx_type, payer, state, gas_consumed, payload FROM idx_tx WHERE tx_hash = ?`), txHash).
+		Scan(&height, &tx.TxIndex, &txType, &tx.Payer, &state, &gasConsumed, &payload)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	tx.Height = uint32(height)
+	tx.TxType = byte(txType)
+	tx.State = byte(state)
+	tx.GasConsumed = uint64(gasConsumed)
+	if payload.Valid && payload.String != "" {
+		err = decodeJson([]byte(payload.String), &tx.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal payload of tx:%s error:%s", txHash, err)
+		}
+	}
+	return tx, nil
+}
+
+func (this *SQLStore) GetTransfers(address string, startHeight, endHeight uint32) ([]*TransferRecord, error) {
+	rows, err := this.db.Query(this.bind(`SELECT tx_hash, height, event_index, contract, asset, from_addr, to_addr, amount FROM idx_transfer
+		WHERE (from_addr = ? OR to_addr = ?) AND height >= ? AND height <= ? ORDER BY height, tx_hash, event_index`),
+		address, address, int64(startHeight), int64(endHeight))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	transfers := make([]*TransferRecord, 0)
+	for rows.Next() {
+		var height int64
+		var amount string
+		transfer := &TransferRecord{}
+		err = rows.Scan(&transfer.TxHash, &height, &transfer.EventIndex, &transfer.Contract, &transfer.Asset, &transfer.From, &transfer.To, &amount)
+		if err != nil {
+			return nil, err
+		}
+		transfer.Height = uint32(height)
+		value, ok := new(big.Int).SetString(amount, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid amount:%s of tx:%s", amount, transfer.TxHash)
+		}
+		transfer.Amount = value
+		transfers = append(transfers, transfer)
+	}
+	return transfers, rows.Err()
+}
+
+func (this *SQLStore) GetEvents(contract string, startHeight, endHeight uint32) ([]*EventRecord, error) {
+	rows, err := this.db.Query(this.bind(`SELECT tx_hash, height, event_index, contract, states FROM idx_event
+		WHERE contract = ? AND height >= ? AND height <= ? ORDER BY height, tx_hash, event_index`),
+		contract, int64(startHeight), int64(endHeight))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	events := make([]*EventRecord, 0)
+	for rows.Next() {
+		var height int64
+		var states sql.NullString
+		evt := &EventRecord{}
+		err = rows.Scan(&evt.TxHash, &height, &evt.EventIndex, &evt.Contract, &states)
+		if err != nil {
+			return nil, err
+		}
+		evt.Height = uint32(height)
+		if states.Valid && states.String != "" {
+			err = decodeJson([]byte(states.String), &evt.States)
+			if err != nil {
+				return nil, fmt.Errorf("unmarshal states of tx:%s error:%s", evt.TxHash, err)
+			}
+		}
+		events = append(events, evt)
+	}
+	return events, rows.Err()
+}
+
+//Close close the underlying database
+func (this *SQLStore) Close() error {
+	return this.db.Close()
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package indexer
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//fakeSQLConn record statements executed by SQLStore, queries always return no rows
+type fakeSQLConn struct {
+	execs     []string
+	args      [][]driver.Value
+	failOn    string
+	commits   int
+	rollbacks int
+}
+
+func (this *fakeSQLConn) Connect(ctx context.Context) (driver.Conn, error) { return this, nil }
+func (this *fakeSQLConn) Driver() driver.Driver                            { return nil }
+func (this *fakeSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeSQLStmt{conn: this, query: query}, nil
+}
+func (this *fakeSQLConn) Close() error              { return nil }
+func (this *fakeSQLConn) Begin() (driver.Tx, error) { return this, nil }
+func (this *fakeSQLConn) Commit() error             { this.commits++; return nil }
+func (this *fakeSQLConn) Rollback() error           { this.rollbacks++; return nil }
+
+type fakeSQLStmt struct {
+	conn  *fakeSQLConn
+	query string
+}
+
+func (this *fakeSQLStmt) Close() error  { return nil }
+func (this *fakeSQLStmt) NumInput() int { return -1 }
+func (this *fakeSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if this.conn.failOn != "" && strings.Contains(this.query, this.conn.failOn) {
+		return nil, fmt.Errorf("exec failed")
+	}
+	this.conn.execs = append(this.conn.execs, this.query)
+	this.conn.args = append(this.conn.args, args)
+	return driver.RowsAffected(1), nil
+}
+func (this *fakeSQLStmt) Query(args []driver.Value) (driver.Rows, error) { return &fakeSQLRows{}, nil }
+
+type fakeSQLRows struct{}
+
+func (this *fakeSQLRows) Columns() []string              { return []string{"height"} }
+func (this *fakeSQLRows) Close() error                   { return nil }
+func (this *fakeSQLRows) Next(dest []driver.Value) error { return io.EOF }
+
+func TestSQLStore(t *testing.T) {
+	conn := &fakeSQLConn{}
+	db := sql.OpenDB(conn)
+	db.SetMaxOpenConns(1)
+	store, err := NewSQLStore(db, SQL_DIALECT_POSTGRES)
+	assert.Nil(t, err)
+	defer store.Close()
+	assert.Equal(t, len(sqlSchema), len(conn.execs))
+	assert.Equal(t, "SELECT a FROM b WHERE c = $1 AND d = $2", store.bind("SELECT a FROM b WHERE c = ? AND d = ?"))
+
+	_, ok, err := store.GetIndexedHeight()
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	tx, err := store.GetTransaction("a")
+	assert.Nil(t, err)
+	assert.Nil(t, tx)
+
+	block := &BlockRecords{
+		Height:    1,
+		Txs:       []*TxRecord{{TxHash: "a", Height: 1, Payer: "A"}},
+		Transfers: []*TransferRecord{{TxHash: "a", Height: 1, Asset: TRANSFER_ASSET_NATIVE, From: "A", To: "B", Amount: big.NewInt(10)}},
+	}
+	conn.execs = nil
+	conn.args = nil
+	assert.Nil(t, store.SaveBlock(block))
+	assert.Equal(t, 1, conn.commits)
+	assert.Equal(t, 4, len(conn.execs))
+	assert.Contains(t, conn.execs[0], "VALUES ($1, $2, $3, $4, $5, $6, $7, $8)")
+	assert.Equal(t, "10", conn.args[1][7])
+	assert.Contains(t, conn.execs[3], "VALUES ($1, $2)")
+	assert.Equal(t, int64(1), conn.args[3][1])
+
+	//block is rolled back if any of records failed to save
+	conn.failOn = "idx_transfer"
+	assert.NotNil(t, store.SaveBlock(block))
+	assert.Equal(t, 1, conn.commits)
+	assert.Equal(t, 1, conn.rollbacks)
+}
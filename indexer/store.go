@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package indexer
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+)
+
+//TxRecord is an indexed transaction
+type TxRecord struct {
+	TxHash      string
+	Height      uint32
+	TxIndex     int
+	TxType      byte
+	Payer       string
+	State       byte
+	GasConsumed uint64
+	Payload     map[string]interface{} //Result of ParsePayload, nil if payload cannot be parsed
+}
+
+//TransferRecord is an indexed native transfer or oep4 transfer
+type TransferRecord struct {
+	TxHash     string
+	Height     uint32
+	EventIndex int
+	Contract   string //Hex string of contract address
+	Asset      string //TRANSFER_ASSET_NATIVE or TRANSFER_ASSET_OEP4
+	From       string //Base58 address
+	To         string //Base58 address
+	Amount     *big.Int
+}
+
+//EventRecord is an indexed notify of smart contract
+type EventRecord struct {
+	TxHash     string
+	Height     uint32
+	EventIndex int
+	Contract   string
+	States     interface{}
+}
+
+//BlockRecords is all of records of one block, which are saved atomically
+type BlockRecords struct {
+	Height    uint32
+	Txs       []*TxRecord
+	Transfers []*TransferRecord
+	Events    []*EventRecord
+}
+
+const (
+	TRANSFER_ASSET_NATIVE = "native"
+	TRANSFER_ASSET_OEP4   = "oep4"
+)
+
+//Store is the storage backend of Indexer.
+//Records of height range [startHeight, endHeight] are returned in order of height and event index.
+type Store interface {
+	//SaveBlock save records of block, and update indexed height to block height
+	SaveBlock(block *BlockRecords) error
+	//GetIndexedHeight return the last indexed height, return false if nothing is indexed
+	GetIndexedHeight() (uint32, bool, error)
+	//GetTransaction return nil if transaction is not indexed
+	GetTransaction(txHash string) (*TxRecord, error)
+	GetTransfers(address string, startHeight, endHeight uint32) ([]*TransferRecord, error)
+	GetEvents(contract string, startHeight, endHeight uint32) ([]*EventRecord, error)
+	Close() error
+}
+
+//decodeJson using json.Number for number, avoid precision lost of amount in event states
+func decodeJson(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
@@ -0,0 +1,295 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/DNAProject/DNA-go-sdk/client"
+	"github.com/DNAProject/DNA/core/types"
+	"github.com/ontio/ontology-crypto/keypair"
+	s "github.com/ontio/ontology-crypto/signature"
+)
+
+//Step of key rotation
+const (
+	KEY_ROTATION_STEP_ADD_KEY       = "addKey"
+	KEY_ROTATION_STEP_REVOKE_KEY    = "revokeKey"
+	KEY_ROTATION_STEP_UPDATE_WALLET = "updateWallet"
+	KEY_ROTATION_STEP_DONE          = "done"
+)
+
+//ERR_INVALID_KEY_ROTATION is returned when key rotation is malformed, or authorized by wrong signer
+var ERR_INVALID_KEY_ROTATION = errors.New("invalid key rotation")
+
+//KeyRotation is the state of key rotation or recovery workflow. It can be saved as JSON after each failed step,
+//and resumed by KeyManager.Resume later. Steps already done on chain are detected and not sent again.
+type KeyRotation struct {
+	OntId         string          `json:"ontId"`
+	Recovery      string          `json:"recovery,omitempty"`    //Base58 address of recovery, which authorize adding key in recovery workflow
+	RevokeKeys    []string        `json:"revokeKeys"`            //Hex public keys to revoke after new key is added
+	NewController *ControllerData `json:"newController"`         //New controller encrypted by password of rotation
+	NewKeyIndex   uint32          `json:"newKeyIndex,omitempty"` //Index of new key on chain, set after new key is added
+	Step          string          `json:"step"`
+}
+
+var (
+	DEFAULT_KEY_MANAGER_GAS_PRICE = uint64(500)
+	DEFAULT_KEY_MANAGER_GAS_LIMIT = uint64(20000)
+)
+
+//KeyManagerConfig config of KeyManager
+type KeyManagerConfig struct {
+	GasPrice     uint64
+	GasLimit     uint64
+	SubmitConfig *client.TxSubmitConfig //client.NewTxSubmitConfig() is used if nil
+}
+
+func NewKeyManagerConfig() *KeyManagerConfig {
+	return &KeyManagerConfig{
+		GasPrice: DEFAULT_KEY_MANAGER_GAS_PRICE,
+		GasLimit: DEFAULT_KEY_MANAGER_GAS_LIMIT,
+	}
+}
+
+//KeyManager rotate and recover keys of identities in wallet. Transactions are paid by payer,
+//and each of them is confirmed before next step.
+type KeyManager struct {
+	ontId  *OntId
+	wallet *Wallet
+	payer  *Account
+	config *KeyManagerConfig
+}
+
+//NewKeyManager return KeyManager of identities in wallet, NewKeyManagerConfig() is used if config is nil
+func (this *OntId) NewKeyManager(wallet *Wallet, payer *Account, config *KeyManagerConfig) *KeyManager {
+	if config == nil {
+		config = NewKeyManagerConfig()
+	}
+	return &KeyManager{
+		ontId:  this,
+		wallet: wallet,
+		payer:  payer,
+		config: config,
+	}
+}
+
+//StartRotation return key rotation to replace key of controller with a new generated key, which is encrypted by passwd
+func (this *KeyManager) StartRotation(ontId string, controller *Controller, passwd []byte, keyType keypair.KeyType, curveCode byte, sigScheme s.SignatureScheme) (*KeyRotation, error) {
+	err := this.ontId.dnaSkd.GetDIDMethod().CheckID(ontId)
+	if err != nil {
+		return nil, err
+	}
+	ctrData, err := NewControllerData("", keyType, curveCode, sigScheme, passwd, this.wallet.Scrypt)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyRotation{
+		OntId:         ontId,
+		RevokeKeys:    []string{hex.EncodeToString(keypair.SerializePublicKey(controller.PublicKey))},
+		NewController: ctrData,
+		Step:          KEY_ROTATION_STEP_ADD_KEY,
+	}, nil
+}
+
+//StartRecovery return key rotation to add a new generated key by recovery of identity, and revoke all of current keys.
+//New key is encrypted by passwd.
+func (this *KeyManager) StartRecovery(ontId string, recovery *Account, passwd []byte, keyType keypair.KeyType, curveCode byte, sigScheme s.SignatureScheme) (*KeyRotation, error) {
+	err := this.ontId.dnaSkd.GetDIDMethod().CheckID(ontId)
+	if err != nil {
+		return nil, err
+	}
+	owners, err := this.ontId.getActiveKeys(ontId)
+	if err != nil {
+		return nil, err
+	}
+	revokeKeys := make([]string, 0, len(owners))
+	for _, owner := range owners {
+		revokeKeys = append(revokeKeys, owner.Value)
+	}
+	ctrData, err := NewControllerData("", keyType, curveCode, sigScheme, passwd, this.wallet.Scrypt)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyRotation{
+		OntId:         ontId,
+		Recovery:      recovery.Address.ToBase58(),
+		RevokeKeys:    revokeKeys,
+		NewController: ctrData,
+		Step:          KEY_ROTATION_STEP_ADD_KEY,
+	}, nil
+}
+
+//RotateKey replace key of controller with a new generated key, and return the new controller.
+//If error is returned, rotation can be resumed by Resume with the same controller.
+func (this *KeyManager) RotateKey(ontId string, controller *Controller, passwd []byte, keyType keypair.KeyType, curveCode byte, sigScheme s.SignatureScheme) (*KeyRotation, *Controller, error) {
+	rotation, err := this.StartRotation(ontId, controller, passwd, keyType, curveCode, sigScheme)
+	if err != nil {
+		return nil, nil, err
+	}
+	newController, err := this.Resume(rotation, controller, passwd)
+	return rotation, newController, err
+}
+
+//Recover add a new generated key by recovery, revoke all of old keys, and return the new controller.
+//If error is returned, recovery can be resumed by Resume with the same recovery.
+func (this *KeyManager) Recover(ontId string, recovery *Account, passwd []byte, keyType keypair.KeyType, curveCode byte, sigScheme s.SignatureScheme) (*KeyRotation, *Controller, error) {
+	rotation, err := this.StartRecovery(ontId, recovery, passwd, keyType, curveCode, sigScheme)
+	if err != nil {
+		return nil, nil, err
+	}
+	newController, err := this.Resume(rotation, recovery, passwd)
+	return rotation, newController, err
+}
+
+//Resume run the remaining steps of rotation, and return the new controller when all steps are done.
+//authorizer is the controller of revoked key in rotation workflow, or the recovery account in recovery workflow,
+//which is only used to add the new key. passwd is the password of new controller.
+func (this *KeyManager) Resume(rotation *KeyRotation, authorizer Signer, passwd []byte) (*Controller, error) {
+	if rotation.NewController == nil {
+		return nil, fmt.Errorf("%w, rotation without new controller", ERR_INVALID_KEY_ROTATION)
+	}
+	//scrypt of controller is lost after rotation is saved as JSON
+	rotation.NewController.scrypt = this.wallet.Scrypt
+	newController, err := rotation.NewController.GetController(passwd)
+	if err != nil {
+		return nil, err
+	}
+	if rotation.Step == KEY_ROTATION_STEP_ADD_KEY {
+		err = this.addKey(rotation, authorizer, newController)
+		if err != nil {
+			return nil, fmt.Errorf("add key error:%w", err)
+		}
+		rotation.Step = KEY_ROTATION_STEP_REVOKE_KEY
+	}
+	if rotation.Step == KEY_ROTATION_STEP_REVOKE_KEY {
+		err = this.revokeKeys(rotation, newController)
+		if err != nil {
+			return nil, fmt.Errorf("revoke key error:%w", err)
+		}
+		rotation.Step = KEY_ROTATION_STEP_UPDATE_WALLET
+	}
+	if rotation.Step == KEY_ROTATION_STEP_UPDATE_WALLET {
+		err = this.updateWallet(rotation)
+		if err != nil {
+			return nil, fmt.Errorf("update wallet error:%w", err)
+		}
+		rotation.Step = KEY_ROTATION_STEP_DONE
+	}
+	if rotation.Step != KEY_ROTATION_STEP_DONE {
+		return nil, fmt.Errorf("%w, unknown step:%s", ERR_INVALID_KEY_ROTATION, rotation.Step)
+	}
+	newController.ID = rotation.NewController.ID
+	return newController, nil
+}
+
+func (this *KeyManager) addKey(rotation *KeyRotation, authorizer Signer, newController *Controller) error {
+	var tx *types.MutableTransaction
+	var err error
+	if rotation.Recovery != "" {
+		address := types.AddressFromPubKey(authorizer.GetPublicKey())
+		if address.ToBase58() != rotation.Recovery {
+			return fmt.Errorf("%w, signer:%s is not recovery:%s", ERR_INVALID_KEY_ROTATION, address.ToBase58(), rotation.Recovery)
+		}
+		tx, err = this.ontId.NewAddKeyByRecoveryTransaction(this.config.GasPrice, this.config.GasLimit, rotation.OntId, newController.PublicKey, address)
+	} else {
+		pubKey := hex.EncodeToString(keypair.SerializePublicKey(authorizer.GetPublicKey()))
+		if len(rotation.RevokeKeys) == 0 || rotation.RevokeKeys[0] != pubKey {
+			return fmt.Errorf("%w, signer:%s is not the rotated key", ERR_INVALID_KEY_ROTATION, pubKey)
+		}
+		tx, err = this.ontId.NewAddKeyTransaction(this.config.GasPrice, this.config.GasLimit, rotation.OntId, newController.PublicKey, authorizer.GetPublicKey())
+	}
+	if err != nil {
+		return err
+	}
+	newKey := hex.EncodeToString(keypair.SerializePublicKey(newController.PublicKey))
+	owner, err := this.getActiveKey(rotation.OntId, newKey)
+	if err != nil {
+		return err
+	}
+	if owner == nil {
+		err = this.ontId.dnaSkd.signAndSubmit(tx, this.payer, authorizer, this.config.SubmitConfig)
+		if err != nil {
+			return err
+		}
+		owner, err = this.getActiveKey(rotation.OntId, newKey)
+		if err != nil {
+			return err
+		}
+		if owner == nil {
+			return fmt.Errorf("new key:%s not found in GetPublicKeys after confirmed", newKey)
+		}
+	}
+	rotation.NewKeyIndex = owner.GetIndex()
+	return nil
+}
+
+func (this *KeyManager) revokeKeys(rotation *KeyRotation, newController *Controller) error {
+	for _, revokeKey := range rotation.RevokeKeys {
+		owner, err := this.getActiveKey(rotation.OntId, revokeKey)
+		if err != nil {
+			return err
+		}
+		if owner == nil {
+			continue
+		}
+		pkData, err := hex.DecodeString(revokeKey)
+		if err != nil {
+			return fmt.Errorf("public key:%s hex decode error:%s", revokeKey, err)
+		}
+		pubKey, err := keypair.DeserializePublicKey(pkData)
+		if err != nil {
+			return fmt.Errorf("DeserializePublicKey:%s error:%s", revokeKey, err)
+		}
+		tx, err := this.ontId.NewRevokeKeyTransaction(this.config.GasPrice, this.config.GasLimit, rotation.OntId, pubKey, newController.PublicKey)
+		if err != nil {
+			return err
+		}
+		err = this.ontId.dnaSkd.signAndSubmit(tx, this.payer, newController, this.config.SubmitConfig)
+		if err != nil {
+			return fmt.Errorf("key:%s error:%w", owner.PubKeyId, err)
+		}
+	}
+	//verify keys of identity after all of old keys are revoked
+	owners, err := this.ontId.getActiveKeys(rotation.OntId)
+	if err != nil {
+		return err
+	}
+	for _, owner := range owners {
+		for _, revokeKey := range rotation.RevokeKeys {
+			if owner.Value == revokeKey {
+				return fmt.Errorf("key:%s still in use after confirmed", owner.PubKeyId)
+			}
+		}
+	}
+	return nil
+}
+
+//updateWallet replace controllers of revoked keys with new controller, whose id is index of new key.
+//Identity is added to wallet if not exist, such as recovered identity.
+func (this *KeyManager) updateWallet(rotation *KeyRotation) error {
+	rotation.NewController.ID = strconv.Itoa(int(rotation.NewKeyIndex))
+	err := this.wallet.replaceControllers(rotation.OntId, rotation.RevokeKeys, rotation.NewController.Clone())
+	if err != nil {
+		return err
+	}
+	return this.wallet.Save()
+}
+
+//getActiveKey return key of identity on chain by hex public key, or nil if not exist or revoked
+func (this *KeyManager) getActiveKey(ontId, pubKey string) (*DDOOwner, error) {
+	owners, err := this.ontId.getActiveKeys(ontId)
+	if err != nil {
+		return nil, err
+	}
+	for _, owner := range owners {
+		if owner.Value == pubKey {
+			return owner, nil
+		}
+	}
+	return nil, nil
+}
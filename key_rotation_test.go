@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	s "github.com/ontio/ontology-crypto/signature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyRotationResume(t *testing.T) {
+	sdk := NewDNASdk()
+	wallet := NewWallet("")
+	passwd := []byte("passwd")
+	manager := sdk.Native.OntId.NewKeyManager(wallet, nil, &KeyManagerConfig{GasPrice: 0, GasLimit: 20000})
+	ontId, err := GenerateID()
+	assert.Nil(t, err)
+	oldController := newTestController(t, keypair.PK_ECDSA, keypair.P256, s.SHA256withECDSA)
+
+	rotation, err := manager.StartRotation(ontId, oldController, passwd, keypair.PK_EDDSA, keypair.ED25519, s.SHA512withEdDSA)
+	assert.Nil(t, err)
+	assert.Equal(t, KEY_ROTATION_STEP_ADD_KEY, rotation.Step)
+	assert.Equal(t, []string{hex.EncodeToString(keypair.SerializePublicKey(oldController.PublicKey))}, rotation.RevokeKeys)
+
+	data, err := json.Marshal(rotation)
+	assert.Nil(t, err)
+	saved := &KeyRotation{}
+	err = json.Unmarshal(data, saved)
+	assert.Nil(t, err)
+	assert.Equal(t, rotation.NewController.Public, saved.NewController.Public)
+
+	_, err = manager.Resume(saved, oldController, []byte("wrong"))
+	assert.NotNil(t, err)
+	other := newTestController(t, keypair.PK_ECDSA, keypair.P256, s.SHA256withECDSA)
+	_, err = manager.Resume(saved, other, passwd)
+	assert.True(t, errors.Is(err, ERR_INVALID_KEY_ROTATION))
+	assert.Equal(t, KEY_ROTATION_STEP_ADD_KEY, saved.Step)
+
+	saved.Step = "unknown"
+	_, err = manager.Resume(saved, oldController, passwd)
+	assert.True(t, errors.Is(err, ERR_INVALID_KEY_ROTATION))
+
+	_, err = manager.StartRotation("did:ont:", oldController, passwd, keypair.PK_ECDSA, keypair.P256, s.SHA256withECDSA)
+	assert.NotNil(t, err)
+}
+
+func TestKeyManagerUpdateWallet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dna_wallet")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	wallet := NewWallet(filepath.Join(dir, "wallet.dat"))
+	passwd := []byte("passwd")
+	manager := NewDNASdk().Native.OntId.NewKeyManager(wallet, nil, nil)
+	assert.Equal(t, DEFAULT_KEY_MANAGER_GAS_LIMIT, manager.config.GasLimit)
+	ontId, err := GenerateID()
+	assert.Nil(t, err)
+	newController := func() *ControllerData {
+		ctrData, err := NewControllerData("", keypair.PK_ECDSA, keypair.P256, s.SHA256withECDSA, passwd, wallet.Scrypt)
+		assert.Nil(t, err)
+		return ctrData
+	}
+
+	//identity is added to wallet if not exist
+	first := newController()
+	rotation := &KeyRotation{OntId: ontId, NewController: first, NewKeyIndex: 1}
+	assert.Nil(t, manager.updateWallet(rotation))
+	identity, err := wallet.GetIdentityById(ontId)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, identity.ControllerCount())
+
+	second := newController()
+	rotation = &KeyRotation{OntId: ontId, RevokeKeys: []string{first.Public}, NewController: second, NewKeyIndex: 2}
+	assert.Nil(t, manager.updateWallet(rotation))
+	assert.Equal(t, 1, identity.ControllerCount())
+	ctrData, err := identity.GetControllerDataById("2")
+	assert.Nil(t, err)
+	assert.Equal(t, second.Public, ctrData.Public)
+
+	//identity is unchanged if new controller conflicts with controller not revoked
+	rotation = &KeyRotation{OntId: ontId, NewController: newController(), NewKeyIndex: 2}
+	assert.NotNil(t, manager.updateWallet(rotation))
+	assert.Equal(t, 1, identity.ControllerCount())
+	ctrData, err = identity.GetControllerDataById("2")
+	assert.Nil(t, err)
+	assert.Equal(t, second.Public, ctrData.Public)
+}
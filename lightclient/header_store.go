@@ -0,0 +1,297 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package lightclient
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/DNAProject/DNA/common"
+	"github.com/DNAProject/DNA/core/types"
+	"github.com/ontio/ontology-crypto/keypair"
+)
+
+//HeaderStore persist the trusted header chain, with the validator set of blocks after the latest header.
+//Headers are saved in order of height, starting from the checkpoint.
+type HeaderStore interface {
+	SaveHeader(header *types.Header, validators []keypair.PublicKey) error
+	//GetHeader return nil if header is not found
+	GetHeader(height uint32) (*types.Header, error)
+	//GetCurrentHeader return the latest header and validator set, header is nil if store is empty
+	GetCurrentHeader() (*types.Header, []keypair.PublicKey, error)
+	Close() error
+}
+
+//MemHeaderStore is a HeaderStore in memory, which is lost after restart
+type MemHeaderStore struct {
+	headers    map[uint32]*types.Header
+	current    *types.Header
+	validators []keypair.PublicKey
+	lock       sync.RWMutex
+}
+
+func NewMemHeaderStore() *MemHeaderStore {
+	return &MemHeaderStore{headers: make(map[uint32]*types.Header)}
+}
+
+func (this *MemHeaderStore) SaveHeader(header *types.Header, validators []keypair.PublicKey) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.current != nil && header.Height != this.current.Height+1 {
+		return fmt.Errorf("header:%d is not next to current header:%d", header.Height, this.current.Height)
+	}
+	this.headers[header.Height] = header
+	this.current = header
+	this.validators = validators
+	return nil
+}
+
+func (this *MemHeaderStore) GetHeader(height uint32) (*types.Header, error) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.headers[height], nil
+}
+
+func (this *MemHeaderStore) GetCurrentHeader() (*types.Header, []keypair.PublicKey, error) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.current, this.validators, nil
+}
+
+func (this *MemHeaderStore) Close() error {
+	return nil
+}
+
+//MAX_HEADER_RECORD_SIZE is the max size of record in header file, which is far larger than header with validators
+const MAX_HEADER_RECORD_SIZE = 4 * 1024 * 1024
+
+//ERR_HEADER_RECORD_TOO_LARGE is returned when size of record in header file exceeds MAX_HEADER_RECORD_SIZE
+var ERR_HEADER_RECORD_TOO_LARGE = errors.New("header record too large")
+
+//FileHeaderStore is a HeaderStore of an append only file. Each record is
+//
+//  [record length uint32][header length uint32][header][changed byte]([validator count uint32]([key length uint32][key])*)
+//
+//Validator set is written only if it's changed. Offsets of records are indexed in memory when file is opened.
+type FileHeaderStore struct {
+	file       *os.File
+	offsets    []int64
+	start      uint32
+	size       int64
+	current    *types.Header
+	validators []keypair.PublicKey
+	lock       sync.RWMutex
+}
+
+//OpenFileHeaderStore open or create the header file. Incomplete record at the end of file is truncated.
+func OpenFileHeaderStore(path string) (*FileHeaderStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open header file:%s error:%s", path, err)
+	}
+	store := &FileHeaderStore{file: file}
+	err = store.load()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+func (this *FileHeaderStore) load() error {
+	info, err := this.file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat header file error:%s", err)
+	}
+	fileSize := info.Size()
+	offset := int64(0)
+	for offset < fileSize {
+		header, validators, size, err := this.readRecord(offset)
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read header record at:%d error:%w", offset, err)
+		}
+		if this.current == nil {
+			this.start = header.Height
+		} else if header.Height != this.current.Height+1 {
+			return fmt.Errorf("header:%d at:%d is not next to header:%d", header.Height, offset, this.current.Height)
+		}
+		this.offsets = append(this.offsets, offset)
+		this.current = header
+		if validators != nil {
+			this.validators = validators
+		}
+		offset += size
+	}
+	if offset < fileSize {
+		err = this.file.Truncate(offset)
+		if err != nil {
+			return fmt.Errorf("truncate header file error:%s", err)
+		}
+	}
+	this.size = offset
+	return nil
+}
+
+func (this *FileHeaderStore) readRecord(offset int64) (*types.Header, []keypair.PublicKey, int64, error) {
+	buf := make([]byte, 4)
+	_, err := this.file.ReadAt(buf, offset)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	recordSize := binary.LittleEndian.Uint32(buf)
+	if recordSize > MAX_HEADER_RECORD_SIZE {
+		return nil, nil, 0, fmt.Errorf("%w, size:%d", ERR_HEADER_RECORD_TOO_LARGE, recordSize)
+	}
+	data := make([]byte, recordSize)
+	_, err = this.file.ReadAt(data, offset+4)
+	if err == io.EOF {
+		return nil, nil, 0, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	headerData, data, err := nextBytes(data)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	header := &types.Header{}
+	err = header.Deserialization(common.NewZeroCopySource(headerData))
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("header deserialization error:%s", err)
+	}
+	size := int64(4 + recordSize)
+	if len(data) < 1 {
+		return nil, nil, 0, io.ErrUnexpectedEOF
+	}
+	if data[0] == 0 {
+		return header, nil, size, nil
+	}
+	if len(data) < 5 {
+		return nil, nil, 0, io.ErrUnexpectedEOF
+	}
+	count := binary.LittleEndian.Uint32(data[1:])
+	data = data[5:]
+	validators := make([]keypair.PublicKey, 0, count)
+	for i := uint32(0); i < count; i++ {
+		var keyData []byte
+		keyData, data, err = nextBytes(data)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		pubKey, err := keypair.DeserializePublicKey(keyData)
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("DeserializePublicKey error:%s", err)
+		}
+		validators = append(validators, pubKey)
+	}
+	return header, validators, size, nil
+}
+
+func nextBytes(data []byte) ([]byte, []byte, error) {
+	if len(data) < 4 {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	size := binary.LittleEndian.Uint32(data)
+	if uint32(len(data)-4) < size {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	return data[4 : 4+size], data[4+size:], nil
+}
+
+func appendBytes(buf, data []byte) []byte {
+	buf = appendUint32(buf, uint32(len(data)))
+	return append(buf, data...)
+}
+
+func appendUint32(buf []byte, value uint32) []byte {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, value)
+	return append(buf, data...)
+}
+
+func (this *FileHeaderStore) SaveHeader(header *types.Header, validators []keypair.PublicKey) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.current != nil && header.Height != this.current.Height+1 {
+		return fmt.Errorf("header:%d is not next to current header:%d", header.Height, this.current.Height)
+	}
+	sink := common.NewZeroCopySink(nil)
+	header.Serialization(sink)
+	record := appendBytes(nil, sink.Bytes())
+	changed := this.current == nil || !sameValidators(this.validators, validators)
+	if changed {
+		record = append(record, 1)
+		record = appendUint32(record, uint32(len(validators)))
+		for _, validator := range validators {
+			record = appendBytes(record, keypair.SerializePublicKey(validator))
+		}
+	} else {
+		record = append(record, 0)
+	}
+	if len(record) > MAX_HEADER_RECORD_SIZE {
+		return fmt.Errorf("header:%d error:%w, size:%d", header.Height, ERR_HEADER_RECORD_TOO_LARGE, len(record))
+	}
+	data := appendBytes(nil, record)
+	_, err := this.file.WriteAt(data, this.size)
+	if err != nil {
+		return fmt.Errorf("write header:%d error:%s", header.Height, err)
+	}
+	err = this.file.Sync()
+	if err != nil {
+		return fmt.Errorf("sync header file error:%s", err)
+	}
+	if this.current == nil {
+		this.start = header.Height
+	}
+	this.offsets = append(this.offsets, this.size)
+	this.size += int64(len(data))
+	this.current = header
+	this.validators = validators
+	return nil
+}
+
+func (this *FileHeaderStore) GetHeader(height uint32) (*types.Header, error) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	if this.current == nil || height < this.start || height > this.current.Height {
+		return nil, nil
+	}
+	if height == this.current.Height {
+		return this.current, nil
+	}
+	header, _, _, err := this.readRecord(this.offsets[height-this.start])
+	if err != nil {
+		return nil, fmt.Errorf("read header:%d error:%s", height, err)
+	}
+	return header, nil
+}
+
+func (this *FileHeaderStore) GetCurrentHeader() (*types.Header, []keypair.PublicKey, error) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.current, this.validators, nil
+}
+
+func (this *FileHeaderStore) Close() error {
+	return this.file.Close()
+}
+
+func sameValidators(a, b []keypair.PublicKey) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !keypair.ComparePublicKey(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package lightclient
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dnaSdk "github.com/DNAProject/DNA-go-sdk"
+	"github.com/DNAProject/DNA/core/types"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileHeaderStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lightclient")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "headers")
+
+	signers := []*dnaSdk.Account{dnaSdk.NewAccount()}
+	store, err := OpenFileHeaderStore(path)
+	assert.Nil(t, err)
+	header := &types.Header{Height: 5, Timestamp: 100}
+	assert.Nil(t, store.SaveHeader(header, []keypair.PublicKey{signers[0].PublicKey}))
+	for i := 0; i < 3; i++ {
+		header = newSignedHeader(t, header, signers)
+		assert.Nil(t, store.SaveHeader(header, []keypair.PublicKey{signers[0].PublicKey}))
+	}
+	assert.NotNil(t, store.SaveHeader(&types.Header{Height: 10}, nil))
+	assert.Nil(t, store.Close())
+
+	store, err = OpenFileHeaderStore(path)
+	assert.Nil(t, err)
+	defer store.Close()
+	current, validators, err := store.GetCurrentHeader()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(8), current.Height)
+	assert.Equal(t, header.Hash(), current.Hash())
+	assert.Equal(t, 1, len(validators))
+	assert.True(t, keypair.ComparePublicKey(signers[0].PublicKey, validators[0]))
+
+	prev, err := store.GetHeader(7)
+	assert.Nil(t, err)
+	assert.Equal(t, header.PrevBlockHash, prev.Hash())
+	missing, err := store.GetHeader(4)
+	assert.Nil(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestFileHeaderStore_Tail(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lightclient")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "headers")
+
+	signers := []*dnaSdk.Account{dnaSdk.NewAccount()}
+	store, err := OpenFileHeaderStore(path)
+	assert.Nil(t, err)
+	header := &types.Header{Height: 5, Timestamp: 100}
+	assert.Nil(t, store.SaveHeader(header, []keypair.PublicKey{signers[0].PublicKey}))
+	header = newSignedHeader(t, header, signers)
+	assert.Nil(t, store.SaveHeader(header, []keypair.PublicKey{signers[0].PublicKey}))
+	assert.Nil(t, store.Close())
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	size := info.Size()
+
+	//incomplete record of interrupted write is truncated
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	assert.Nil(t, err)
+	partial := make([]byte, 10)
+	binary.LittleEndian.PutUint32(partial, 100)
+	_, err = file.Write(partial)
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+	store, err = OpenFileHeaderStore(path)
+	assert.Nil(t, err)
+	current, _, err := store.GetCurrentHeader()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(6), current.Height)
+	next := newSignedHeader(t, header, signers)
+	assert.Nil(t, store.SaveHeader(next, []keypair.PublicKey{signers[0].PublicKey}))
+	assert.Nil(t, store.Close())
+
+	store, err = OpenFileHeaderStore(path)
+	assert.Nil(t, err)
+	saved, err := store.GetHeader(7)
+	assert.Nil(t, err)
+	assert.Equal(t, next.Hash(), saved.Hash())
+	assert.Nil(t, store.Close())
+
+	//corrupt size of record is rejected without allocating it
+	assert.Nil(t, os.Truncate(path, size))
+	file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	assert.Nil(t, err)
+	corrupt := make([]byte, 8)
+	binary.LittleEndian.PutUint32(corrupt, 0xffffffff)
+	_, err = file.Write(corrupt)
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+	_, err = OpenFileHeaderStore(path)
+	assert.True(t, errors.Is(err, ERR_HEADER_RECORD_TOO_LARGE))
+}
@@ -0,0 +1,266 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package lightclient
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	dnaSdk "github.com/DNAProject/DNA-go-sdk"
+	"github.com/DNAProject/DNA-go-sdk/client"
+	"github.com/DNAProject/DNA/common"
+	"github.com/DNAProject/DNA/core/types"
+	"github.com/ontio/ontology-crypto/keypair"
+)
+
+var (
+	DEFAULT_LIGHT_CLIENT_POLL_INTERVAL = 3 * time.Second
+)
+
+//LightClientConfig config of LightClient
+type LightClientConfig struct {
+	Checkpoint   *types.Header       //Trusted header to start from if store is empty, which should be obtained out of band
+	Validators   []keypair.PublicKey //Consensus validator set of blocks after checkpoint, required with checkpoint
+	PollInterval time.Duration       //Interval of polling current block height of chain
+	OnError      func(err error)     //Called when sync failed, light client will retry at next round
+}
+
+//NewLightClientConfig return config starting from checkpoint, validators is the consensus validator set
+//of blocks after checkpoint, such as peers of vbft chain config, which should be obtained out of band with checkpoint.
+func NewLightClientConfig(checkpoint *types.Header, validators []keypair.PublicKey) *LightClientConfig {
+	return &LightClientConfig{
+		Checkpoint:   checkpoint,
+		Validators:   validators,
+		PollInterval: DEFAULT_LIGHT_CLIENT_POLL_INTERVAL,
+	}
+}
+
+//LightClient download headers from node, verify that each header is linked to the previous trusted header
+//and signed by the validators, and persist the trusted header chain into HeaderStore.
+//Node is not trusted, a header which fails verification is never stored.
+type LightClient struct {
+	sdk        *dnaSdk.DNASdk
+	store      HeaderStore
+	config     *LightClientConfig
+	current    *types.Header
+	validators []keypair.PublicKey
+	lock       sync.RWMutex
+	syncLock   sync.Mutex
+	exitCh     chan interface{}
+	exitOnce   sync.Once
+	startOnce  sync.Once
+	wg         sync.WaitGroup
+}
+
+//NewLightClient create light client from the latest header of store, or from checkpoint of config if store is empty
+func NewLightClient(sdk *dnaSdk.DNASdk, store HeaderStore, config *LightClientConfig) (*LightClient, error) {
+	if config == nil {
+		config = NewLightClientConfig(nil, nil)
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DEFAULT_LIGHT_CLIENT_POLL_INTERVAL
+	}
+	current, validators, err := store.GetCurrentHeader()
+	if err != nil {
+		return nil, fmt.Errorf("GetCurrentHeader error:%s", err)
+	}
+	if current == nil {
+		if config.Checkpoint == nil {
+			return nil, ERR_NO_TRUSTED_HEADER
+		}
+		//Bookkeepers of checkpoint are only its signers, not the whole validator set
+		if len(config.Validators) == 0 {
+			return nil, ERR_NO_VALIDATORS
+		}
+		current = config.Checkpoint
+		validators = config.Validators
+		err = store.SaveHeader(current, validators)
+		if err != nil {
+			return nil, fmt.Errorf("save checkpoint:%d error:%s", current.Height, err)
+		}
+	}
+	return &LightClient{
+		sdk:        sdk,
+		store:      store,
+		config:     config,
+		current:    current,
+		validators: validators,
+		exitCh:     make(chan interface{}),
+	}, nil
+}
+
+//Start begin to sync headers in background
+func (this *LightClient) Start() {
+	this.startOnce.Do(func() {
+		this.wg.Add(1)
+		go this.run()
+	})
+}
+
+//Close stop syncing and wait for background routine exit. Store is not closed.
+func (this *LightClient) Close() {
+	this.exitOnce.Do(func() {
+		close(this.exitCh)
+	})
+	this.wg.Wait()
+}
+
+func (this *LightClient) run() {
+	defer this.wg.Done()
+	for {
+		err := this.Sync()
+		select {
+		case <-this.exitCh:
+			return
+		default:
+		}
+		if err != nil && this.config.OnError != nil {
+			this.config.OnError(err)
+		}
+		select {
+		case <-time.After(this.config.PollInterval):
+		case <-this.exitCh:
+			return
+		}
+	}
+}
+
+//Sync download and verify headers from the next height of trusted height to current block height of chain
+func (this *LightClient) Sync() error {
+	curHeight, err := this.sdk.GetCurrentBlockHeight()
+	if err != nil {
+		return fmt.Errorf("GetCurrentBlockHeight error:%s", err)
+	}
+	return this.SyncTo(curHeight)
+}
+
+//SyncTo download and verify headers until trusted height reaches height
+func (this *LightClient) SyncTo(height uint32) error {
+	this.syncLock.Lock()
+	defer this.syncLock.Unlock()
+	for next := this.GetTrustedHeight() + 1; next <= height; next++ {
+		select {
+		case <-this.exitCh:
+			return fmt.Errorf("light client closed")
+		default:
+		}
+		block, err := this.sdk.GetBlockByHeight(next)
+		if err != nil {
+			return fmt.Errorf("GetBlockByHeight:%d error:%s", next, err)
+		}
+		err = this.addHeader(block.Header)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//AddHeader verify header obtained elsewhere, such as websocket push, and append it to the trusted header chain
+func (this *LightClient) AddHeader(header *types.Header) error {
+	this.syncLock.Lock()
+	defer this.syncLock.Unlock()
+	return this.addHeader(header)
+}
+
+func (this *LightClient) addHeader(header *types.Header) error {
+	this.lock.RLock()
+	current := this.current
+	validators := this.validators
+	this.lock.RUnlock()
+	if header.Height <= current.Height {
+		//Already trusted, but a different header of the same height means the node is on a fork
+		trusted, err := this.store.GetHeader(header.Height)
+		if err != nil {
+			return fmt.Errorf("GetHeader:%d error:%s", header.Height, err)
+		}
+		if trusted != nil && trusted.Hash() != header.Hash() {
+			return fmt.Errorf("%w, height:%d conflicts with trusted header", ERR_BLOCK_MISMATCH, header.Height)
+		}
+		return nil
+	}
+	err := VerifyHeader(current, header, validators)
+	if err != nil {
+		return err
+	}
+	nextValidators, err := NextValidators(header, validators)
+	if err != nil {
+		return err
+	}
+	err = this.store.SaveHeader(header, nextValidators)
+	if err != nil {
+		return fmt.Errorf("save header:%d error:%s", header.Height, err)
+	}
+	this.lock.Lock()
+	this.current = header
+	this.validators = nextValidators
+	this.lock.Unlock()
+	return nil
+}
+
+//GetTrustedHeight return height of the latest trusted header
+func (this *LightClient) GetTrustedHeight() uint32 {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.current.Height
+}
+
+//GetCurrentHeader return the latest trusted header
+func (this *LightClient) GetCurrentHeader() *types.Header {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.current
+}
+
+//GetValidators return the validator set of blocks after the latest trusted header
+func (this *LightClient) GetValidators() []keypair.PublicKey {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.validators
+}
+
+//GetTrustedHeader return trusted header of height. Header above trusted height is synced first,
+//so it can be used as getTrustedHeader of ClientMgr.VerifyTxMerkleProof.
+func (this *LightClient) GetTrustedHeader(height uint32) (*types.Header, error) {
+	if height > this.GetTrustedHeight() {
+		err := this.SyncTo(height)
+		if err != nil {
+			return nil, fmt.Errorf("sync to height:%d error:%s", height, err)
+		}
+	}
+	header, err := this.store.GetHeader(height)
+	if err != nil {
+		return nil, fmt.Errorf("GetHeader:%d error:%s", height, err)
+	}
+	if header == nil {
+		return nil, fmt.Errorf("%w, height:%d", ERR_HEADER_NOT_TRUSTED, height)
+	}
+	return header, nil
+}
+
+//VerifyTxMerkleProof verify that transaction is in the trusted header chain by merkle proof from node
+func (this *LightClient) VerifyTxMerkleProof(txHash string) (*client.MerkleProofResult, error) {
+	return this.sdk.VerifyTxMerkleProof(txHash, this.GetTrustedHeader)
+}
+
+//VerifyBlock verify that block matches the trusted header of its height, and its transactions match TransactionsRoot.
+//Events and storage changes of verified transactions can be trusted as much as the validators.
+func (this *LightClient) VerifyBlock(block *types.Block) error {
+	trusted, err := this.GetTrustedHeader(block.Header.Height)
+	if err != nil {
+		return err
+	}
+	if trusted.Hash() != block.Hash() {
+		return fmt.Errorf("%w, height:%d hash doesn't match", ERR_BLOCK_MISMATCH, block.Header.Height)
+	}
+	txHashes := make([]common.Uint256, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		txHashes = append(txHashes, tx.Hash())
+	}
+	if common.ComputeMerkleRoot(txHashes) != trusted.TransactionsRoot {
+		return fmt.Errorf("%w, height:%d transactions root doesn't match", ERR_BLOCK_MISMATCH, block.Header.Height)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package lightclient
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/DNAProject/DNA/common"
+	"github.com/DNAProject/DNA/core/signature"
+	"github.com/DNAProject/DNA/core/types"
+	"github.com/ontio/ontology-crypto/keypair"
+)
+
+//Errors of header verification, wrapped with height of header
+var (
+	ERR_HEADER_HEIGHT     = errors.New("header height is not the next height of trusted header")
+	ERR_HEADER_PREV_HASH  = errors.New("header prev block hash doesn't match hash of trusted header")
+	ERR_HEADER_TIMESTAMP  = errors.New("header timestamp is not later than trusted header")
+	ERR_HEADER_BOOKKEEPER = errors.New("header bookkeepers are not the validators of trusted header")
+	ERR_HEADER_SIGNATURE  = errors.New("header signatures are not enough")
+)
+
+var (
+	//ERR_HEADER_NOT_TRUSTED is the error of header above the trusted height is requested
+	ERR_HEADER_NOT_TRUSTED = errors.New("header is not trusted yet")
+	//ERR_NO_TRUSTED_HEADER is the error of header store is empty and no checkpoint is given
+	ERR_NO_TRUSTED_HEADER = errors.New("no trusted header, checkpoint is required")
+	//ERR_NO_VALIDATORS is the error of checkpoint is given without validator set
+	ERR_NO_VALIDATORS = errors.New("validator set of checkpoint is required")
+	//ERR_BLOCK_MISMATCH is the error of block doesn't match the trusted header
+	ERR_BLOCK_MISMATCH = errors.New("block doesn't match trusted header")
+)
+
+//vbftBlockInfo is the consensus payload of vbft block, only validators are decoded
+type vbftBlockInfo struct {
+	NewChainConfig *struct {
+		Peers []*struct {
+			Index uint32 `json:"index"`
+			ID    string `json:"id"`
+		} `json:"peers"`
+	} `json:"new_chain_config"`
+}
+
+//VerifyHeader verify that header is the next header of the trusted header prev.
+//
+//Bookkeepers of header must be distinct. If NextBookkeeper of prev is set, bookkeepers of header must be
+//the committed ones, and signatures of n-(n-1)/3 bookkeepers are required.
+//Otherwise bookkeepers of header must be in validators, and signatures of 2/3 distinct validators are required.
+func VerifyHeader(prev, header *types.Header, validators []keypair.PublicKey) error {
+	if header.Height != prev.Height+1 {
+		return fmt.Errorf("%w, height:%d trusted height:%d", ERR_HEADER_HEIGHT, header.Height, prev.Height)
+	}
+	prevHash := prev.Hash()
+	if header.PrevBlockHash != prevHash {
+		return fmt.Errorf("%w, height:%d", ERR_HEADER_PREV_HASH, header.Height)
+	}
+	if header.Timestamp <= prev.Timestamp {
+		return fmt.Errorf("%w, height:%d", ERR_HEADER_TIMESTAMP, header.Height)
+	}
+	if len(header.Bookkeepers) == 0 {
+		return fmt.Errorf("%w, height:%d has no bookkeeper", ERR_HEADER_BOOKKEEPER, header.Height)
+	}
+	//VerifyMultiSignature count signatures by index of key, so duplicate bookkeeper would be counted more than once
+	bookkeepers := make(map[string]bool, len(header.Bookkeepers))
+	for _, bookkeeper := range header.Bookkeepers {
+		key := hex.EncodeToString(keypair.SerializePublicKey(bookkeeper))
+		if bookkeepers[key] {
+			return fmt.Errorf("%w, height:%d duplicate bookkeeper:%s", ERR_HEADER_BOOKKEEPER, header.Height, key)
+		}
+		bookkeepers[key] = true
+	}
+	var m int
+	if prev.NextBookkeeper != common.ADDRESS_EMPTY {
+		address, err := types.AddressFromBookkeepers(header.Bookkeepers)
+		if err != nil {
+			return fmt.Errorf("AddressFromBookkeepers error:%s", err)
+		}
+		if address != prev.NextBookkeeper {
+			return fmt.Errorf("%w, height:%d", ERR_HEADER_BOOKKEEPER, header.Height)
+		}
+		n := len(header.Bookkeepers)
+		m = n - (n-1)/3
+	} else {
+		validatorSet := make(map[string]bool, len(validators))
+		for _, validator := range validators {
+			validatorSet[hex.EncodeToString(keypair.SerializePublicKey(validator))] = true
+		}
+		if len(validatorSet) == 0 {
+			return fmt.Errorf("%w, height:%d validator set is empty", ERR_HEADER_BOOKKEEPER, header.Height)
+		}
+		for key := range bookkeepers {
+			if !validatorSet[key] {
+				return fmt.Errorf("%w, height:%d", ERR_HEADER_BOOKKEEPER, header.Height)
+			}
+		}
+		m = (2*len(validatorSet) + 2) / 3
+		if len(bookkeepers) < m {
+			return fmt.Errorf("%w, height:%d bookkeepers:%d required:%d", ERR_HEADER_SIGNATURE, header.Height, len(bookkeepers), m)
+		}
+	}
+	hash := header.Hash()
+	err := signature.VerifyMultiSignature(hash[:], header.Bookkeepers, m, header.SigData)
+	if err != nil {
+		return fmt.Errorf("%w, height:%d error:%s", ERR_HEADER_SIGNATURE, header.Height, err)
+	}
+	return nil
+}
+
+//NextValidators return the validator set of blocks after header. If header carries a new vbft chain config,
+//validators of the config are returned, otherwise validators is returned unchanged.
+func NextValidators(header *types.Header, validators []keypair.PublicKey) ([]keypair.PublicKey, error) {
+	if len(header.ConsensusPayload) == 0 {
+		return validators, nil
+	}
+	info := &vbftBlockInfo{}
+	err := json.Unmarshal(header.ConsensusPayload, info)
+	if err != nil || info.NewChainConfig == nil {
+		//Not a vbft block or chain config unchanged
+		return validators, nil
+	}
+	next := make([]keypair.PublicKey, 0, len(info.NewChainConfig.Peers))
+	for _, peer := range info.NewChainConfig.Peers {
+		data, err := hex.DecodeString(peer.ID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer id:%s of height:%d error:%s", peer.ID, header.Height, err)
+		}
+		pubKey, err := keypair.DeserializePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("DeserializePublicKey peer id:%s of height:%d error:%s", peer.ID, header.Height, err)
+		}
+		next = append(next, pubKey)
+	}
+	return next, nil
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package lightclient
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dnaSdk "github.com/DNAProject/DNA-go-sdk"
+	"github.com/DNAProject/DNA/core/types"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSignedHeader(t *testing.T, prev *types.Header, signers []*dnaSdk.Account) *types.Header {
+	header := &types.Header{
+		Height:    prev.Height + 1,
+		Timestamp: prev.Timestamp + 1,
+	}
+	header.PrevBlockHash = prev.Hash()
+	for _, signer := range signers {
+		header.Bookkeepers = append(header.Bookkeepers, signer.PublicKey)
+	}
+	hash := header.Hash()
+	for _, signer := range signers {
+		sig, err := signer.Sign(hash[:])
+		assert.Nil(t, err)
+		header.SigData = append(header.SigData, sig)
+	}
+	return header
+}
+
+func TestVerifyHeader(t *testing.T) {
+	signers := []*dnaSdk.Account{dnaSdk.NewAccount(), dnaSdk.NewAccount(), dnaSdk.NewAccount(), dnaSdk.NewAccount()}
+	validators := make([]keypair.PublicKey, 0, len(signers))
+	for _, signer := range signers {
+		validators = append(validators, signer.PublicKey)
+	}
+	checkpoint := &types.Header{Height: 10, Timestamp: 100}
+
+	header := newSignedHeader(t, checkpoint, signers[:3])
+	assert.Nil(t, VerifyHeader(checkpoint, header, validators))
+
+	notEnough := newSignedHeader(t, checkpoint, signers[:2])
+	assert.True(t, errors.Is(VerifyHeader(checkpoint, notEnough, validators), ERR_HEADER_SIGNATURE))
+
+	stranger := newSignedHeader(t, checkpoint, []*dnaSdk.Account{signers[0], signers[1], dnaSdk.NewAccount()})
+	assert.True(t, errors.Is(VerifyHeader(checkpoint, stranger, validators), ERR_HEADER_BOOKKEEPER))
+
+	//One validator key repeated to reach the quorum
+	duplicate := newSignedHeader(t, checkpoint, []*dnaSdk.Account{signers[0], signers[0], signers[0]})
+	assert.True(t, errors.Is(VerifyHeader(checkpoint, duplicate, validators), ERR_HEADER_BOOKKEEPER))
+	//Duplicate validators are counted once
+	assert.Nil(t, VerifyHeader(checkpoint, header, append(validators, validators[0])))
+
+	unlinked := newSignedHeader(t, &types.Header{Height: 10, Timestamp: 99}, signers)
+	assert.True(t, errors.Is(VerifyHeader(checkpoint, unlinked, validators), ERR_HEADER_PREV_HASH))
+
+	//Bookkeepers committed by NextBookkeeper of previous header
+	nextBookkeeper, err := types.AddressFromBookkeepers(validators)
+	assert.Nil(t, err)
+	committed := &types.Header{Height: 10, Timestamp: 100, NextBookkeeper: nextBookkeeper}
+	assert.Nil(t, VerifyHeader(committed, newSignedHeader(t, committed, signers), nil))
+	assert.True(t, errors.Is(VerifyHeader(committed, newSignedHeader(t, committed, signers[:3]), nil), ERR_HEADER_BOOKKEEPER))
+}
+
+func TestNewLightClientRequireValidators(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lightclient")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	store, err := OpenFileHeaderStore(filepath.Join(dir, "headers"))
+	assert.Nil(t, err)
+	defer store.Close()
+
+	signer := dnaSdk.NewAccount()
+	checkpoint := &types.Header{Height: 5, Timestamp: 100, Bookkeepers: []keypair.PublicKey{signer.PublicKey}}
+	_, err = NewLightClient(dnaSdk.NewDNASdk(), store, NewLightClientConfig(checkpoint, nil))
+	assert.True(t, errors.Is(err, ERR_NO_VALIDATORS))
+	lc, err := NewLightClient(dnaSdk.NewDNASdk(), store, NewLightClientConfig(checkpoint, []keypair.PublicKey{signer.PublicKey}))
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(5), lc.GetTrustedHeight())
+}
@@ -315,8 +315,9 @@ func (this *Gas) TotalSupply() (uint64, error) {
 }
 
 type OntId struct {
-	dnaSkd *DNASdk
-	native *NativeContract
+	dnaSkd      *DNASdk
+	native      *NativeContract
+	attrSchemas *AttributeSchemaRegistry
 }
 
 func (this *OntId) NewRegIDWithPublicKeyTransaction(gasPrice, gasLimit uint64, ontId string, pubKey keypair.PublicKey) (*types.MutableTransaction, error) {
@@ -361,6 +362,10 @@ func (this *OntId) NewRegIDWithAttributesTransaction(gasPrice, gasLimit uint64,
 		PubKey     []byte
 		Attributes []*DDOAttribute
 	}
+	err := this.validateAttributes(attributes)
+	if err != nil {
+		return nil, err
+	}
 	return this.native.NewNativeInvokeTransaction(
 		gasPrice,
 		gasLimit,
@@ -407,6 +412,10 @@ func (this *OntId) GetDDO(ontId string) (*DDO, error) {
 	if err != nil {
 		return nil, err
 	}
+	return this.getDDO(ontId, data)
+}
+
+func (this *OntId) getDDO(ontId string, data []byte) (*DDO, error) {
 	buf := bytes.NewBuffer(data)
 	keyData, err := serialization.ReadVarBytes(buf)
 	if err != nil {
@@ -480,6 +489,44 @@ func (this *OntId) AddKey(gasPrice, gasLimit uint64, ontId string, signer *Accou
 	return this.dnaSkd.SendTransaction(tx)
 }
 
+//NewAddKeyByRecoveryTransaction return transaction to add key authorized by recovery address of identity
+func (this *OntId) NewAddKeyByRecoveryTransaction(gasPrice, gasLimit uint64, ontId string, newPubKey keypair.PublicKey, recovery common.Address) (*types.MutableTransaction, error) {
+	type addKey struct {
+		OntId     string
+		NewPubKey []byte
+		Recovery  []byte
+	}
+	return this.native.NewNativeInvokeTransaction(
+		gasPrice,
+		gasLimit,
+		ONT_ID_CONTRACT_VERSION,
+		ONT_ID_CONTRACT_ADDRESS,
+		"addKey",
+		[]interface{}{
+			&addKey{
+				OntId:     ontId,
+				NewPubKey: keypair.SerializePublicKey(newPubKey),
+				Recovery:  recovery[:],
+			},
+		})
+}
+
+func (this *OntId) AddKeyByRecovery(gasPrice, gasLimit uint64, ontId string, signer *Account, newPubKey keypair.PublicKey, recovery *Account) (common.Uint256, error) {
+	tx, err := this.NewAddKeyByRecoveryTransaction(gasPrice, gasLimit, ontId, newPubKey, recovery.Address)
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	err = this.dnaSkd.SignToTransaction(tx, signer)
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	err = this.dnaSkd.SignToTransaction(tx, recovery)
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	return this.dnaSkd.SendTransaction(tx)
+}
+
 func (this *OntId) NewRevokeKeyTransaction(gasPrice, gasLimit uint64, ontId string, removedPubKey, pubKey keypair.PublicKey) (*types.MutableTransaction, error) {
 	type removeKey struct {
 		OntId      string
@@ -502,6 +549,29 @@ func (this *OntId) NewRevokeKeyTransaction(gasPrice, gasLimit uint64, ontId stri
 	)
 }
 
+//NewRevokeKeyByRecoveryTransaction return transaction to revoke key authorized by recovery address of identity
+func (this *OntId) NewRevokeKeyByRecoveryTransaction(gasPrice, gasLimit uint64, ontId string, removedPubKey keypair.PublicKey, recovery common.Address) (*types.MutableTransaction, error) {
+	type removeKey struct {
+		OntId      string
+		RemovedKey []byte
+		Recovery   []byte
+	}
+	return this.native.NewNativeInvokeTransaction(
+		gasPrice,
+		gasLimit,
+		ONT_ID_CONTRACT_VERSION,
+		ONT_ID_CONTRACT_ADDRESS,
+		"removeKey",
+		[]interface{}{
+			&removeKey{
+				OntId:      ontId,
+				RemovedKey: keypair.SerializePublicKey(removedPubKey),
+				Recovery:   recovery[:],
+			},
+		},
+	)
+}
+
 func (this *OntId) RevokeKey(gasPrice, gasLimit uint64, ontId string, signer *Account, removedPubKey keypair.PublicKey, controller *Controller) (common.Uint256, error) {
 	tx, err := this.NewRevokeKeyTransaction(gasPrice, gasLimit, ontId, removedPubKey, controller.PublicKey)
 	if err != nil {
@@ -598,6 +668,10 @@ func (this *OntId) NewAddAttributesTransaction(gasPrice, gasLimit uint64, ontId
 		Attributes []*DDOAttribute
 		PubKey     []byte
 	}
+	err := this.validateAttributes(attributes)
+	if err != nil {
+		return nil, err
+	}
 	return this.native.NewNativeInvokeTransaction(
 		gasPrice,
 		gasLimit,
@@ -766,7 +840,7 @@ func (this *OntId) getPublicKeys(ontId string, data []byte) ([]*DDOOwner, error)
 		if err != nil {
 			return nil, fmt.Errorf("index ReadUint32 error:%s", err)
 		}
-		pubKeyId := fmt.Sprintf("%s#keys-%d", ontId, index)
+		pubKeyId := GetKeyId(ontId, index)
 		pkData, err := serialization.ReadVarBytes(buf)
 		if err != nil {
 			return nil, fmt.Errorf("PubKey Idenx:%d ReadVarBytes error:%s", index, err)
@@ -809,6 +883,34 @@ func (this *OntId) GetKeyState(ontId string, keyIndex int) (string, error) {
 	return preResult.Result.ToString()
 }
 
+//getActiveKeys return keys of identity on chain, excluding revoked keys
+func (this *OntId) getActiveKeys(ontId string) ([]*DDOOwner, error) {
+	owners, err := this.GetPublicKeys(ontId)
+	if err != nil {
+		return nil, fmt.Errorf("GetPublicKeys of:%s error:%s", ontId, err)
+	}
+	activeKeys, _, err := this.splitRevokedKeys(ontId, owners)
+	return activeKeys, err
+}
+
+//splitRevokedKeys split keys of identity into active keys and revoked keys by GetKeyState
+func (this *OntId) splitRevokedKeys(ontId string, owners []*DDOOwner) ([]*DDOOwner, []*DDOOwner, error) {
+	activeKeys := make([]*DDOOwner, 0, len(owners))
+	revokedKeys := make([]*DDOOwner, 0)
+	for _, owner := range owners {
+		state, err := this.GetKeyState(ontId, int(owner.GetIndex()))
+		if err != nil {
+			return nil, nil, fmt.Errorf("GetKeyState of key:%s error:%s", owner.PubKeyId, err)
+		}
+		if state == KEY_STATUS_REVOKE {
+			revokedKeys = append(revokedKeys, owner)
+		} else {
+			activeKeys = append(activeKeys, owner)
+		}
+	}
+	return activeKeys, revokedKeys, nil
+}
+
 type GlobalParam struct {
 	dnaSkd *DNASdk
 	native *NativeContract
@@ -244,6 +244,12 @@ func (this *Oep4) parseTransferEvent(contractEvt *scomm.SmartContactEvent) []*Oe
 	return result
 }
 
+//ParseTransferEvent parse notify of oep4 contract to Oep4TransferEvent.
+//Notify of other contract may also be parsed, caller should check the contract address and event name.
+func ParseTransferEvent(notify *scomm.NotifyEventInfo) (*Oep4TransferEvent, error) {
+	return parseOep4TransferEvent(notify)
+}
+
 func parseOep4TransferEvent(notify *scomm.NotifyEventInfo) (*Oep4TransferEvent, error) {
 	state, ok := notify.States.([]interface{})
 	if !ok {
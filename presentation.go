@@ -0,0 +1,235 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var DEFAULT_CHALLENGE_TTL = 5 * time.Minute
+
+const (
+	PRESENTATION_TYPE   = "VerifiablePresentation"
+	CHALLENGE_NONCE_LEN = 32
+)
+
+//ERR_INVALID_PRESENTATION is returned when presentation or its proof is malformed
+var ERR_INVALID_PRESENTATION = errors.New("invalid presentation")
+
+//ERR_CHALLENGE_NOT_FOUND is returned when challenge of presentation is unknown, expired or already used
+var ERR_CHALLENGE_NOT_FOUND = errors.New("challenge not found")
+
+//ERR_AUDIENCE_MISMATCH is returned when presentation is not bound to the audience of verifier
+var ERR_AUDIENCE_MISMATCH = errors.New("audience mismatch")
+
+//VerifiablePresentation is W3C verifiable presentation signed by holder
+type VerifiablePresentation struct {
+	Context              []string                `json:"@context"`
+	Id                   string                  `json:"id,omitempty"`
+	Type                 []string                `json:"type"`
+	Holder               string                  `json:"holder"`
+	VerifiableCredential []*VerifiableCredential `json:"verifiableCredential,omitempty"`
+	Proof                *CredentialProof        `json:"proof,omitempty"`
+}
+
+//NewVerifiablePresentation return unsigned presentation of holder, which bundles credentials with JSON-LD proof
+func NewVerifiablePresentation(holder string, credentials ...*VerifiableCredential) (*VerifiablePresentation, error) {
+	id, err := newUrnUUID()
+	if err != nil {
+		return nil, err
+	}
+	return &VerifiablePresentation{
+		Context:              []string{CREDENTIAL_CONTEXT},
+		Id:                   id,
+		Type:                 []string{PRESENTATION_TYPE},
+		Holder:               holder,
+		VerifiableCredential: credentials,
+	}, nil
+}
+
+//AuthChallenge is the challenge sent by verifier, which should be signed in presentation of holder
+type AuthChallenge struct {
+	Nonce    string `json:"nonce"`
+	Domain   string `json:"domain"`   //Audience of presentation, such as domain of service
+	ExpireAt int64  `json:"expireAt"` //Unix time
+}
+
+//SignPresentation add proof of authentication to presentation, which is bound to challenge and signed by key of holder.
+//keyId is the id of public key in DID document of holder, such as did:ont:xxx#keys-1.
+func SignPresentation(vp *VerifiablePresentation, signer Signer, keyId string, challenge *AuthChallenge) error {
+	if getDIDOfKeyId(keyId) != vp.Holder {
+		return fmt.Errorf("%w, key:%s is not a key of holder:%s", ERR_INVALID_PRESENTATION, keyId, vp.Holder)
+	}
+	proof := &CredentialProof{
+		Type:               CREDENTIAL_PROOF_TYPE,
+		Created:            formatCredentialTime(time.Now()),
+		VerificationMethod: keyId,
+		ProofPurpose:       PROOF_PURPOSE_AUTHENTICATION,
+		Challenge:          challenge.Nonce,
+		Domain:             challenge.Domain,
+	}
+	vp.Proof = proof
+	payload, err := canonicalJSON(vp)
+	if err != nil {
+		vp.Proof = nil
+		return err
+	}
+	jws, err := signDetachedJWS(signer, payload)
+	if err != nil {
+		vp.Proof = nil
+		return err
+	}
+	proof.Jws = jws
+	return nil
+}
+
+//NonceStore keep nonces of issued challenges for replay protection. It may be shared by verifiers of a cluster.
+type NonceStore interface {
+	//Put save nonce until expireAt
+	Put(nonce string, expireAt time.Time) error
+	//Consume remove nonce and return whether it existed and not expired
+	Consume(nonce string) (bool, error)
+}
+
+//MemNonceStore is an in-memory NonceStore
+type MemNonceStore struct {
+	nonces map[string]time.Time
+	lock   sync.Mutex
+}
+
+func NewMemNonceStore() *MemNonceStore {
+	return &MemNonceStore{nonces: make(map[string]time.Time)}
+}
+
+func (this *MemNonceStore) Put(nonce string, expireAt time.Time) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	now := time.Now()
+	for n, expire := range this.nonces {
+		if now.After(expire) {
+			delete(this.nonces, n)
+		}
+	}
+	this.nonces[nonce] = expireAt
+	return nil
+}
+
+func (this *MemNonceStore) Consume(nonce string) (bool, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	expireAt, ok := this.nonces[nonce]
+	if !ok {
+		return false, nil
+	}
+	delete(this.nonces, nonce)
+	return !time.Now().After(expireAt), nil
+}
+
+//PresentationVerifyConfig config of PresentationVerifier
+type PresentationVerifyConfig struct {
+	Audience         string                  //Domain which presentation must be bound to
+	ChallengeTTL     time.Duration           //DEFAULT_CHALLENGE_TTL is used if 0
+	NonceStore       NonceStore              //MemNonceStore is used if nil
+	CredentialConfig *CredentialVerifyConfig //Config to verify credentials in presentation
+}
+
+//PresentationVerifier issue challenges and verify presentations signed by holders, for login with on-chain identity
+type PresentationVerifier struct {
+	ontId  *OntId
+	config *PresentationVerifyConfig
+}
+
+//NewPresentationVerifier return verifier of presentations bound to config.Audience
+func (this *OntId) NewPresentationVerifier(config *PresentationVerifyConfig) *PresentationVerifier {
+	verifyConfig := *config
+	if verifyConfig.ChallengeTTL == 0 {
+		verifyConfig.ChallengeTTL = DEFAULT_CHALLENGE_TTL
+	}
+	if verifyConfig.NonceStore == nil {
+		verifyConfig.NonceStore = NewMemNonceStore()
+	}
+	return &PresentationVerifier{
+		ontId:  this,
+		config: &verifyConfig,
+	}
+}
+
+//NewChallenge return a challenge with random nonce, which can be used once before expired
+func (this *PresentationVerifier) NewChallenge() (*AuthChallenge, error) {
+	var buf [CHALLENGE_NONCE_LEN]byte
+	_, err := rand.Read(buf[:])
+	if err != nil {
+		return nil, fmt.Errorf("generate nonce error:%s", err)
+	}
+	expireAt := time.Now().Add(this.config.ChallengeTTL)
+	challenge := &AuthChallenge{
+		Nonce:    base64.RawURLEncoding.EncodeToString(buf[:]),
+		Domain:   this.config.Audience,
+		ExpireAt: expireAt.Unix(),
+	}
+	err = this.config.NonceStore.Put(challenge.Nonce, expireAt)
+	if err != nil {
+		return nil, fmt.Errorf("save nonce error:%s", err)
+	}
+	return challenge, nil
+}
+
+//Verify verify presentation against current keys of holder on chain, and the credentials in it.
+//The challenge of presentation is consumed once signature is valid, so the presentation cannot be replayed.
+func (this *PresentationVerifier) Verify(vp *VerifiablePresentation) error {
+	proof := vp.Proof
+	if proof == nil {
+		return fmt.Errorf("%w, presentation without proof", ERR_INVALID_PRESENTATION)
+	}
+	if proof.Type != CREDENTIAL_PROOF_TYPE {
+		return fmt.Errorf("%w, unsupported proof type:%s", ERR_INVALID_PRESENTATION, proof.Type)
+	}
+	if proof.ProofPurpose != PROOF_PURPOSE_AUTHENTICATION {
+		return fmt.Errorf("%w, proof purpose:%s", ERR_INVALID_PRESENTATION, proof.ProofPurpose)
+	}
+	if proof.Domain != this.config.Audience {
+		return fmt.Errorf("%w, domain:%s", ERR_AUDIENCE_MISMATCH, proof.Domain)
+	}
+	if proof.Challenge == "" {
+		return fmt.Errorf("%w, proof without challenge", ERR_INVALID_PRESENTATION)
+	}
+	if getDIDOfKeyId(proof.VerificationMethod) != vp.Holder {
+		return fmt.Errorf("%w, key:%s is not a key of holder:%s", ERR_INVALID_PRESENTATION, proof.VerificationMethod, vp.Holder)
+	}
+	err := this.ontId.dnaSkd.GetDIDMethod().CheckID(vp.Holder)
+	if err != nil {
+		return err
+	}
+	ddo, err := this.ontId.GetDDO(vp.Holder)
+	if err != nil {
+		return fmt.Errorf("GetDDO of:%s error:%s", vp.Holder, err)
+	}
+	pubKey, err := this.ontId.getActivePublicKey(vp.Holder, proof.VerificationMethod, ddo.Owners)
+	if err != nil {
+		return err
+	}
+	err = verifyDetachedJWS(pubKey, vp, proof)
+	if err != nil {
+		return err
+	}
+	ok, err := this.config.NonceStore.Consume(proof.Challenge)
+	if err != nil {
+		return fmt.Errorf("consume nonce error:%s", err)
+	}
+	if !ok {
+		return ERR_CHALLENGE_NOT_FOUND
+	}
+	for _, vc := range vp.VerifiableCredential {
+		err = this.ontId.VerifyCredential(vc, this.config.CredentialConfig)
+		if err != nil {
+			return fmt.Errorf("verify credential:%s error:%w", vc.Id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	s "github.com/ontio/ontology-crypto/signature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemNonceStore(t *testing.T) {
+	store := NewMemNonceStore()
+	assert.Nil(t, store.Put("a", time.Now().Add(time.Minute)))
+	assert.Nil(t, store.Put("b", time.Now().Add(-time.Minute)))
+	ok, err := store.Consume("a")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, _ = store.Consume("a")
+	assert.False(t, ok)
+	ok, _ = store.Consume("b")
+	assert.False(t, ok)
+}
+
+func TestPresentation(t *testing.T) {
+	holder, err := GenerateID()
+	assert.Nil(t, err)
+	keyId := holder + "#keys-1"
+	controller := newTestController(t, keypair.PK_ECDSA, keypair.P256, s.SHA256withECDSA)
+
+	verifier := (&OntId{}).NewPresentationVerifier(&PresentationVerifyConfig{Audience: "example.com"})
+	challenge, err := verifier.NewChallenge()
+	assert.Nil(t, err)
+	assert.Equal(t, "example.com", challenge.Domain)
+
+	vp, err := NewVerifiablePresentation(holder)
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(SignPresentation(vp, controller, "did:ont:other#keys-1", challenge), ERR_INVALID_PRESENTATION))
+	assert.Nil(t, SignPresentation(vp, controller, keyId, challenge))
+	assert.Equal(t, challenge.Nonce, vp.Proof.Challenge)
+	assert.Nil(t, verifyDetachedJWS(controller.PublicKey, vp, vp.Proof))
+
+	vp.Proof.Domain = "evil.com"
+	assert.True(t, errors.Is(verifier.Verify(vp), ERR_AUDIENCE_MISMATCH))
+	assert.True(t, errors.Is(verifyDetachedJWS(controller.PublicKey, vp, vp.Proof), ERR_CREDENTIAL_SIGNATURE))
+}
@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/DNAProject/DNA-go-sdk/utils"
+	"github.com/DNAProject/DNA/common"
+	"github.com/DNAProject/DNA/common/constants"
+	"github.com/DNAProject/DNA/core/types"
+	"github.com/ontio/ontology-crypto/keypair"
+)
+
+//ERR_RECOVERY_NOT_SIGNED is returned when transaction doesn't have enough signatures of guardians in recovery group
+var ERR_RECOVERY_NOT_SIGNED = errors.New("recovery not signed")
+
+//RecoveryGroup is m-of-n guardians, whose multi-signature address is used as recovery of identity
+type RecoveryGroup struct {
+	M       uint16
+	PubKeys []keypair.PublicKey
+}
+
+func NewRecoveryGroup(m uint16, pubKeys []keypair.PublicKey) (*RecoveryGroup, error) {
+	size := len(pubKeys)
+	if m == 0 || int(m) > size || size > constants.MULTI_SIG_MAX_PUBKEY_SIZE {
+		return nil, fmt.Errorf("m:%d and number of guardians:%d must larger than 0, not larger than %d, and m must not larger than number of guardians",
+			m, size, constants.MULTI_SIG_MAX_PUBKEY_SIZE)
+	}
+	for i := 0; i < size; i++ {
+		for j := i + 1; j < size; j++ {
+			if keypair.ComparePublicKey(pubKeys[i], pubKeys[j]) {
+				return nil, fmt.Errorf("duplicate guardian:%s", types.AddressFromPubKey(pubKeys[i]).ToBase58())
+			}
+		}
+	}
+	return &RecoveryGroup{
+		M:       m,
+		PubKeys: pubKeys,
+	}, nil
+}
+
+//Address return multi-signature address of group, which is set as recovery of identity
+func (this *RecoveryGroup) Address() (common.Address, error) {
+	address, err := types.AddressFromMultiPubKeys(this.PubKeys, int(this.M))
+	if err != nil {
+		return common.ADDRESS_EMPTY, fmt.Errorf("AddressFromMultiPubKeys error:%s", err)
+	}
+	return address, nil
+}
+
+func (this *RecoveryGroup) IsGuardian(pubKey keypair.PublicKey) bool {
+	for _, pk := range this.PubKeys {
+		if keypair.ComparePublicKey(pk, pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+//RecoverySignStatus is signatures of guardians in transaction
+type RecoverySignStatus struct {
+	Signed   []keypair.PublicKey //Guardians have signed
+	Pending  []keypair.PublicKey //Guardians haven't signed
+	Required int                 //Number of signatures still required
+}
+
+func (this *RecoverySignStatus) IsComplete() bool {
+	return this.Required == 0
+}
+
+//GetSignStatus return which guardians have signed transaction, and how many signatures still required
+func (this *RecoveryGroup) GetSignStatus(tx *types.MutableTransaction) *RecoverySignStatus {
+	status := &RecoverySignStatus{
+		Signed:  make([]keypair.PublicKey, 0),
+		Pending: make([]keypair.PublicKey, 0),
+	}
+	sigData := this.getSigData(tx)
+	txHash := tx.Hash()
+	for _, pk := range this.PubKeys {
+		if utils.HasAlreadySig(txHash.ToArray(), pk, sigData) {
+			status.Signed = append(status.Signed, pk)
+		} else {
+			status.Pending = append(status.Pending, pk)
+		}
+	}
+	if len(status.Signed) < int(this.M) {
+		status.Required = int(this.M) - len(status.Signed)
+	}
+	return status
+}
+
+func (this *RecoveryGroup) getSigData(tx *types.MutableTransaction) [][]byte {
+	for _, sig := range tx.Sigs {
+		if utils.PubKeysEqual(sig.PubKeys, this.PubKeys) {
+			return sig.SigData
+		}
+	}
+	return nil
+}
+
+//Sign add signature of guardian to transaction. Signatures are kept in order of sorted public keys,
+//so guardians can sign in any order. Payer of transaction must be set before, otherwise group will be the payer.
+func (this *RecoveryGroup) Sign(sdk *DNASdk, tx *types.MutableTransaction, guardian Signer) error {
+	if !this.IsGuardian(guardian.GetPublicKey()) {
+		return fmt.Errorf("signer:%s is not guardian of recovery", types.AddressFromPubKey(guardian.GetPublicKey()).ToBase58())
+	}
+	err := sdk.MultiSignToTransaction(tx, this.M, this.PubKeys, guardian)
+	if err != nil {
+		return err
+	}
+	txHash := tx.Hash()
+	for i, sig := range tx.Sigs {
+		if !utils.PubKeysEqual(sig.PubKeys, this.PubKeys) {
+			continue
+		}
+		sigData := make([][]byte, 0, len(sig.SigData))
+		for _, pk := range keypair.SortPublicKeys(this.PubKeys) {
+			for _, data := range sig.SigData {
+				if utils.HasAlreadySig(txHash.ToArray(), pk, [][]byte{data}) {
+					sigData = append(sigData, data)
+					break
+				}
+			}
+		}
+		sig.SigData = sigData
+		tx.Sigs[i] = sig
+		break
+	}
+	return nil
+}
+
+//SignAndCheck add signatures of guardians to transaction, and return ERR_RECOVERY_NOT_SIGNED if signatures are not enough
+func (this *RecoveryGroup) SignAndCheck(sdk *DNASdk, tx *types.MutableTransaction, guardians []Signer) error {
+	for _, guardian := range guardians {
+		err := this.Sign(sdk, tx, guardian)
+		if err != nil {
+			return err
+		}
+	}
+	status := this.GetSignStatus(tx)
+	if !status.IsComplete() {
+		return fmt.Errorf("%w, signed:%d required:%d more", ERR_RECOVERY_NOT_SIGNED, len(status.Signed), status.Required)
+	}
+	return nil
+}
+
+//SetRecoveryGroup set multi-signature address of group as recovery of identity
+func (this *OntId) SetRecoveryGroup(gasPrice, gasLimit uint64, signer *Account, ontId string, group *RecoveryGroup, controller *Controller) (common.Uint256, error) {
+	recovery, err := group.Address()
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	return this.SetRecovery(gasPrice, gasLimit, signer, ontId, recovery, controller)
+}
+
+//ChangeRecoveryByGroup change recovery of identity from address of group to newRecovery, which is authorized by guardians of group
+func (this *OntId) ChangeRecoveryByGroup(gasPrice, gasLimit uint64, signer *Account, ontId string, newRecovery common.Address, group *RecoveryGroup, guardians []Signer) (common.Uint256, error) {
+	oldRecovery, err := group.Address()
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	tx, err := this.NewChangeRecoveryTransaction(gasPrice, gasLimit, ontId, newRecovery, oldRecovery)
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	return this.sendRecoveryTransaction(tx, signer, group, guardians)
+}
+
+//AddKeyByRecoveryGroup add key to identity, which is authorized by guardians of recovery group
+func (this *OntId) AddKeyByRecoveryGroup(gasPrice, gasLimit uint64, ontId string, signer *Account, newPubKey keypair.PublicKey, group *RecoveryGroup, guardians []Signer) (common.Uint256, error) {
+	recovery, err := group.Address()
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	tx, err := this.NewAddKeyByRecoveryTransaction(gasPrice, gasLimit, ontId, newPubKey, recovery)
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	return this.sendRecoveryTransaction(tx, signer, group, guardians)
+}
+
+//RevokeKeyByRecoveryGroup revoke key of identity, which is authorized by guardians of recovery group
+func (this *OntId) RevokeKeyByRecoveryGroup(gasPrice, gasLimit uint64, ontId string, signer *Account, removedPubKey keypair.PublicKey, group *RecoveryGroup, guardians []Signer) (common.Uint256, error) {
+	recovery, err := group.Address()
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	tx, err := this.NewRevokeKeyByRecoveryTransaction(gasPrice, gasLimit, ontId, removedPubKey, recovery)
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	return this.sendRecoveryTransaction(tx, signer, group, guardians)
+}
+
+func (this *OntId) sendRecoveryTransaction(tx *types.MutableTransaction, signer *Account, group *RecoveryGroup, guardians []Signer) (common.Uint256, error) {
+	err := this.dnaSkd.SignToTransaction(tx, signer)
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	err = group.SignAndCheck(this.dnaSkd, tx, guardians)
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	return this.dnaSkd.SendTransaction(tx)
+}
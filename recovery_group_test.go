@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+// Copyright 2019 the DNA Dev team
+//
+package DNA_go_sdk
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DNAProject/DNA-go-sdk/utils"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryGroupSign(t *testing.T) {
+	sdk := NewDNASdk()
+	guardians := []*Account{NewAccount(), NewAccount(), NewAccount()}
+	pubKeys := make([]keypair.PublicKey, 0, len(guardians))
+	for _, guardian := range guardians {
+		pubKeys = append(pubKeys, guardian.PublicKey)
+	}
+	_, err := NewRecoveryGroup(0, pubKeys)
+	assert.NotNil(t, err)
+	_, err = NewRecoveryGroup(4, pubKeys)
+	assert.NotNil(t, err)
+	_, err = NewRecoveryGroup(2, append(pubKeys, pubKeys[0]))
+	assert.NotNil(t, err)
+
+	group, err := NewRecoveryGroup(2, pubKeys)
+	assert.Nil(t, err)
+	recovery, err := group.Address()
+	assert.Nil(t, err)
+	multiAddr, err := sdk.GetMultiAddr(pubKeys, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, multiAddr, recovery.ToBase58())
+
+	ontId, err := GenerateID()
+	assert.Nil(t, err)
+	payer := NewAccount()
+	tx, err := sdk.Native.OntId.NewAddKeyByRecoveryTransaction(0, 20000, ontId, NewAccount().PublicKey, recovery)
+	assert.Nil(t, err)
+	assert.Nil(t, sdk.SignToTransaction(tx, payer))
+	assert.Equal(t, 3, len(group.GetSignStatus(tx).Pending))
+
+	assert.NotNil(t, group.Sign(sdk, tx, NewAccount()))
+	err = group.SignAndCheck(sdk, tx, []Signer{guardians[2]})
+	assert.True(t, errors.Is(err, ERR_RECOVERY_NOT_SIGNED))
+	status := group.GetSignStatus(tx)
+	assert.False(t, status.IsComplete())
+	assert.Equal(t, 1, status.Required)
+	assert.Equal(t, []keypair.PublicKey{guardians[0].PublicKey, guardians[1].PublicKey}, status.Pending)
+
+	assert.Nil(t, group.Sign(sdk, tx, guardians[0]))
+	assert.Nil(t, group.Sign(sdk, tx, guardians[0]))
+	status = group.GetSignStatus(tx)
+	assert.True(t, status.IsComplete())
+	assert.Equal(t, 2, len(status.Signed))
+	assert.Equal(t, payer.Address, tx.Payer)
+
+	//signatures are in order of sorted public keys
+	txHash := tx.Hash()
+	sigData := group.getSigData(tx)
+	assert.Equal(t, 2, len(sigData))
+	signed := make([]keypair.PublicKey, 0)
+	for _, pk := range keypair.SortPublicKeys(pubKeys) {
+		if keypair.ComparePublicKey(pk, guardians[0].PublicKey) || keypair.ComparePublicKey(pk, guardians[2].PublicKey) {
+			signed = append(signed, pk)
+		}
+	}
+	for i, pk := range signed {
+		assert.True(t, utils.HasAlreadySig(txHash.ToArray(), pk, [][]byte{sigData[i]}))
+	}
+}
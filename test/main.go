@@ -13,23 +13,33 @@ import (
 func main() {
 	testDnaSdk := sdk.NewDNASdk()
 	testDnaSdk.NewRpcClient().SetAddress("http://dappnode1.dna.io:20336")
-	for i := uint32(4513925); i > 100000; i++ {
-		block, err := testDnaSdk.GetBlockByHeight(i)
-		if err != nil {
-			fmt.Println("error: ", err)
-			return
-		}
-		for _, tx := range block.Transactions {
+	curHeight, err := testDnaSdk.GetCurrentBlockHeight()
+	if err != nil {
+		fmt.Println("error: ", err)
+		return
+	}
+	iterator, err := testDnaSdk.NewBlockIterator(4513925, curHeight, nil)
+	if err != nil {
+		fmt.Println("error: ", err)
+		return
+	}
+	iterator.Start()
+	defer iterator.Close()
+	for blockEvt := range iterator.Blocks() {
+		for _, tx := range blockEvt.Block.Transactions {
 			invokeCode, ok := tx.Payload.(*payload.InvokeCode)
 			if ok {
 				res, err := sdk.ParsePayload(invokeCode.Code)
 				if err != nil {
-					//fmt.Printf("error: %s, height:%d\n", err, i)
+					//fmt.Printf("error: %s, height:%d\n", err, blockEvt.Height)
 					continue
 				}
 				fmt.Println("res:", res)
-				fmt.Printf("height: %d\n", i)
+				fmt.Printf("height: %d\n", blockEvt.Height)
 			}
 		}
 	}
+	if iterator.Err() != nil {
+		fmt.Printf("error: %s, checkpoint:%d\n", iterator.Err(), iterator.Checkpoint())
+	}
 }
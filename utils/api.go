@@ -24,6 +24,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strconv"
 
 	sdkcom "github.com/DNAProject/DNA-go-sdk/common"
 	"github.com/DNAProject/DNA/common"
@@ -93,6 +94,28 @@ func GetUint256(data []byte) (common.Uint256, error) {
 	return hash, nil
 }
 
+//GetBalanceInfo decode balance of getbalance, all of fields are number strings
+func GetBalanceInfo(data []byte) (*sdkcom.BalanceInfo, error) {
+	fields := make(map[string]string)
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal balance:%s error:%s", data, err)
+	}
+	balance := &sdkcom.BalanceInfo{Balances: make(map[string]uint64, len(fields))}
+	for name, field := range fields {
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s:%s of balance error:%s", name, field, err)
+		}
+		if name == "height" {
+			balance.Height = uint32(value)
+			continue
+		}
+		balance.Balances[name] = value
+	}
+	return balance, nil
+}
+
 func GetTransaction(data []byte) (*types.Transaction, error) {
 	hexStr := ""
 	err := json.Unmarshal(data, &hexStr)
@@ -180,6 +203,18 @@ func GetMerkleProof(data []byte) (*sdkcom.MerkleProof, error) {
 	return proof, nil
 }
 
+func GetBlockInfo(data []byte) (*sdkcom.BlockInfo, error) {
+	blockInfo := &sdkcom.BlockInfo{}
+	err := json.Unmarshal(data, blockInfo)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal error:%s", err)
+	}
+	if blockInfo.Header == nil {
+		return nil, fmt.Errorf("block header is nil")
+	}
+	return blockInfo, nil
+}
+
 func GetBlockTxHashes(data []byte) (*sdkcom.BlockTxHashes, error) {
 	blockTxHashesStr := &sdkcom.BlockTxHashesStr{}
 	err := json.Unmarshal(data, &blockTxHashesStr)
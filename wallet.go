@@ -39,6 +39,7 @@ type Wallet struct {
 	identityLabelMap map[string]*Identity
 	defIdentity      *Identity
 	path             string
+	didMethod        *DIDMethod
 	dnaSdk           *DNASdk
 	lock             sync.RWMutex
 }
@@ -69,6 +70,7 @@ func OpenWallet(path string) (*Wallet, error) {
 	wallet.Version = walletData.Version
 	wallet.Scrypt = walletData.Scrypt
 	wallet.Extra = walletData.Extra
+	wallet.didMethod = walletData.DIDMethod
 	for _, accountData := range walletData.Accounts {
 		accountData.scrypt = wallet.Scrypt
 		if accountData.IsDefault {
@@ -451,8 +453,29 @@ func (this *Wallet) ExportAccounts(path string, accountDatas []*AccountData, pas
 	return newWallet, nil
 }
 
+//SetDIDMethod set DID method of identities in wallet, which is saved in wallet file
+func (this *Wallet) SetDIDMethod(method *DIDMethod) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.didMethod = method
+}
+
+//GetDIDMethod return DID method of identities in wallet, default DID method if not set
+func (this *Wallet) GetDIDMethod() *DIDMethod {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.getDIDMethod()
+}
+
+func (this *Wallet) getDIDMethod() *DIDMethod {
+	if this.didMethod == nil {
+		return DefaultDIDMethod()
+	}
+	return this.didMethod
+}
+
 func (this *Wallet) NewIdentity(keyType keypair.KeyType, curveCode byte, sigScheme s.SignatureScheme, passwd []byte) (*Identity, error) {
-	identity, err := NewIdentity(this.Scrypt)
+	identity, err := NewIdentityWithDIDMethod(this.Scrypt, this.GetDIDMethod())
 	if err != nil {
 		return nil, err
 	}
@@ -504,9 +527,28 @@ func (this *Wallet) SetDefaultIdentity(id string) error {
 func (this *Wallet) AddIdentity(identity *Identity) error {
 	this.lock.Lock()
 	defer this.lock.Unlock()
+	return this.addIdentity(identity)
+}
+
+//addIdentity add identity to wallet, lock of wallet should be held
+func (this *Wallet) addIdentity(identity *Identity) error {
+	err := this.getDIDMethod().CheckID(identity.ID)
+	if err != nil {
+		return fmt.Errorf("identity:%s error:%s", identity.ID, err)
+	}
+	if _, ok := this.identityMap[identity.ID]; ok {
+		return fmt.Errorf("%w, identity:%s", ERR_IDENTITY_EXISTS, identity.ID)
+	}
 	if this.defIdentity != nil && identity.IsDefault {
 		return fmt.Errorf("already have default identity")
 	}
+	if identity.Label != "" {
+		_, ok := this.identityLabelMap[identity.Label]
+		if ok {
+			return fmt.Errorf("duplicate identity label:%s", identity.Label)
+		}
+		this.identityLabelMap[identity.Label] = identity
+	}
 	if this.defIdentity == nil {
 		this.defIdentity = identity
 		identity.IsDefault = true
@@ -516,6 +558,57 @@ func (this *Wallet) AddIdentity(identity *Identity) error {
 	return nil
 }
 
+//replaceControllers delete controllers of pubKeys from identity, and add ctrData if its public key is not in identity.
+//Identity is added to wallet if not exist. Identity is unchanged if ctrData cannot be added.
+func (this *Wallet) replaceControllers(id string, pubKeys []string, ctrData *ControllerData) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	identity, found := this.identityMap[id]
+	if !found {
+		identity = &Identity{
+			ID:          id,
+			scrypt:      this.Scrypt,
+			controllers: make([]*ControllerData, 0),
+			ctrsIdMap:   make(map[string]*ControllerData),
+			ctrsPubMap:  make(map[string]*ControllerData),
+		}
+	}
+	revoked := make(map[string]bool, len(pubKeys))
+	for _, pubKey := range pubKeys {
+		revoked[pubKey] = true
+	}
+	_, exist := identity.ctrsPubMap[ctrData.Public]
+	exist = exist && !revoked[ctrData.Public]
+	if !exist {
+		if !ScryptEqual(ctrData.scrypt, identity.scrypt) {
+			return fmt.Errorf("controller:%s error:scrypt unmatch", ctrData.ID)
+		}
+		if old, ok := identity.ctrsIdMap[ctrData.ID]; ok && !revoked[old.Public] {
+			return fmt.Errorf("duplicate controller id:%s", ctrData.ID)
+		}
+	}
+	for _, pubKey := range pubKeys {
+		old, ok := identity.ctrsPubMap[pubKey]
+		if !ok {
+			continue
+		}
+		err := identity.DeleteControllerData(old.ID)
+		if err != nil {
+			return err
+		}
+	}
+	if !exist {
+		err := identity.AddControllerData(ctrData)
+		if err != nil {
+			return err
+		}
+	}
+	if !found {
+		return this.addIdentity(identity)
+	}
+	return nil
+}
+
 func (this *Wallet) DeleteIdentity(id string) error {
 	this.lock.Lock()
 	defer this.lock.Unlock()
@@ -614,6 +707,7 @@ func (this *Wallet) Save() error {
 		Identities: make([]*IdentityData, 0),
 		Accounts:   make([]*AccountData, 0),
 		Extra:      this.Extra,
+		DIDMethod:  this.didMethod,
 	}
 	for _, identity := range this.identities {
 		walletData.Identities = append(walletData.Identities, identity.ToIdentityData())
@@ -632,6 +726,7 @@ type WalletData struct {
 	Identities []*IdentityData      `json:"identities,omitempty"`
 	Accounts   []*AccountData       `json:"accounts,omitempty"`
 	Extra      string               `json:"extra,omitempty"`
+	DIDMethod  *DIDMethod           `json:"didMethod,omitempty"` //DID method of identities, did:ont if absent
 }
 
 func NewWalletData() *WalletData {
@@ -659,6 +754,7 @@ func (this *WalletData) Clone() *WalletData {
 	}
 	w.Identities = this.Identities
 	w.Extra = this.Extra
+	w.DIDMethod = this.DIDMethod
 	return &w
 }
 